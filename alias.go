@@ -0,0 +1,56 @@
+package dbf
+
+import "errors"
+
+// Alias lets code use logical names for fields whatever vintage of a
+// file it reads. aliases maps field names to logical names; names the
+// file does not have are passed over, so one map can list them all:
+//
+//	d.Alias(map[string]string{
+//		"STATEFP": "STATE", "STATEFP10": "STATE", "STATEFP00": "STATE",
+//	})
+//
+// The logical names then work wherever fields are named: Field,
+// Record.Get, expressions, Where, Project, CompositeKey, Scan tags and
+// ExportOptions.Fields. Exports still head columns with the field names.
+// Two fields for one logical name, or a logical name that is another
+// field's name, fail. nil removes the aliases.
+func (d *Dbf) Alias(aliases map[string]string) error {
+	byLogical := make(map[string]*DbfField, len(aliases))
+	for name, logical := range aliases {
+		f := d.field(name)
+		if f == nil {
+			continue
+		}
+		if other := byLogical[logical]; other != nil && other != f {
+			return errors.New("dbf alias " + logical + " is both " + other.Name + " and " + f.Name)
+		}
+		if other := d.field(logical); other != nil && other != f {
+			return errors.New("dbf alias " + logical + " for " + f.Name + " is the name of another field")
+		}
+		byLogical[logical] = f
+	}
+	d.aliases = byLogical
+	// Scan mappings may now find more fields
+	d.scan = nil
+	return nil
+}
+
+// Field is the field named name, or given that logical name by Alias, nil
+// if there is none
+func (d *Dbf) Field(name string) *DbfField {
+	if f := d.field(name); f != nil {
+		return f
+	}
+	return d.aliases[name]
+}
+
+// field is the field named name, without aliases
+func (d *Dbf) field(name string) *DbfField {
+	for i := range d.Fields {
+		if d.Fields[i].Name == name {
+			return &d.Fields[i]
+		}
+	}
+	return nil
+}
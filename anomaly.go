@@ -0,0 +1,104 @@
+package dbf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AnomalyKind is what an Anomaly found wrong with the header
+type AnomalyKind int
+
+const (
+	// AnomalyRecordLength is a NumRecordBytes other than the field widths
+	// plus the deletion flag
+	AnomalyRecordLength AnomalyKind = iota + 1
+	// AnomalyHeaderLength is a NumHeaderBytes that ends the header before
+	// the 0x0d terminator of the field descriptors
+	AnomalyHeaderLength
+	// AnomalyHeaderPadding is an unusual number of bytes between the
+	// terminator and NumHeaderBytes, for the version: none for dBASE III
+	// and IV, 263 for the Visual FoxPro backlink. The records still start
+	// at NumHeaderBytes, so it is only noted.
+	AnomalyHeaderPadding
+	// AnomalyRecordStart is data at NumHeaderBytes that does not start
+	// with a deletion flag
+	AnomalyRecordStart
+	// AnomalyRecordCount is a NumRecords other than the file size implies
+	AnomalyRecordCount
+)
+
+func (k AnomalyKind) String() string {
+	switch k {
+	case AnomalyRecordLength:
+		return "record length"
+	case AnomalyHeaderLength:
+		return "header length"
+	case AnomalyHeaderPadding:
+		return "header padding"
+	case AnomalyRecordStart:
+		return "record start"
+	case AnomalyRecordCount:
+		return "record count"
+	}
+	return "AnomalyKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Anomaly is a disagreement between the header and the file found by
+// NewDbf, and how it was read anyway
+type Anomaly struct {
+	Kind AnomalyKind
+	// Declared is the header value, Actual what the file has instead
+	Declared int64
+	Actual   int64
+	Msg      string
+}
+
+func (a Anomaly) String() string {
+	return a.Kind.String() + ": " + a.Msg
+}
+
+// Anomalies are the header inconsistencies found opening the table, in
+// the order found. The warnings WithLogger gets are among them; WithStrict
+// fails on those instead.
+func (d *Dbf) Anomalies() []Anomaly {
+	return d.anomalies
+}
+
+// anomaly notes a header inconsistency, logging it or with WithStrict
+// failing on it
+func (d *Dbf) anomaly(kind AnomalyKind, declared, actual int64, format string, v ...interface{}) error {
+	if d.strict {
+		return &StrictError{d.addAnomaly(kind, declared, actual, format, v...).Msg}
+	}
+	d.warnAnomaly(kind, declared, actual, format, v...)
+	return nil
+}
+
+// warnAnomaly notes and logs a header inconsistency that was worked
+// around as asked, even WithStrict
+func (d *Dbf) warnAnomaly(kind AnomalyKind, declared, actual int64, format string, v ...interface{}) {
+	a := d.addAnomaly(kind, declared, actual, format, v...)
+	if d.logf != nil {
+		d.logf("%s", a.Msg)
+	}
+}
+
+func (d *Dbf) addAnomaly(kind AnomalyKind, declared, actual int64, format string, v ...interface{}) Anomaly {
+	a := Anomaly{Kind: kind, Declared: declared, Actual: actual, Msg: fmt.Sprintf(format, v...)}
+	d.anomalies = append(d.anomalies, a)
+	return a
+}
+
+// expectedHeaderPadding is the usual number of bytes between the field
+// descriptor terminator and the records, -1 where it varies
+func expectedHeaderPadding(version byte) int64 {
+	switch {
+	case version == 0x30 || version == 0x31 || version == 0x32:
+		// Visual FoxPro database container backlink
+		return 263
+	case version == dBaseII || version&0x07 == 4:
+		// fixed size dBASE II header; dBASE 7 field properties
+		return -1
+	}
+	return 0
+}
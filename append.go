@@ -0,0 +1,84 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// appendState is the file an OpenAppend Writer patches on Close
+type appendState struct {
+	file *os.File
+	// end is the file position after the records already there, count of them
+	end   int64
+	count uint32
+}
+
+// OpenAppend opens an existing table to add records to its end. Records
+// are written with WriteRecord, WriteValues or WriteRaw over the end of
+// file marker, and Close updates the record count and last update date
+// in the header, truncates anything after the new end of file marker and
+// closes the file. The record count is taken from the file size, so a
+// header that was never updated is corrected too. NumRecords on the
+// returned Writer is the count already in the file. Values are written as
+// the table's version holds them, the I, Y, B and T fields of a Visual
+// FoxPro table as binary.
+func OpenAppend(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newAppendWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func newAppendWriter(f *os.File) (*Writer, error) {
+	d, err := NewDbf(f, WithLogger(nil))
+	if err != nil {
+		return nil, err
+	}
+	if d.Version == dBaseII {
+		return nil, errors.New("dbf OpenAppend does not support dBASE II tables")
+	}
+	w, err := NewWriter(f, d.Fields, WithTableVersion(WriterVersion(d.Version)))
+	if err != nil {
+		return nil, err
+	}
+	if int(d.NumRecordBytes) != 1+w.recordLength {
+		return nil, errors.New("dbf OpenAppend header record length does not match the field widths")
+	}
+	count := d.EffectiveRecords()
+	end := d.pos + count*int64(d.NumRecordBytes)
+	_, err = f.Seek(end, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	w.Language = d.Language
+	w.NumRecords = uint32(count)
+	w.count = uint32(count)
+	w.headerWritten = true
+	w.appendTo = &appendState{file: f, end: end, count: w.count}
+	return w, nil
+}
+
+// finishAppend rewrites the header date and count, truncates after the
+// end of file marker and closes the file.
+func (w *Writer) finishAppend() error {
+	a := w.appendTo
+	w.appendTo = nil
+	_, err := a.file.WriteAt(headerPatch(w.count), 1)
+	if err == nil {
+		end := a.end + int64(w.count-a.count)*int64(1+w.recordLength) + 1
+		err = a.file.Truncate(end)
+	}
+	cerr := a.file.Close()
+	if err == nil {
+		err = cerr
+	}
+	w.NumRecords = w.count
+	return err
+}
@@ -0,0 +1,413 @@
+package dbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// DefaultArrowBatchRows is the record batch size of WriteArrow when
+// ExportOptions.BatchRows is 0
+const DefaultArrowBatchRows = 65536
+
+// arrowKind is the Arrow type of an exported column
+type arrowKind int
+
+const (
+	arrowUtf8 arrowKind = iota
+	arrowBinary
+	arrowBool
+	arrowInt32
+	arrowInt64
+	arrowFloat64
+	arrowDate32
+	arrowTimestamp
+)
+
+// Arrow Type union and MessageHeader union members, from Schema.fbs and
+// Message.fbs
+const (
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeBinary        = 4
+	arrowTypeUtf8          = 5
+	arrowTypeBool          = 6
+	arrowTypeDate          = 8
+	arrowTypeTimestamp     = 10
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	// arrowMetadataV5 is the MetadataVersion of Arrow 1.0 and later
+	arrowMetadataV5 = 4
+)
+
+// arrowKindOf maps a column to an Arrow type by its field type, as Value
+// does to Go types
+func arrowKindOf(c *exportColumn) arrowKind {
+	if c.redact != nil {
+		return arrowUtf8
+	}
+	if c.computed != nil {
+		if c.computed.Type == DbfFieldNumeric {
+			return arrowFloat64
+		}
+		return arrowUtf8
+	}
+	f := c.field
+	if f.isMemo() {
+		if f.d.memo != nil {
+			return arrowBinary
+		}
+		return arrowUtf8
+	}
+	switch f.Type {
+	case DbfFieldNumeric:
+		if f.DecimalCount() == 0 {
+			return arrowInt64
+		}
+		return arrowFloat64
+	case DbfFieldFloat, DbfFieldDouble, DbfFieldCurrency:
+		return arrowFloat64
+	case DbfFieldInteger:
+		return arrowInt32
+	case DbfFieldLogical:
+		return arrowBool
+	case DbfFieldDate:
+		return arrowDate32
+	case DbfFieldDateTime:
+		return arrowTimestamp
+	}
+	return arrowUtf8
+}
+
+// typeTable is the Field.type union member for the kind
+func (k arrowKind) typeTable() (byte, fbTable) {
+	switch k {
+	case arrowBinary:
+		return arrowTypeBinary, fbTable{}
+	case arrowBool:
+		return arrowTypeBool, fbTable{}
+	case arrowInt32:
+		return arrowTypeInt, fbTable{fbScalar(4, 32), fbScalar(1, 1)}
+	case arrowInt64:
+		return arrowTypeInt, fbTable{fbScalar(4, 64), fbScalar(1, 1)}
+	case arrowFloat64:
+		// precision DOUBLE
+		return arrowTypeFloatingPoint, fbTable{fbScalar(2, 2)}
+	case arrowDate32:
+		// unit DAY, which is not the default
+		return arrowTypeDate, fbTable{fbScalar(2, 0)}
+	case arrowTimestamp:
+		// unit MILLISECOND, no time zone
+		return arrowTypeTimestamp, fbTable{fbScalar(2, 1)}
+	}
+	return arrowTypeUtf8, fbTable{}
+}
+
+// arrowColumn builds the buffers of one column of a record batch
+type arrowColumn struct {
+	name string
+	kind arrowKind
+	// col is nil for the DeletedColumn
+	col *exportColumn
+
+	n       int
+	nulls   int
+	valid   []byte
+	values  []byte
+	offsets []byte
+	data    []byte
+}
+
+func setBit(bitmap []byte, i int, v bool) []byte {
+	for len(bitmap) <= i/8 {
+		bitmap = append(bitmap, 0)
+	}
+	if v {
+		bitmap[i/8] |= 1 << uint(i%8)
+	}
+	return bitmap
+}
+
+func (c *arrowColumn) reset() {
+	c.n, c.nulls = 0, 0
+	c.valid = c.valid[:0]
+	c.values = c.values[:0]
+	c.data = c.data[:0]
+	c.offsets = append(c.offsets[:0], 0, 0, 0, 0)
+}
+
+func (c *arrowColumn) appendUint(size int, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	c.values = append(c.values, b[:size]...)
+}
+
+// add appends the current record's value
+func (c *arrowColumn) add(d *Dbf) error {
+	var v interface{}
+	if c.col == nil {
+		v = d.IsDeleted()
+	} else if c.col.redact != nil || c.col.computed != nil {
+		v = c.col.value()
+	} else {
+		var err error
+		v, err = c.col.field.Value()
+		if err != nil {
+			return err
+		}
+	}
+	i := c.n
+	c.n++
+	c.valid = setBit(c.valid, i, v != nil)
+	if v == nil {
+		c.nulls++
+	}
+	switch c.kind {
+	case arrowUtf8, arrowBinary:
+		switch v := v.(type) {
+		case string:
+			c.data = append(c.data, v...)
+		case []byte:
+			c.data = append(c.data, v...)
+		}
+		if len(c.data) > math.MaxInt32 {
+			return errors.New("dbf arrow batch column " + c.name + " over 2GB, use a smaller BatchRows")
+		}
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(len(c.data)))
+		c.offsets = append(c.offsets, b[:]...)
+		return nil
+	case arrowBool:
+		b, _ := v.(bool)
+		c.values = setBit(c.values, i, b)
+		return nil
+	}
+	var x uint64
+	switch v := v.(type) {
+	case nil:
+	case int64:
+		if c.kind == arrowFloat64 {
+			x = math.Float64bits(float64(v))
+		} else {
+			x = uint64(v)
+		}
+	case float64:
+		if c.kind != arrowFloat64 {
+			if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+				return c.col.field.parseError(fmt.Errorf("%v is not an integer", v))
+			}
+			x = uint64(int64(v))
+		} else {
+			x = math.Float64bits(v)
+		}
+	case string:
+		// a computed numeric column
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.valid[i/8] &^= 1 << uint(i%8)
+			c.nulls++
+		}
+		x = math.Float64bits(f)
+	case time.Time:
+		days := v.Unix() / 86400
+		if v.Unix()%86400 < 0 {
+			days--
+		}
+		if c.kind == arrowDate32 {
+			x = uint64(days)
+		} else {
+			x = uint64(v.Unix()*1000 + int64(v.Nanosecond()/1e6))
+		}
+	}
+	switch c.kind {
+	case arrowInt32, arrowDate32:
+		c.appendUint(4, x)
+	default:
+		c.appendUint(8, x)
+	}
+	return nil
+}
+
+// arrowWriter writes an Arrow IPC stream
+type arrowWriter struct {
+	w       io.Writer
+	columns []*arrowColumn
+}
+
+// writeMessage writes an encapsulated IPC message: continuation marker,
+// metadata length, metadata padded to 8 bytes, then the body
+func (aw *arrowWriter) writeMessage(header byte, headerTable fbTable, body []byte) error {
+	meta := fbFinish(fbTable{
+		fbScalar(2, arrowMetadataV5),
+		fbScalar(1, uint64(header)),
+		fbRef(headerTable),
+		fbScalar(8, uint64(len(body))),
+	})
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix, 0xFFFFFFFF)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)))
+	_, err := aw.w.Write(append(prefix, meta...))
+	if err == nil && len(body) != 0 {
+		_, err = aw.w.Write(body)
+	}
+	return err
+}
+
+func (aw *arrowWriter) writeSchema() error {
+	fields := make(fbVector, len(aw.columns))
+	for i, c := range aw.columns {
+		typ, typeTable := c.kind.typeTable()
+		fields[i] = fbTable{
+			fbRef(fbString(c.name)),
+			fbScalar(1, 1), // nullable
+			fbScalar(1, uint64(typ)),
+			fbRef(typeTable),
+			{},
+			fbRef(fbVector{}), // children, required by readers even if empty
+		}
+	}
+	return aw.writeMessage(arrowHeaderSchema, fbTable{{}, fbRef(fields)}, nil)
+}
+
+func (aw *arrowWriter) writeBatch() error {
+	if len(aw.columns) == 0 || aw.columns[0].n == 0 {
+		return nil
+	}
+	var nodes, buffers, body []byte
+	var b [16]byte
+	addBuffer := func(data []byte) {
+		binary.LittleEndian.PutUint64(b[0:], uint64(len(body)))
+		binary.LittleEndian.PutUint64(b[8:], uint64(len(data)))
+		buffers = append(buffers, b[:]...)
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	n := aw.columns[0].n
+	for _, c := range aw.columns {
+		binary.LittleEndian.PutUint64(b[0:], uint64(c.n))
+		binary.LittleEndian.PutUint64(b[8:], uint64(c.nulls))
+		nodes = append(nodes, b[:]...)
+		// bitmaps cover every row even when the trailing bits are 0
+		for len(c.valid) < (c.n+7)/8 {
+			c.valid = append(c.valid, 0)
+		}
+		addBuffer(c.valid)
+		switch c.kind {
+		case arrowUtf8, arrowBinary:
+			addBuffer(c.offsets)
+			addBuffer(c.data)
+		case arrowBool:
+			for len(c.values) < (c.n+7)/8 {
+				c.values = append(c.values, 0)
+			}
+			addBuffer(c.values)
+		default:
+			addBuffer(c.values)
+		}
+	}
+	batch := fbTable{
+		fbScalar(8, uint64(n)),
+		fbRef(fbStructs{len(aw.columns), nodes}),
+		fbRef(fbStructs{len(buffers) / 16, buffers}),
+	}
+	err := aw.writeMessage(arrowHeaderRecordBatch, batch, body)
+	for _, c := range aw.columns {
+		c.reset()
+	}
+	return err
+}
+
+// writeEnd writes the end of stream marker
+func (aw *arrowWriter) writeEnd() error {
+	_, err := aw.w.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})
+	return err
+}
+
+// WriteArrow writes every remaining record of d as an Apache Arrow IPC
+// stream, the format of Arrow Flight and of pyarrow.ipc.open_stream and
+// the Go ipc.NewReader: a schema and record batches of
+// ExportOptions.BatchRows rows. Columns are typed by field type as Value
+// does, all nullable: C and memo fields utf8 (binary once AttachMemo is
+// called), N int64 without decimals and float64 with, F, B and Y float64,
+// I int32, L bool, D date32 and T timestamp[ms]. Redacted columns are
+// utf8. Each part of a split export is a complete stream.
+func WriteArrow(d *Dbf, w io.Writer, opts *ExportOptions) error {
+	cols, err := exportColumns(d, opts)
+	if err != nil {
+		return err
+	}
+	out, err := newExportOutput(w, opts)
+	if err != nil {
+		return err
+	}
+	batchRows := DefaultArrowBatchRows
+	if opts != nil && opts.BatchRows > 0 {
+		batchRows = opts.BatchRows
+	}
+	aw := &arrowWriter{w: out}
+	for i := range cols {
+		aw.columns = append(aw.columns, &arrowColumn{name: cols[i].field.Name, kind: arrowKindOf(&cols[i]), col: &cols[i]})
+	}
+	if opts.includeDeleted() {
+		aw.columns = append(aw.columns, &arrowColumn{name: DeletedColumn, kind: arrowBool})
+	}
+	for _, c := range aw.columns {
+		c.reset()
+	}
+	err = aw.writeSchema()
+	if err != nil {
+		return err
+	}
+	n := 0
+	for {
+		err = out.nextExported(d)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if n == 0 && out.full() {
+			err = aw.writeEnd()
+			if err == nil {
+				err = out.nextPart()
+			}
+			if err == nil {
+				err = aw.writeSchema()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, c := range aw.columns {
+			err = c.add(d)
+			if err != nil {
+				return err
+			}
+		}
+		n++
+		out.rows++
+		if n >= batchRows || out.full() {
+			err = aw.writeBatch()
+			if err != nil {
+				return err
+			}
+			n = 0
+		}
+	}
+	err = aw.writeBatch()
+	if err == nil {
+		err = aw.writeEnd()
+	}
+	if err != nil {
+		return err
+	}
+	return out.finishPart()
+}
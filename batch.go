@@ -0,0 +1,128 @@
+package dbf
+
+import "io"
+
+// NextN reads up to n records with one read of the input, for scans where
+// a Read per record is the cost. The records keep their own bytes, so they
+// stay valid after more are read. Afterwards the last of them is the
+// current record, as if Next had been called for each. Fewer than n are
+// returned at the end of the data; after the last, NextN returns io.EOF.
+// Other errors come with the records read before them. WithSkipDeleted
+// records and those WithFilter passes over are left out. WithResync and
+// WithFollow inputs are read a record at a time.
+func (d *Dbf) NextN(n int) ([]Record, error) {
+	var records []Record
+	for len(records) == 0 {
+		if n <= 0 {
+			return nil, nil
+		}
+		if d.reader == nil || d.eof || d.atLimit() {
+			return nil, io.EOF
+		}
+		var err error
+		if d.resync || d.follow != nil {
+			records, err = d.nextEach(n)
+		} else {
+			records, err = d.nextBatch(n)
+		}
+		if err == io.EOF && len(records) != 0 {
+			return records, nil
+		} else if err != nil {
+			return records, err
+		}
+	}
+	return records, nil
+}
+
+// nextEach is NextN by calls to Next
+func (d *Dbf) nextEach(n int) ([]Record, error) {
+	rowBytes := d.rowWidth + 1
+	buf := make([]byte, n*rowBytes)
+	records := make([]Record, 0, n)
+	for i := 0; i < n; i++ {
+		err := d.Next()
+		if err != nil {
+			return records, err
+		}
+		row := buf[i*rowBytes : i*rowBytes+d.rowWidth]
+		copy(row, d.recordBuffer)
+		records = append(records, Record{d: d, row: row, flag: d.flag[0], recno: d.recno})
+	}
+	return records, nil
+}
+
+// nextBatch reads n records at once, pushing back whatever follows a
+// terminator or a short last record for next to deal with
+func (d *Dbf) nextBatch(n int) ([]Record, error) {
+	rowBytes := d.rowWidth + 1
+	if d.SizeRecords >= 0 {
+		// don't allocate much past the end of the data; at least one
+		// read still finds the end
+		remaining := d.SizeRecords - d.recno - 1
+		if remaining < 1 {
+			remaining = 1
+		}
+		if int64(n) > remaining {
+			n = int(remaining)
+		}
+	}
+	if d.limited && int64(n) > d.limit-d.recno-1 {
+		// n is at least 1, NextN checked atLimit
+		n = int(d.limit - d.recno - 1)
+	}
+	start := d.pos
+	// an OpenMmap table hands out the records in place
+	buf, mapped := d.mappedSpan(n * rowBytes)
+	got := len(buf)
+	var err error
+	if !mapped {
+		buf = make([]byte, n*rowBytes)
+		got, err = d.readFull(buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = nil
+		} else if err != nil {
+			return nil, d.wrapNextError(err)
+		}
+	}
+	records := make([]Record, 0, n)
+	off := 0
+	for ; off+rowBytes <= got && buf[off] != 0x1a; off += rowBytes {
+		d.recno++
+		if d.skipDeleted && buf[off] == '*' {
+			continue
+		}
+		row := buf[off+1 : off+rowBytes]
+		if d.filter != nil {
+			// the filter reads the current row
+			d.recordPos = start + int64(off)
+			d.flag[0] = buf[off]
+			d.setRow(row)
+			if !d.filter(d) {
+				continue
+			}
+		}
+		err = d.checkRecord(row, d.recno, start+int64(off))
+		if err != nil {
+			// this record is consumed, the rest are read again
+			off += rowBytes
+			d.unreadBytes(buf[off:got])
+			break
+		}
+		records = append(records, Record{d: d, row: row, flag: buf[off], recno: d.recno})
+	}
+	if err == nil && (off < got || got < n*rowBytes) {
+		// the terminator, a short record or nothing: next sees the end
+		d.unreadBytes(buf[off:got])
+		err = d.wrapNextError(d.next())
+		if err == nil {
+			// the input is done, next can only find the end
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	if off > 0 {
+		d.recordPos = start + int64(off-rowBytes)
+		d.flag[0] = buf[off-rowBytes]
+		d.setRow(buf[off-rowBytes+1 : off])
+	}
+	return records, err
+}
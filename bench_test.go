@@ -0,0 +1,237 @@
+package dbf
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// wideFields is the number of C(120) fields in a wideTable, the shape
+// where trimming dominates a scan
+const wideFields = 40
+
+// wideTable is a table of rows records of 40 C(120) fields with short
+// values, then an N(10,0) and an N(12,3) field
+func wideTable(tb testing.TB, rows int) []byte {
+	fields := make([]DbfField, 0, wideFields+2)
+	for i := 0; i < wideFields; i++ {
+		fields = append(fields, DbfField{Name: "C" + strconv.Itoa(i), Type: DbfFieldChar, Width: 120})
+	}
+	fields = append(fields,
+		DbfField{Name: "COUNT", Type: DbfFieldNumeric, Width: 10},
+		DbfField{Name: "RATE", Type: DbfFieldNumeric, Width: 12, Count: 3})
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, fields)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	w.NumRecords = uint32(rows)
+	values := make([]string, len(fields))
+	for r := 0; r < rows; r++ {
+		for i := 0; i < wideFields; i++ {
+			values[i] = "value " + strconv.Itoa(i)
+		}
+		values[wideFields] = strconv.Itoa(r * 7)
+		values[wideFields+1] = strconv.FormatFloat(float64(r)/8, 'f', 3, 64)
+		err = w.WriteRecord(values...)
+		if err != nil {
+			tb.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// openTable reads table, positioned on record recno
+func openTable(tb testing.TB, table []byte, recno int64) *Dbf {
+	d, err := NewDbf(bytes.NewReader(table))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for d.RecordIndex() < recno {
+		err = d.Next()
+		if err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return d
+}
+
+func BenchmarkNext(b *testing.B) {
+	table := wideTable(b, 1000)
+	b.SetBytes(int64(len(table)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d, err := NewDbf(bytes.NewReader(table))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for {
+			err = d.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkStringValue(b *testing.B) {
+	d := openTable(b, wideTable(b, 200), 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < wideFields; j++ {
+			d.Fields[j].StringValue()
+		}
+	}
+}
+
+func BenchmarkBytesValue(b *testing.B) {
+	d := openTable(b, wideTable(b, 200), 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < wideFields; j++ {
+			d.Fields[j].BytesValue()
+		}
+	}
+}
+
+func BenchmarkInt64(b *testing.B) {
+	d := openTable(b, wideTable(b, 200), 100)
+	f := &d.Fields[wideFields]
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := f.Int64()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFloat64(b *testing.B) {
+	d := openTable(b, wideTable(b, 200), 100)
+	f := &d.Fields[wideFields+1]
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := f.Float64()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkReadRow reads every field of every record as strings, the
+// shape of an export
+func BenchmarkReadRow(b *testing.B) {
+	table := wideTable(b, 1000)
+	b.SetBytes(int64(len(table)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d, err := NewDbf(bytes.NewReader(table))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for d.Next() == nil {
+			for j := range d.Fields {
+				d.Fields[j].StringValue()
+			}
+		}
+	}
+}
+
+func TestNextAllocs(t *testing.T) {
+	d := openTable(t, wideTable(t, 200), 0)
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := d.Next(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Next allocates %v times per record, want 0", allocs)
+	}
+}
+
+func TestStringValueAllocs(t *testing.T) {
+	d := openTable(t, wideTable(t, 200), 100)
+	allocs := testing.AllocsPerRun(100, func() {
+		for j := 0; j < wideFields; j++ {
+			d.Fields[j].StringValue()
+		}
+	})
+	// only the trimmed value is converted to a string
+	if allocs != wideFields {
+		t.Errorf("StringValue of %d fields allocates %v times, want %d", wideFields, allocs, wideFields)
+	}
+}
+
+func TestBytesValueAllocs(t *testing.T) {
+	d := openTable(t, wideTable(t, 200), 100)
+	allocs := testing.AllocsPerRun(100, func() {
+		for j := range d.Fields {
+			d.Fields[j].BytesValue()
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("BytesValue allocates %v times per record, want 0", allocs)
+	}
+}
+
+func TestNumericAllocs(t *testing.T) {
+	d := openTable(t, wideTable(t, 200), 100)
+	count, rate := &d.Fields[wideFields], &d.Fields[wideFields+1]
+	// the value text is the only allocation
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := count.Int64(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 1 {
+		t.Errorf("Int64 allocates %v times, want 1", allocs)
+	}
+	allocs = testing.AllocsPerRun(100, func() {
+		if _, err := rate.Float64(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 1 {
+		t.Errorf("Float64 allocates %v times, want 1", allocs)
+	}
+}
+
+func TestFixedOpenAllocs(t *testing.T) {
+	fields := []fuzzField{{"NAME", 'C', 10, 0}, {"POP", 'N', 9, 0}}
+	rows := []string{" Springfld    12345"}
+	// a Visual FoxPro table, with the backlink before the data
+	const descriptors = 32 + 32*2 + 1
+	table := fuzzTable(fields, rows, descriptors+263, 0, true)
+	table = append(table[:descriptors:descriptors], append(make([]byte, 263), table[descriptors:]...)...)
+	table[0] = 0x30
+	record := make([]byte, 0, 20+264)
+	fieldBuf := make([]DbfField, 0, len(fields))
+	r := bytes.NewReader(table)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(table)
+		d, err := NewDbf(r, WithBuffers(record, fieldBuf), WithLogger(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Next() != nil {
+			t.Fatal("no record")
+		}
+	})
+	// the Dbf and the field names
+	if want := float64(1 + len(fields)); allocs > want {
+		t.Errorf("NewDbf with WithBuffers allocates %v times, want %v", allocs, want)
+	}
+}
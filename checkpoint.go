@@ -0,0 +1,78 @@
+package dbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+var ErrBadCheckpoint error = errors.New("dbf checkpoint is not valid")
+var ErrCheckpointMismatch error = errors.New("dbf checkpoint is for a different file")
+
+const checkpointMagic = "DBFCK\x01"
+const checkpointLength = len(checkpointMagic) + 8 + 8 + 4 + 2 + 2 + 4
+
+// schemaSum identifies the header layout so a checkpoint is not applied to another file
+func (d *Dbf) schemaSum() uint32 {
+	h := crc32.NewIEEE()
+	var scratch [2]byte
+	for _, f := range d.Fields {
+		io.WriteString(h, f.Name)
+		scratch[0] = byte(f.Type)
+		scratch[1] = f.Length
+		h.Write(scratch[:])
+	}
+	return h.Sum32()
+}
+
+// Checkpoint captures the position after the current record. Pass it to
+// ResumeDbf with a fresh reader of the same file to continue from the next
+// record.
+func (d *Dbf) Checkpoint() ([]byte, error) {
+	out := make([]byte, checkpointLength)
+	copy(out, checkpointMagic)
+	b := out[len(checkpointMagic):]
+	binary.LittleEndian.PutUint64(b[0:8], uint64(d.pos))
+	binary.LittleEndian.PutUint64(b[8:16], uint64(d.recno))
+	binary.LittleEndian.PutUint32(b[16:20], d.NumRecords)
+	binary.LittleEndian.PutUint16(b[20:22], d.NumHeaderBytes)
+	binary.LittleEndian.PutUint16(b[22:24], d.NumRecordBytes)
+	binary.LittleEndian.PutUint32(b[24:28], d.schemaSum())
+	return out, nil
+}
+
+// ResumeDbf reads the header from reader, checks that it matches the file
+// the checkpoint came from, and skips to the checkpoint position. It seeks
+// if reader is an io.Seeker and otherwise reads and discards.
+func ResumeDbf(reader io.Reader, checkpoint []byte, opts ...Option) (*Dbf, error) {
+	if len(checkpoint) != checkpointLength || string(checkpoint[:len(checkpointMagic)]) != checkpointMagic {
+		return nil, ErrBadCheckpoint
+	}
+	b := checkpoint[len(checkpointMagic):]
+	pos := int64(binary.LittleEndian.Uint64(b[0:8]))
+	recno := int64(binary.LittleEndian.Uint64(b[8:16]))
+	d, err := NewDbf(reader, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if d.NumRecords != binary.LittleEndian.Uint32(b[16:20]) ||
+		d.NumHeaderBytes != binary.LittleEndian.Uint16(b[20:22]) ||
+		d.NumRecordBytes != binary.LittleEndian.Uint16(b[22:24]) ||
+		d.schemaSum() != binary.LittleEndian.Uint32(b[24:28]) {
+		return nil, ErrCheckpointMismatch
+	}
+	if pos < d.pos {
+		return nil, ErrBadCheckpoint
+	}
+	if _, ok := reader.(io.Seeker); ok {
+		err = d.seekTo(pos)
+	} else {
+		err = d.skip(pos - d.pos)
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.recno = recno
+	return d, nil
+}
@@ -1,17 +1,54 @@
-// Read a zip file and report some stats on whatever .dbf is contained within it, as per a Census shapefile bundle for FACES or EDGES etc.
+// Read zip files and report stats on whatever .dbf is contained within them, as per a Census shapefile bundle for FACES or EDGES etc.
+// Checks that state+county+tract+block make a complete 15 character block GEOID on every row.
+//
+//	censustest [-format text|json|csv] [-continue] [-workers N] [-state STATEFP10 ...] tl_2010_06001_tabblock10.zip ...
+//
+// -json and -csv are short for -format json and -format csv: one JSON object, or one CSV
+// row after a header, per dbf on stdout with its file, layer and counts, to aggregate runs.
+//
+// With more than one worker, zips are checked in parallel and reported in the order they finish.
 
 package main
 
 import (
-	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"path"
+	"strconv"
 	"strings"
+	"sync"
 
 	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+	"github.com/brianolson/go-dbf/tiger"
 )
 
-func getField(d *dbf.Dbf, name string) *dbf.DbfField {
+// fileStats is the result for one .dbf within a zip
+type fileStats struct {
+	File    string `json:"file"`
+	Member  string `json:"member"`
+	Layer   string `json:"layer"`
+	Records int64  `json:"records"`
+	Good    int    `json:"good"`
+	Short   int    `json:"short"`
+	Error   string `json:"error,omitempty"`
+}
+
+// fieldNames are explicit field names from flags, or "" to find by base name with any vintage suffix
+type fieldNames struct {
+	state, county, tract, block string
+}
+
+func getField(d *dbf.Dbf, name, base string) *dbf.DbfField {
+	if name == "" {
+		return tiger.Field(d, base)
+	}
 	for i, df := range d.Fields {
 		if name == df.Name {
 			return &d.Fields[i]
@@ -20,76 +57,162 @@ func getField(d *dbf.Dbf, name string) *dbf.DbfField {
 	return nil
 }
 
-func getBestField(d *dbf.Dbf, names []string) *dbf.DbfField {
-	for _, name := range names {
-		out := getField(d, name)
-		if out != nil {
-			return out
+func checkDbf(d *dbf.Dbf, names *fieldNames, stats *fileStats) error {
+	stats.Records = d.EffectiveRecords()
+	state := getField(d, names.state, "STATEFP")
+	county := getField(d, names.county, "COUNTYFP")
+	tract := getField(d, names.tract, "TRACTCE")
+	block := getField(d, names.block, "BLOCKCE")
+	if state == nil || county == nil || tract == nil || block == nil {
+		var fields []string
+		for _, df := range d.Fields {
+			fields = append(fields, df.Name)
+		}
+		return errors.New("missing a field. fields: " + strings.Join(fields, " "))
+	}
+	ubid := dbf.KeyFunc(state, county, tract, block)
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if len(ubid()) == 15 {
+			stats.Good++
+		} else {
+			stats.Short++
 		}
 	}
 	return nil
 }
 
+// layerName is the TIGER layer of a file name, "tabblock10" for
+// tl_2010_06001_tabblock10.dbf, or the base name of other files
+func layerName(name string) string {
+	base := path.Base(name)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	parts := strings.SplitN(base, "_", 4)
+	if len(parts) == 4 && strings.EqualFold(parts[0], "tl") {
+		return parts[3]
+	}
+	return base
+}
+
+// csvHeader names the columns of -format csv
+var csvHeader = []string{"file", "member", "layer", "records", "good", "short", "error"}
+
+func (s *fileStats) csvRow() []string {
+	return []string{s.File, s.Member, s.Layer, strconv.FormatInt(s.Records, 10), strconv.Itoa(s.Good), strconv.Itoa(s.Short), s.Error}
+}
+
+// outputFormats are text (log lines), json (one object per dbf on stdout) or csv (one row per dbf)
+var outputFormats = []string{"text", "json", "csv"}
+
 func main() {
+	var names fieldNames
+	flag.StringVar(&names.state, "state", "", "state FIPS field name (default STATEFP with any vintage suffix)")
+	flag.StringVar(&names.county, "county", "", "county FIPS field name (default COUNTYFP with any vintage suffix)")
+	flag.StringVar(&names.tract, "tract", "", "tract field name (default TRACTCE with any vintage suffix)")
+	flag.StringVar(&names.block, "block", "", "block field name (default BLOCKCE with any vintage suffix)")
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.Format|cliconfig.Strictness, "text", outputFormats...)
+	keepGoing := flag.Bool("continue", false, "keep going after a file fails")
+	workers := flag.Int("workers", 1, "zip files to check at once")
+	asJSON := flag.Bool("json", false, "same as -format json")
+	asCSV := flag.Bool("csv", false, "same as -format csv")
+	flag.Parse()
+
+	if *asJSON && *asCSV {
+		log.Print("use one of -json and -csv")
+		os.Exit(2)
+	} else if *asJSON {
+		cfg.Format = "json"
+	} else if *asCSV {
+		cfg.Format = "csv"
+	}
+	err := cfg.Parsed(outputFormats...)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	cw := csv.NewWriter(os.Stdout)
+	if cfg.Format == "csv" {
+		cw.Write(csvHeader)
+		cw.Flush()
+	}
+
 	totcount := 0
-	totrecords := 0
+	totrecords := int64(0)
 	dbfsFound := 0
-	for _, fname := range os.Args[1:] {
-		zf, err := zip.OpenReader(fname)
-		if err != nil {
-			log.Print(fname, ": ", err)
-			os.Exit(1)
-			return
+	failures := 0
+	report := func(stats *fileStats) error {
+		if stats.Member != "" {
+			dbfsFound++
+			stats.Layer = layerName(stats.Member)
+		} else {
+			stats.Layer = layerName(stats.File)
 		}
-
-		for _, zff := range zf.File {
-			if strings.HasSuffix(zff.Name, ".dbf") {
-				dbfsFound++
-				log.Print(fname, " ", zff.Name)
-				ior, err := zff.Open()
-				if err != nil {
-					log.Print(fname, " ", zff.Name, ": ", err)
-					os.Exit(1)
-					return
-				}
-				d, err := dbf.NewDbf(ior)
-				if err != nil {
-					log.Print(fname, " ", zff.Name, ": ", err)
-					os.Exit(1)
-					return
-				}
-				state := getBestField(d, []string{"STATEFP10", "STATEFP00"})
-				county := getBestField(d, []string{"COUNTYFP10", "COUNTYFP00"})
-				tract := getBestField(d, []string{"TRACTCE10", "TRACTCE00"})
-				block := getBestField(d, []string{"BLOCKCE10", "BLOCKCE00"})
-				if state == nil || county == nil || tract == nil || block == nil {
-					log.Print("missing a field. fields...")
-					for _, df := range d.Fields {
-						log.Print(df.GoString())
-					}
-					continue
-				}
-				okcount := 0
-				shortcount := 0
-				for {
-					err = d.Next()
-					if err != nil {
-						break
-					}
-					ubid := state.StringValue() + county.StringValue() + tract.StringValue() + block.StringValue()
-					//log.Print(ubid)
-					if len(ubid) == 15 {
-						okcount++
-					} else {
-						shortcount++
-					}
-				}
-				log.Print("good ubid count=", okcount, " short=", shortcount, " num records=", d.NumRecords)
-				totcount += okcount
-				totrecords += int(d.NumRecords)
-				//state := getBestField(d, []string{"",""})
+		if cfg.Format == "json" {
+			err := enc.Encode(stats)
+			if err != nil {
+				return err
+			}
+		} else if cfg.Format == "csv" {
+			// flushed per row so an interrupted run keeps what it has
+			cw.Write(stats.csvRow())
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+		} else if stats.Error != "" {
+			log.Print(stats.File, " ", stats.Member, ": ", stats.Error)
+		} else {
+			log.Print(stats.File, " ", stats.Member)
+			log.Print("good ubid count=", stats.Good, " short=", stats.Short, " num records=", stats.Records)
+		}
+		totcount += stats.Good
+		totrecords += stats.Records
+		if stats.Error != "" {
+			failures++
+			if !*keepGoing {
+				return dbf.ErrStopWalk
 			}
 		}
+		return nil
+	}
+	var mu sync.Mutex
+	var outErr error
+	err = dbf.WalkZips(flag.Args(), *workers, func(zipName, member string, d *dbf.Dbf) error {
+		stats := &fileStats{File: zipName, Member: member}
+		err := checkDbf(d, &names, stats)
+		if err != nil {
+			stats.Error = err.Error()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		err = report(stats)
+		if err != nil && err != dbf.ErrStopWalk {
+			outErr = err
+			return dbf.ErrStopWalk
+		}
+		return err
+	}, cfg.DbfOptions()...)
+	if walkErr, ok := err.(*dbf.WalkError); ok {
+		// zips and members that could not be opened
+		for _, ze := range walkErr.Errors {
+			report(&fileStats{File: ze.Zip, Member: ze.Member, Error: ze.Err.Error()})
+		}
+	}
+	if outErr != nil {
+		log.Print(outErr)
+		failures++
+	}
+	if cfg.Format == "text" {
+		log.Printf("%d dbfs, %d total records, %d ok, %d failed\n", dbfsFound, totrecords, totcount, failures)
+	}
+	if failures != 0 {
+		fmt.Fprintf(os.Stderr, "%d failures\n", failures)
+		os.Exit(1)
 	}
-	log.Printf("%d dbfs, %d total records, %d ok\n", dbfsFound, totrecords, totcount)
 }
@@ -0,0 +1,288 @@
+// Build a dBASE III table from a CSV file with a header row.
+//
+//	csv2dbf [-schema NAME:C:20,POP:N:9:0 | -schema-file schema.txt] [-delimiter ,] [in.csv] > out.dbf
+//
+// A schema lists fields as name:type:length[:decimals]; a schema file has one per line,
+// with # comments. CSV columns are matched to fields by header name, and fields with no
+// column are left blank. Without a schema every column becomes a field: N if all its
+// values are plain decimal numbers, otherwise C, as wide as the longest value; the rows
+// are read before the table is written. With a schema they are streamed, and a table
+// written to a pipe has a record count of 0 in its header, for readers to count the
+// records from the size of the file. Names longer than the version allows, 10 bytes or
+// 31 for dBASE 7, are cut short.
+//
+// -encoding also defaults from the environment, see internal/cliconfig.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+)
+
+// maxNumericWidth is the widest N field dBASE III reads
+const maxNumericWidth = 20
+
+// maxCharWidth is the widest C field, FoxPro allows more
+const maxCharWidth = 254
+
+// column is what inference has seen of one CSV column
+type column struct {
+	length   int
+	intPart  int
+	decimals int
+	numeric  bool
+	seen     bool
+}
+
+func (c *column) observe(v string) {
+	if len(v) > c.length {
+		c.length = len(v)
+	}
+	if v == "" || !c.numeric {
+		return
+	}
+	intPart, decimals := v, ""
+	if dot := strings.IndexByte(v, '.'); dot >= 0 {
+		intPart, decimals = v[:dot], v[dot+1:]
+	}
+	digits := strings.TrimPrefix(intPart, "-")
+	if digits == "" && decimals == "" || strings.Trim(digits, "0123456789") != "" || strings.Trim(decimals, "0123456789") != "" {
+		c.numeric = false
+		return
+	}
+	c.seen = true
+	if len(intPart) > c.intPart {
+		c.intPart = len(intPart)
+	}
+	if len(decimals) > c.decimals {
+		c.decimals = len(decimals)
+	}
+}
+
+// field is the dbf field for the column
+func (c *column) field(name string) (dbf.DbfField, error) {
+	if c.numeric && c.seen {
+		width := c.intPart
+		if c.decimals > 0 {
+			width += 1 + c.decimals
+		}
+		if width <= maxNumericWidth {
+			return dbf.DbfField{Name: name, Type: dbf.DbfFieldNumeric, Width: width, Count: uint8(c.decimals)}, nil
+		}
+	}
+	if c.length > maxCharWidth {
+		return dbf.DbfField{}, fmt.Errorf("column %s has values of %d bytes, over %d", name, c.length, maxCharWidth)
+	}
+	width := c.length
+	if width == 0 {
+		width = 1
+	}
+	return dbf.DbfField{Name: name, Type: dbf.DbfFieldChar, Width: width}, nil
+}
+
+// fieldName cuts a header to a dbf field name of at most maxLength bytes
+func fieldName(header string, maxLength int) string {
+	name := strings.TrimSpace(header)
+	for len(name) > maxLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// inferFields makes a field for each column of rows, with names for a
+// table of version
+func inferFields(header []string, rows [][]string, version byte) ([]dbf.DbfField, error) {
+	cols := make([]column, len(header))
+	for i := range cols {
+		cols[i].numeric = true
+	}
+	for _, row := range rows {
+		for i := range cols {
+			if i < len(row) {
+				cols[i].observe(row[i])
+			}
+		}
+	}
+	fields := make([]dbf.DbfField, len(header))
+	names := make(map[string]bool, len(header))
+	for i, h := range header {
+		name := fieldName(h, dbf.MaxNameLength(version))
+		if name == "" {
+			return nil, fmt.Errorf("column %d has no name", i+1)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("column %q makes a second field named %s", h, name)
+		}
+		names[name] = true
+		f, err := cols[i].field(name)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = f
+	}
+	return fields, nil
+}
+
+// columnIndex is the CSV column of each field, by name, -1 for none
+func columnIndex(fields []dbf.DbfField, header []string, version byte) []int {
+	colIndex := make([]int, len(fields))
+	for fi, f := range fields {
+		colIndex[fi] = -1
+		for ci, name := range header {
+			if strings.TrimSpace(name) == f.Name || fieldName(name, dbf.MaxNameLength(version)) == f.Name {
+				colIndex[fi] = ci
+				break
+			}
+		}
+	}
+	return colIndex
+}
+
+// tableVersions are the -version values and their version bytes
+var tableVersions = map[string]byte{"3": 0x03, "7": 0x04, "vfp": 0x30}
+
+// convert writes the CSV on in as a table of version on out. Given fields
+// the rows are streamed, otherwise they are read first to infer them.
+func convert(in io.Reader, out *os.File, fields []dbf.DbfField, comma rune, version byte, opts []dbf.WriterOption) error {
+	cr := csv.NewReader(in)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	next := cr.Read
+	numRecords := -1
+	if fields == nil {
+		rows, err := cr.ReadAll()
+		if err != nil {
+			return err
+		}
+		fields, err = inferFields(header, rows, version)
+		if err != nil {
+			return err
+		}
+		numRecords = len(rows)
+		next = func() ([]string, error) {
+			if len(rows) == 0 {
+				return nil, io.EOF
+			}
+			record := rows[0]
+			rows = rows[1:]
+			return record, nil
+		}
+	}
+	colIndex := columnIndex(fields, header, version)
+	w, err := cliconfig.CreateDbf(out, fields, opts...)
+	if err != nil {
+		return err
+	}
+	if numRecords >= 0 {
+		w.NumRecords = uint32(numRecords)
+	}
+	row := make([]string, len(fields))
+	for n := 1; ; n++ {
+		record, err := next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		for fi, ci := range colIndex {
+			row[fi] = ""
+			if ci >= 0 && ci < len(record) {
+				row[fi] = record[ci]
+			}
+		}
+		err = w.WriteRecord(row...)
+		if err != nil {
+			return fmt.Errorf("row %d: %v", n, err)
+		}
+	}
+	return w.Close()
+}
+
+func main() {
+	schema := flag.String("schema", "", "fields as NAME:C:20,POP:N:9:0, name:type:length[:decimals]; inferred from the data if not set")
+	schemaFile := flag.String("schema-file", "", "file listing the fields, one name:type:length[:decimals] per line")
+	delimiter := flag.String("delimiter", ",", "CSV field delimiter, tab for a tab")
+	versionName := flag.String("version", "3", "table version: 3 for dBASE III, 7 for dBASE 7, vfp for Visual FoxPro")
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.Encoding, "")
+	replacement := flag.String("replacement", "?", "written for characters -encoding lacks, empty to fail instead")
+	flag.Parse()
+
+	var fields []dbf.DbfField
+	err := cfg.Parsed()
+	switch {
+	case err != nil:
+	case *schema != "" && *schemaFile != "":
+		err = errors.New("use one of -schema and -schema-file")
+	case *schema != "":
+		fields, err = cliconfig.ParseSchema(*schema)
+	case *schemaFile != "":
+		var spec []byte
+		spec, err = ioutil.ReadFile(*schemaFile)
+		if err == nil {
+			fields, err = cliconfig.ParseSchema(string(spec))
+		}
+	}
+	comma, _ := utf8.DecodeRuneInString(*delimiter)
+	if *delimiter == "tab" || *delimiter == `\t` {
+		comma = '\t'
+	} else if err == nil && utf8.RuneCountInString(*delimiter) != 1 {
+		err = fmt.Errorf("bad -delimiter %#v, want one character", *delimiter)
+	}
+	version, ok := tableVersions[*versionName]
+	if err == nil && !ok {
+		err = fmt.Errorf("bad -version %#v, want 3, 7 or vfp", *versionName)
+	}
+	opts := []dbf.WriterOption{dbf.WithTableVersion(version)}
+	switch cfg.Encoding {
+	case "", dbf.EncodingUTF8:
+	case "auto", "language":
+		if err == nil {
+			err = errors.New("-encoding " + cfg.Encoding + " is for reading a table, name the code page to write")
+		}
+	default:
+		opts = append(opts, dbf.WithEncoding(cfg.Encoding))
+		if *replacement != "" {
+			opts = append(opts, dbf.WithReplacement(*replacement))
+		}
+	}
+	if err == nil && flag.NArg() > 1 {
+		err = errors.New("usage: csv2dbf [-schema spec | -schema-file file] [-delimiter ,] [-version 3|7|vfp] [in.csv] > out.dbf")
+	}
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+
+	var in io.Reader = bufio.NewReader(os.Stdin)
+	if flag.NArg() == 1 {
+		fin, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fin.Close()
+		in = bufio.NewReader(fin)
+	}
+	err = convert(in, os.Stdout, fields, comma, version, opts)
+	if err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,157 @@
+// Convert a dbf to CSV.
+//
+//	dbf2csv [-delimiter ,] [-null NULL] [-output-encoding windows-1252] [-include-deleted] [in.dbf] > out.csv
+//
+// The dbf is read from stdin if no file is named. Text is written as UTF-8 unless
+// -output-encoding names a single byte code page; characters it lacks become
+// -replacement, or fail the conversion if that is empty.
+//
+// Flags shared with the other tools also default from the environment, see internal/cliconfig.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"unicode/utf8"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+)
+
+// encodingWriter transcodes UTF-8 written to it, holding back a character
+// split across writes until the rest arrives
+type encodingWriter struct {
+	w       io.Writer
+	enc     dbf.Encoder
+	pending []byte
+}
+
+func (ew *encodingWriter) Write(p []byte) (int, error) {
+	buf := append(ew.pending, p...)
+	n := len(buf)
+	for i := n - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				n = i
+			}
+			break
+		}
+	}
+	out, err := ew.enc.Bytes(buf[:n])
+	if err != nil {
+		return 0, err
+	}
+	ew.pending = append([]byte(nil), buf[n:]...)
+	_, err = ew.w.Write(out)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close encodes whatever is held back
+func (ew *encodingWriter) Close() error {
+	if len(ew.pending) == 0 {
+		return nil
+	}
+	out, err := ew.enc.Bytes(ew.pending)
+	ew.pending = nil
+	if err == nil {
+		_, err = ew.w.Write(out)
+	}
+	return err
+}
+
+// parseDelimiter is the -delimiter flag as a rune, "tab" or \t for a tab
+func parseDelimiter(s string) (rune, error) {
+	switch s {
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("bad -delimiter %#v, want one character", s)
+	}
+	return r, nil
+}
+
+func convert(in io.Reader, out io.Writer, cfg *cliconfig.Config, opts *dbf.ExportOptions, enc dbf.Encoder) error {
+	d, err := dbf.NewDbf(in, cfg.DbfOptions()...)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	_, err = cfg.ResolveEncoding(d)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(out)
+	var w io.Writer = bw
+	var ew *encodingWriter
+	if enc != nil {
+		ew = &encodingWriter{w: bw, enc: enc}
+		w = ew
+	}
+	err = dbf.WriteCSV(d, w, opts)
+	if err == nil && ew != nil {
+		err = ew.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func main() {
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.Encoding|cliconfig.Deleted|cliconfig.Fields|cliconfig.Strictness|cliconfig.Where, "")
+	delimiter := flag.String("delimiter", ",", "field delimiter, tab for a tab")
+	null := flag.String("null", "", "write this for null values: blank numbers, dates and logicals and _NullFlags nulls")
+	outputEncoding := flag.String("output-encoding", dbf.EncodingUTF8, "encoding of the CSV text, UTF-8 or a single byte code page such as windows-1252 or IBM850")
+	replacement := flag.String("replacement", "?", "written for characters -output-encoding lacks, empty to fail instead")
+	flag.Parse()
+
+	err := cfg.Parsed()
+	var comma rune
+	if err == nil {
+		comma, err = parseDelimiter(*delimiter)
+	}
+	var enc dbf.Encoder
+	if err == nil && *outputEncoding != dbf.EncodingUTF8 {
+		enc = dbf.NewEncoder(*outputEncoding)
+		if enc == nil {
+			err = errors.New("unknown -output-encoding " + *outputEncoding)
+		} else if *replacement != "" {
+			enc = dbf.ReplaceUnencodable(enc, *replacement)
+		}
+	}
+	if err == nil && flag.NArg() > 1 {
+		err = errors.New("usage: dbf2csv [flags] [in.dbf] > out.csv")
+	}
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	opts := cfg.ExportOptions()
+	opts.Comma = comma
+	opts.Null = *null
+
+	var in io.Reader = bufio.NewReader(os.Stdin)
+	if flag.NArg() == 1 {
+		fin, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		in = fin
+	}
+	err = convert(in, os.Stdout, cfg, opts, enc)
+	if err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
+}
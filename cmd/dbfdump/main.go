@@ -0,0 +1,133 @@
+// Print the records of .dbf files, or of the .dbf members of zip bundles, as a table, CSV or NDJSON.
+//
+//	dbfdump [-format table|csv|json] [-skip N] [-limit N] [-fields A,B] file.dbf|bundle.zip ...
+//
+// -skip and -limit count per file. With more than one file or a zip, table and
+// CSV output start each file with a "==> name <==" line.
+//
+// Flags shared with the other tools also default from the environment, see internal/cliconfig.
+
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+)
+
+var outputFormats = []string{"table", "csv", "json", "ndjson"}
+
+// dumper prints each file to out
+type dumper struct {
+	cfg     *cliconfig.Config
+	out     io.Writer
+	skip    int64
+	limit   int64
+	headers bool
+}
+
+// oneLine keeps a value on its record's line and out of the column layout
+var oneLine = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// writeTable prints the CSV export of d aligned in columns
+func writeTable(d *dbf.Dbf, w io.Writer, opts *dbf.ExportOptions) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(dbf.WriteCSV(d, pw, opts))
+	}()
+	cr := csv.NewReader(pr)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			pr.CloseWithError(err)
+			return err
+		}
+		for i, v := range row {
+			row[i] = oneLine.Replace(v)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (dd *dumper) dump(name string, d *dbf.Dbf) error {
+	if dd.headers && dd.cfg.Format != "json" && dd.cfg.Format != "ndjson" {
+		fmt.Fprintf(dd.out, "==> %s <==\n", name)
+	}
+	_, err := dd.cfg.ResolveEncoding(d)
+	if err != nil {
+		return err
+	}
+	opts := dd.cfg.ExportOptions()
+	opts.Skip = dd.skip
+	opts.Limit = dd.limit
+	switch dd.cfg.Format {
+	case "csv":
+		return dbf.WriteCSV(d, dd.out, opts)
+	case "json", "ndjson":
+		return dbf.WriteNDJSON(d, dd.out, opts)
+	}
+	return writeTable(d, dd.out, opts)
+}
+
+func (dd *dumper) dumpPath(path string) error {
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		return dbf.WalkZips([]string{path}, 1, func(zipName, member string, d *dbf.Dbf) error {
+			return dd.dump(zipName+" "+member, d)
+		}, dd.cfg.DbfOptions()...)
+	}
+	fin, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	d, err := dbf.NewDbf(fin, dd.cfg.DbfOptions()...)
+	if err != nil {
+		fin.Close()
+		return err
+	}
+	defer d.Close()
+	return dd.dump(path, d)
+}
+
+func main() {
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.All, "table", outputFormats...)
+	skip := flag.Int64("skip", 0, "records to skip at the start of each file")
+	limit := flag.Int64("limit", 0, "most records to print from each file, 0 for all")
+	flag.Parse()
+	err := cfg.Parsed(outputFormats...)
+	if err != nil || flag.NArg() == 0 || *skip < 0 || *limit < 0 {
+		if err != nil {
+			log.Print(err)
+		}
+		fmt.Fprintln(os.Stderr, "usage: dbfdump [-format table|csv|json] [-skip N] [-limit N] [-fields A,B] file.dbf|bundle.zip ...")
+		os.Exit(2)
+	}
+	dd := &dumper{cfg: cfg, out: os.Stdout, skip: *skip, limit: *limit, headers: flag.NArg() > 1}
+	for _, path := range flag.Args() {
+		if strings.HasSuffix(strings.ToLower(path), ".zip") {
+			dd.headers = true
+		}
+	}
+	failed := false
+	for _, path := range flag.Args() {
+		err = dd.dumpPath(path)
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,177 @@
+// Print the header and fields of dbf files without reading their records.
+//
+//	dbfinfo [-json] file.dbf ...
+//	dbfinfo -strict -encoding auto file.dbf
+//
+// With -json (-format json) each file is one JSON object per line. The
+// encoding reported is the one -encoding picks, by default the one the
+// header language byte names; -encoding auto reads records to guess it.
+// Flags shared with the other tools also default from the environment, see
+// internal/cliconfig.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+)
+
+// versionNames describe the version bytes seen in the wild
+var versionNames = map[byte]string{
+	0x02: "dBASE II / FoxBASE",
+	0x03: "dBASE III / FoxPro, no memo",
+	0x04: "dBASE 7, no memo",
+	0x30: "Visual FoxPro",
+	0x31: "Visual FoxPro, autoincrement",
+	0x32: "Visual FoxPro, varchar",
+	0x43: "dBASE IV SQL table, no memo",
+	0x63: "dBASE IV SQL system, no memo",
+	0x83: "dBASE III, memo",
+	0x8b: "dBASE IV, memo",
+	0x8c: "dBASE 7, memo",
+	0xcb: "dBASE IV SQL table, memo",
+	0xf5: "FoxPro 2, memo",
+	0xfb: "FoxBASE",
+}
+
+type fieldInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Length   int    `json:"length"`
+	Decimals int    `json:"decimals"`
+}
+
+type info struct {
+	File        string `json:"file"`
+	Version     byte   `json:"version"`
+	VersionName string `json:"version_name,omitempty"`
+	Updated     string `json:"updated"`
+	Records     uint32 `json:"records"`
+	// SizeRecords is the count the file size implies, -1 if unknown
+	SizeRecords int64       `json:"size_records"`
+	HeaderBytes uint16      `json:"header_bytes"`
+	RecordBytes uint16      `json:"record_bytes"`
+	Language    byte        `json:"language"`
+	Encoding    string      `json:"encoding,omitempty"`
+	Driver      string      `json:"driver,omitempty"`
+	Fields      []fieldInfo `json:"fields"`
+	// Anomalies are where the header disagrees with the file
+	Anomalies []string `json:"anomalies,omitempty"`
+}
+
+func readInfo(path string, cfg *cliconfig.Config) (*info, error) {
+	fin, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// header anomalies are printed rather than logged
+	d, err := dbf.NewDbf(fin, append(cfg.DbfOptions(), dbf.WithLogger(nil))...)
+	if err != nil {
+		fin.Close()
+		return nil, err
+	}
+	defer d.Close()
+	encoding, err := cfg.ResolveEncoding(d)
+	if err != nil {
+		return nil, err
+	}
+	if encoding == "" {
+		encoding = dbf.LanguageEncoding(d.Language)
+	}
+	x := &info{
+		File:        path,
+		Version:     d.Version,
+		VersionName: versionNames[d.Version],
+		Updated:     fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day),
+		Records:     d.NumRecords,
+		SizeRecords: d.SizeRecords,
+		HeaderBytes: d.NumHeaderBytes,
+		RecordBytes: d.NumRecordBytes,
+		Language:    d.Language,
+		Encoding:    encoding,
+		Driver:      d.DriverName,
+		Fields:      make([]fieldInfo, len(d.Fields)),
+	}
+	for i, f := range d.Fields {
+		x.Fields[i] = fieldInfo{f.Name, string(rune(f.Type)), f.Width, f.DecimalCount()}
+	}
+	for _, a := range d.Anomalies() {
+		x.Anomalies = append(x.Anomalies, a.String())
+	}
+	return x, nil
+}
+
+func printInfo(w io.Writer, x *info) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "file\t%s\n", x.File)
+	fmt.Fprintf(tw, "version\t%s\n", strings.TrimSpace(fmt.Sprintf("0x%02x %s", x.Version, x.VersionName)))
+	fmt.Fprintf(tw, "updated\t%s\n", x.Updated)
+	if x.SizeRecords >= 0 && x.SizeRecords != int64(x.Records) {
+		fmt.Fprintf(tw, "records\t%d (%d by file size)\n", x.Records, x.SizeRecords)
+	} else {
+		fmt.Fprintf(tw, "records\t%d\n", x.Records)
+	}
+	fmt.Fprintf(tw, "record length\t%d\n", x.RecordBytes)
+	fmt.Fprintf(tw, "header length\t%d\n", x.HeaderBytes)
+	fmt.Fprintf(tw, "language\t%s\n", strings.TrimSpace(fmt.Sprintf("0x%02x %s", x.Language, x.Encoding)))
+	if x.Driver != "" {
+		fmt.Fprintf(tw, "driver\t%s\n", x.Driver)
+	}
+	for _, a := range x.Anomalies {
+		fmt.Fprintf(tw, "anomaly\t%s\n", a)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "NAME\tTYPE\tLENGTH\tDECIMALS")
+	for _, f := range x.Fields {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", f.Name, f.Type, f.Length, f.Decimals)
+	}
+	return tw.Flush()
+}
+
+var outputFormats = []string{"text", "json"}
+
+func main() {
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.Encoding|cliconfig.Strictness|cliconfig.Format, "text", outputFormats...)
+	asJSON := flag.Bool("json", false, "print JSON, one object per file per line, same as -format json")
+	flag.Parse()
+	if *asJSON {
+		cfg.Format = "json"
+	}
+	if flag.NArg() == 0 || cfg.Parsed(outputFormats...) != nil {
+		fmt.Fprintln(os.Stderr, "usage: dbfinfo [-json] [-format text|json] [-encoding E] [-strict] file.dbf ...")
+		os.Exit(2)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	failed := false
+	for i, path := range flag.Args() {
+		x, err := readInfo(path, cfg)
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+			failed = true
+			continue
+		}
+		if cfg.Format == "json" {
+			err = enc.Encode(x)
+		} else {
+			if i > 0 {
+				fmt.Println()
+			}
+			err = printInfo(os.Stdout, x)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,264 @@
+// Convert a dbf on stdin to CSV, NDJSON, XML or an Arrow IPC stream on stdout, or with -schema convert CSV or NDJSON on stdin to a dbf on stdout.
+//
+//	dbfpipe < in.dbf > out.csv
+//	dbfpipe -format ndjson -fields NAME,POP < in.dbf > out.ndjson
+//	dbfpipe -format ndjson -typed < in.dbf > out.ndjson
+//	dbfpipe -format arrow < in.dbf > out.arrows
+//	dbfpipe -drop OWNER2 -mask PHONE:4 -hash OWNER -hash-key-file key.txt < parcels.dbf > parcels.csv
+//	dbfpipe -guess-encoding < in.dbf
+//	dbfpipe -schema NAME:C:20,POP:N:9:0 < in.csv > out.dbf
+//	dbfpipe -from ndjson -schema NAME:C:20,POP:N:9:0 < in.ndjson > out.dbf
+//
+// A dbf written to a pipe is streamed with a record count of 0 in its
+// header, readers count its records from the size of the file.
+//
+// Flags shared with the other tools also default from the environment, see internal/cliconfig.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+)
+
+// rowReader returns the next row of values in field order, io.EOF after the last
+type rowReader func() ([]string, error)
+
+func csvRows(r io.Reader, fields []dbf.DbfField) (rowReader, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	colIndex := make([]int, len(fields))
+	for fi, f := range fields {
+		colIndex[fi] = -1
+		for ci, name := range header {
+			if name == f.Name {
+				colIndex[fi] = ci
+				break
+			}
+		}
+	}
+	row := make([]string, len(fields))
+	return func() ([]string, error) {
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		for fi, ci := range colIndex {
+			row[fi] = ""
+			if ci >= 0 && ci < len(record) {
+				row[fi] = record[ci]
+			}
+		}
+		return row, nil
+	}, nil
+}
+
+func ndjsonRows(r io.Reader, fields []dbf.DbfField) rowReader {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	row := make([]string, len(fields))
+	return func() ([]string, error) {
+		var ob map[string]interface{}
+		err := dec.Decode(&ob)
+		if err != nil {
+			return nil, err
+		}
+		for fi, f := range fields {
+			row[fi] = ""
+			switch v := ob[f.Name].(type) {
+			case nil:
+			case string:
+				row[fi] = v
+			case json.Number:
+				row[fi] = v.String()
+			case bool:
+				if v {
+					row[fi] = "T"
+				} else {
+					row[fi] = "F"
+				}
+			default:
+				return nil, fmt.Errorf("field %s: unsupported json value %#v", f.Name, v)
+			}
+		}
+		return row, nil
+	}
+}
+
+// parseRedactions builds export redactions from the -drop, -hash and -mask
+// flags, each a comma separated list of field names, -mask fields
+// optionally NAME:keep.
+func parseRedactions(drop, hash, mask, keyFile string) ([]dbf.Redaction, error) {
+	var out []dbf.Redaction
+	names := func(list string) []string {
+		if list == "" {
+			return nil
+		}
+		return strings.Split(list, ",")
+	}
+	for _, name := range names(drop) {
+		out = append(out, dbf.Redaction{Field: name, Action: dbf.RedactDrop})
+	}
+	if hash != "" {
+		if keyFile == "" {
+			return nil, errors.New("-hash needs -hash-key-file")
+		}
+		key, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key = []byte(strings.TrimSpace(string(key)))
+		for _, name := range names(hash) {
+			out = append(out, dbf.Redaction{Field: name, Action: dbf.RedactHash, Key: key})
+		}
+	}
+	for _, spec := range names(mask) {
+		r := dbf.Redaction{Field: spec, Action: dbf.RedactMask}
+		if colon := strings.IndexByte(spec, ':'); colon >= 0 {
+			keep, err := strconv.Atoi(spec[colon+1:])
+			if err != nil {
+				return nil, fmt.Errorf("bad -mask %#v: %v", spec, err)
+			}
+			r.Field = spec[:colon]
+			r.Keep = keep
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// toDbf streams rows to a table on out. On a regular file the record count
+// is patched into the header at the end; on a pipe it is left 0, for
+// readers to count the records from the size of the file.
+func toDbf(in io.Reader, out *os.File, from, schema string) error {
+	fields, err := cliconfig.ParseSchema(schema)
+	if err != nil {
+		return err
+	}
+	var next rowReader
+	switch from {
+	case "csv":
+		next, err = csvRows(in, fields)
+	case "ndjson", "json":
+		next = ndjsonRows(in, fields)
+	default:
+		return errors.New("unknown -from format " + from)
+	}
+	if err != nil {
+		return err
+	}
+	w, err := cliconfig.CreateDbf(out, fields)
+	if err != nil {
+		return err
+	}
+	for {
+		row, err := next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		err = w.WriteRecord(row...)
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func fromDbf(in io.Reader, out io.Writer, cfg *cliconfig.Config, redact []dbf.Redaction, typed, guessEncoding bool) error {
+	d, err := dbf.NewDbf(bufio.NewReader(in), cfg.DbfOptions()...)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if guessEncoding {
+		encoding, err := d.GuessEncoding()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "language byte 0x%02x, text looks like %s\n", d.Language, encoding)
+		return err
+	}
+	encoding, err := cfg.ResolveEncoding(d)
+	if err != nil {
+		return err
+	}
+	if encoding != "" {
+		log.Printf("text is %s, output as UTF-8", encoding)
+	}
+	opts := cfg.ExportOptions()
+	opts.Redact = redact
+	opts.Typed = typed
+	bw := bufio.NewWriter(out)
+	switch cfg.Format {
+	case "csv":
+		err = dbf.WriteCSV(d, bw, opts)
+	case "ndjson", "json":
+		err = dbf.WriteNDJSON(d, bw, opts)
+	case "xml":
+		err = dbf.WriteXML(d, bw, opts)
+	case "arrow":
+		err = dbf.WriteArrow(d, bw, opts)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+var outputFormats = []string{"csv", "ndjson", "json", "xml", "arrow"}
+
+func main() {
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.All, "csv", outputFormats...)
+	flag.StringVar(&cfg.Format, "to", cfg.Format, "same as -format")
+	from := flag.String("from", "csv", "input format when writing a dbf: csv or ndjson")
+	schema := flag.String("schema", "", "write a dbf from stdin with these fields, NAME:C:20,POP:N:9:0 as name:type:length[:decimals]")
+	drop := flag.String("drop", "", "comma separated fields to leave out of the output")
+	hash := flag.String("hash", "", "comma separated fields to replace with a keyed hash")
+	hashKeyFile := flag.String("hash-key-file", "", "file holding the secret key for -hash")
+	mask := flag.String("mask", "", "comma separated fields to mask with '*', NAME:4 keeps the last 4 characters")
+	typed := flag.Bool("typed", false, "write ndjson numbers, booleans, dates and blanks as JSON types instead of strings")
+	guessEncoding := flag.Bool("guess-encoding", false, "report the likely text encoding of the dbf instead of converting it")
+	flag.Parse()
+
+	err := cfg.Parsed(outputFormats...)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	redact, err := parseRedactions(*drop, *hash, *mask, *hashKeyFile)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	if len(redact) != 0 {
+		// the audit trail goes to stderr to keep alongside the published output
+		log.Print("redactions:\n", dbf.DescribeRedactions(redact))
+	}
+	if *schema != "" {
+		err = toDbf(os.Stdin, os.Stdout, *from, *schema)
+	} else {
+		err = fromDbf(os.Stdin, os.Stdout, cfg, redact, *typed, *guessEncoding)
+	}
+	if err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,493 @@
+// Serve a directory of dbf files and shapefile bundles over HTTP.
+//
+//	dbfserve -addr :8080 -dir /data/tiger2020
+//
+// Endpoints:
+//
+//	GET /                          list tables as JSON
+//	GET /tables/NAME               schema as JSON
+//	GET /tables/NAME/records       records, ?offset=0&limit=100 and FIELD=VALUE filters
+//
+// Records are JSON unless ?format= or the Accept header asks for csv
+// (text/csv), ndjson (application/x-ndjson) or geojson
+// (application/geo+json, shapefile bundles only).
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+	"github.com/brianolson/go-dbf/shapefile"
+	"github.com/brianolson/go-dbf/shp"
+)
+
+const maxLimit = 10000
+
+type server struct {
+	dir string
+	cfg *cliconfig.Config
+}
+
+// table is one servable dbf, with its bundle if it is part of a shapefile
+type table struct {
+	Name      string `json:"name"`
+	path      string
+	bundle    *shapefile.Bundle
+	Shapefile bool `json:"shapefile"`
+}
+
+// tables lists the dbfs in the directory. Only names found here are ever
+// opened, so request paths can't reach outside it.
+func (s *server) tables() (map[string]*table, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*table)
+	for _, entry := range entries {
+		fname := entry.Name()
+		ext := filepath.Ext(fname)
+		if entry.IsDir() || strings.ToLower(ext) != ".dbf" {
+			continue
+		}
+		t := &table{Name: fname[:len(fname)-len(ext)], path: filepath.Join(s.dir, fname)}
+		if b, err := shapefile.Find(t.path); err == nil {
+			t.bundle = b
+			t.Shapefile = true
+		}
+		out[t.Name] = t
+	}
+	return out, nil
+}
+
+func (t *table) open(cfg *cliconfig.Config) (*dbf.Dbf, error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	d, err := dbf.NewDbf(f, cfg.DbfOptions()...)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(v)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tables, err := s.tables()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Path == "/" {
+		list := make([]*table, 0, len(tables))
+		for _, t := range tables {
+			list = append(list, t)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		writeJSON(w, list)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/tables/"), "/")
+	if !strings.HasPrefix(r.URL.Path, "/tables/") || len(parts) > 2 || (len(parts) == 2 && parts[1] != "records") {
+		http.NotFound(w, r)
+		return
+	}
+	t := tables[parts[0]]
+	if t == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 1 {
+		s.schema(w, t)
+	} else {
+		s.records(w, r, t)
+	}
+}
+
+type fieldInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Width    int    `json:"width"`
+	Decimals uint8  `json:"decimals"`
+}
+
+type schemaInfo struct {
+	Name       string      `json:"name"`
+	Version    byte        `json:"version"`
+	Updated    string      `json:"updated"`
+	NumRecords int64       `json:"records"`
+	Shapefile  bool        `json:"shapefile"`
+	Fields     []fieldInfo `json:"fields"`
+}
+
+func (s *server) schema(w http.ResponseWriter, t *table) {
+	d, err := t.open(s.cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer d.Close()
+	info := schemaInfo{
+		Name:       t.Name,
+		Version:    d.Version,
+		Updated:    strconv.Itoa(d.Year) + "-" + twoDigits(d.Month) + "-" + twoDigits(d.Day),
+		NumRecords: d.EffectiveRecords(),
+		Shapefile:  t.Shapefile,
+	}
+	for _, f := range d.Fields {
+		info.Fields = append(info.Fields, fieldInfo{f.Name, string(rune(f.Type)), f.Width, uint8(f.DecimalCount())})
+	}
+	writeJSON(w, info)
+}
+
+func twoDigits(x int) string {
+	if x < 10 {
+		return "0" + strconv.Itoa(x)
+	}
+	return strconv.Itoa(x)
+}
+
+// format picks the records output from ?format= or the Accept header
+func format(r *http.Request) string {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f
+	}
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/geo+json"):
+		return "geojson"
+	case strings.Contains(accept, "application/x-ndjson"):
+		return "ndjson"
+	case strings.Contains(accept, "text/csv"):
+		return "csv"
+	}
+	return "json"
+}
+
+// recordQuery is a parsed records request
+type recordQuery struct {
+	offset, limit  int64
+	filters        map[*dbf.DbfField]string
+	includeDeleted bool
+}
+
+func parseQuery(r *http.Request, d *dbf.Dbf) (*recordQuery, error) {
+	q := &recordQuery{limit: 100, filters: make(map[*dbf.DbfField]string)}
+	var err error
+	for key, values := range r.URL.Query() {
+		switch key {
+		case "offset":
+			q.offset, err = strconv.ParseInt(values[0], 10, 64)
+			if err != nil || q.offset < 0 {
+				return nil, errors.New("bad offset")
+			}
+		case "limit":
+			q.limit, err = strconv.ParseInt(values[0], 10, 64)
+			if err != nil || q.limit < 1 || q.limit > maxLimit {
+				return nil, errors.New("limit must be 1.." + strconv.Itoa(maxLimit))
+			}
+		case "format":
+		default:
+			var field *dbf.DbfField
+			for i := range d.Fields {
+				if d.Fields[i].Name == key {
+					field = &d.Fields[i]
+				}
+			}
+			if field == nil {
+				return nil, errors.New("no field " + key)
+			}
+			q.filters[field] = values[0]
+		}
+	}
+	return q, nil
+}
+
+// next advances d to the next live record passing the filters
+func (q *recordQuery) next(d *dbf.Dbf) error {
+	for {
+		err := d.Next()
+		if err != nil {
+			return err
+		}
+		if d.IsDeleted() && !q.includeDeleted {
+			continue
+		}
+		ok := true
+		for f, v := range q.filters {
+			if f.StringValue() != v {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return nil
+		}
+	}
+}
+
+// recordWriter emits records in one output format
+type recordWriter interface {
+	start(d *dbf.Dbf) error
+	record(d *dbf.Dbf) error
+	end(more bool) error
+}
+
+func (s *server) records(w http.ResponseWriter, r *http.Request, t *table) {
+	d, err := t.open(s.cfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer d.Close()
+	q, err := parseQuery(r, d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q.includeDeleted = s.cfg.IncludeDeleted
+	var out recordWriter
+	switch format(r) {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		out = &jsonRecords{w: w, offset: q.offset, deleted: q.includeDeleted}
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		out = &jsonRecords{w: w, lines: true, deleted: q.includeDeleted}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		out = &csvRecords{w: csv.NewWriter(w), deleted: q.includeDeleted}
+	case "geojson":
+		if t.bundle == nil {
+			http.Error(w, t.Name+" is not a shapefile", http.StatusNotAcceptable)
+			return
+		}
+		geo, err := openGeometry(t.bundle)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer geo.shp.Close()
+		w.Header().Set("Content-Type", "application/geo+json")
+		out = &geoJSONRecords{jsonRecords{w: w, deleted: q.includeDeleted}, geo}
+	default:
+		http.Error(w, "unknown format", http.StatusNotAcceptable)
+		return
+	}
+	err = out.start(d)
+	for i := int64(0); err == nil && i < q.offset; i++ {
+		err = q.next(d)
+	}
+	for i := int64(0); err == nil && i < q.limit; i++ {
+		err = q.next(d)
+		if err == nil {
+			err = out.record(d)
+		}
+	}
+	more := err == nil && q.next(d) == nil
+	if err == io.EOF {
+		err = nil
+	}
+	if err == nil {
+		err = out.end(more)
+	}
+	if err != nil {
+		// headers are probably gone already, all we can do is log
+		log.Print(t.Name, ": ", err)
+	}
+}
+
+// jsonRecords writes {"offset":N,"records":[...],"more":bool} or with lines, one object per line
+type jsonRecords struct {
+	w      io.Writer
+	lines  bool
+	offset int64
+	count  int
+	// deleted adds the DeletedColumn
+	deleted bool
+}
+
+func (j *jsonRecords) start(d *dbf.Dbf) error {
+	if j.lines {
+		return nil
+	}
+	_, err := io.WriteString(j.w, "{\"offset\":"+strconv.FormatInt(j.offset, 10)+",\"records\":[\n")
+	return err
+}
+
+func (j *jsonRecords) object(d *dbf.Dbf) map[string]string {
+	ob := make(map[string]string, len(d.Fields))
+	for i := range d.Fields {
+		ob[d.Fields[i].Name] = d.Fields[i].StringValue()
+	}
+	if j.deleted {
+		ob[dbf.DeletedColumn] = strconv.FormatBool(d.IsDeleted())
+	}
+	return ob
+}
+
+// item writes one array element or line
+func (j *jsonRecords) item(v interface{}) error {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if j.count != 0 && !j.lines {
+		blob = append([]byte(",\n"), blob...)
+	}
+	if j.lines {
+		blob = append(blob, '\n')
+	}
+	j.count++
+	_, err = j.w.Write(blob)
+	return err
+}
+
+func (j *jsonRecords) record(d *dbf.Dbf) error {
+	return j.item(j.object(d))
+}
+
+func (j *jsonRecords) end(more bool) error {
+	if j.lines {
+		return nil
+	}
+	_, err := io.WriteString(j.w, "\n],\"more\":"+strconv.FormatBool(more)+"}\n")
+	return err
+}
+
+type csvRecords struct {
+	w       *csv.Writer
+	row     []string
+	deleted bool
+}
+
+func (c *csvRecords) start(d *dbf.Dbf) error {
+	header := make([]string, len(d.Fields))
+	for i, f := range d.Fields {
+		header[i] = f.Name
+	}
+	if c.deleted {
+		header = append(header, dbf.DeletedColumn)
+	}
+	c.row = make([]string, len(header))
+	return c.w.Write(header)
+}
+
+func (c *csvRecords) record(d *dbf.Dbf) error {
+	for i := range d.Fields {
+		c.row[i] = d.Fields[i].StringValue()
+	}
+	if c.deleted {
+		c.row[len(d.Fields)] = strconv.FormatBool(d.IsDeleted())
+	}
+	return c.w.Write(c.row)
+}
+
+func (c *csvRecords) end(more bool) error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// geometry reads the shape for a record number of a bundle
+type geometry struct {
+	shp   *os.File
+	index []shp.IndexEntry
+}
+
+func openGeometry(b *shapefile.Bundle) (*geometry, error) {
+	shx, err := os.Open(b.Shx)
+	if err != nil {
+		return nil, err
+	}
+	defer shx.Close()
+	_, index, err := shp.ReadIndex(shx)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(b.Shp)
+	if err != nil {
+		return nil, err
+	}
+	return &geometry{f, index}, nil
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	ID         int64             `json:"id"`
+	Geometry   json.RawMessage   `json:"geometry"`
+	Properties map[string]string `json:"properties"`
+}
+
+// geoJSONRecords writes a FeatureCollection
+type geoJSONRecords struct {
+	jsonRecords
+	geo *geometry
+}
+
+func (g *geoJSONRecords) start(d *dbf.Dbf) error {
+	_, err := io.WriteString(g.w, "{\"type\":\"FeatureCollection\",\"features\":[\n")
+	return err
+}
+
+func (g *geoJSONRecords) record(d *dbf.Dbf) error {
+	n := d.RecordIndex()
+	geometry := json.RawMessage("null")
+	if n < int64(len(g.geo.index)) {
+		rec, err := shp.ReadRecordAt(g.geo.shp, g.geo.index[n])
+		if err != nil {
+			return err
+		}
+		geometry, err = rec.GeoJSON()
+		if err != nil {
+			return err
+		}
+	}
+	return g.item(geoJSONFeature{"Feature", n, geometry, g.object(d)})
+}
+
+func (g *geoJSONRecords) end(more bool) error {
+	_, err := io.WriteString(g.w, "\n]}\n")
+	return err
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	dir := flag.String("dir", ".", "directory of .dbf files and shapefile bundles to serve")
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.Deleted|cliconfig.Strictness, "")
+	flag.Parse()
+	err := cfg.Parsed()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("serving %s on %s", *dir, *addr)
+	log.Fatal(http.ListenAndServe(*addr, &server{dir: *dir, cfg: cfg}))
+}
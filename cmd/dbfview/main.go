@@ -0,0 +1,435 @@
+// Browse a dbf from the terminal: page through records, jump to a record
+// number, filter on a field value, and show one record vertically.
+//
+//	dbfview [-page 20] [-width 20] [-fields GEOID20,POP20] [-lenient] tl_2020_06_tabblock20.dbf
+//	dbfview -index NAME.ndx parcels.dbf
+//
+// A filter on a field that an index is keyed on reads the matches from the
+// index instead of scanning the table: the -index .ndx or .mdx, or else the
+// table's production .mdx.
+//
+// Commands, one per line:
+//
+//	(enter) or n   next page
+//	p              previous page
+//	g N            go to record N (0 based)
+//	r N            show record N vertically
+//	f FIELD=VALUE  only show records where FIELD is VALUE
+//	f              clear the filter
+//	i              file info and fields
+//	q              quit
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/internal/cliconfig"
+	"github.com/brianolson/go-dbf/mdx"
+	"github.com/brianolson/go-dbf/ndx"
+)
+
+// viewer is the browsing state over one seekable dbf
+type viewer struct {
+	d     *dbf.Dbf
+	out   io.Writer
+	page  int
+	width int
+	// cols are the fields shown in the page view
+	cols []*dbf.DbfField
+
+	// top is the record index at the top of the current page, history the
+	// tops of the pages paged forward from
+	top     int64
+	history []int64
+	// nextTop is the top of the page after the one shown, -1 at the end
+	nextTop int64
+
+	filterField *dbf.DbfField
+	filterValue string
+	// indexes are keyed on one field each, by upper case field name
+	indexes map[string]dbf.Index
+	// hits are the matching records in table order when the filter was
+	// found in an index, nil when it is checked record by record
+	hits []int64
+}
+
+func (v *viewer) matches() bool {
+	return v.filterField == nil || v.filterField.StringValue() == v.filterValue
+}
+
+// seek leaves d on record n, or the first match at or after n when
+// filtering
+func (v *viewer) seek(n int64) error {
+	if v.hits != nil {
+		i := sort.Search(len(v.hits), func(i int) bool { return v.hits[i] >= n })
+		if i == len(v.hits) {
+			return io.EOF
+		}
+		return v.d.RecordAt(v.hits[i])
+	}
+	err := v.d.RecordAt(n)
+	if err == nil && !v.matches() {
+		err = v.next()
+	}
+	return err
+}
+
+// next moves d to the next matching record
+func (v *viewer) next() error {
+	if v.hits != nil {
+		return v.seek(v.d.RecordIndex() + 1)
+	}
+	for {
+		err := v.d.Next()
+		if err != nil || v.matches() {
+			return err
+		}
+	}
+}
+
+func clip(s string, width int) string {
+	if len(s) > width {
+		return s[:width-1] + "~"
+	}
+	return s
+}
+
+// showPage prints up to a page of matching records starting at v.top
+func (v *viewer) showPage() error {
+	v.nextTop = -1
+	err := v.seek(v.top)
+	if err == io.EOF {
+		fmt.Fprintln(v.out, "(end of file)")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	v.top = v.d.RecordIndex()
+	tw := tabwriter.NewWriter(v.out, 0, 4, 1, ' ', 0)
+	fmt.Fprint(tw, "#")
+	for _, f := range v.cols {
+		fmt.Fprint(tw, "\t", clip(f.Name, v.width))
+	}
+	fmt.Fprintln(tw)
+	for shown := 0; shown < v.page; shown++ {
+		flag := ""
+		if v.d.IsDeleted() {
+			flag = "*"
+		}
+		fmt.Fprint(tw, v.d.RecordIndex(), flag)
+		for _, f := range v.cols {
+			fmt.Fprint(tw, "\t", clip(f.StringValue(), v.width))
+		}
+		fmt.Fprintln(tw)
+		err = v.next()
+		if err == io.EOF {
+			v.nextTop = -1
+			break
+		} else if err != nil {
+			return err
+		}
+		v.nextTop = v.d.RecordIndex()
+	}
+	return tw.Flush()
+}
+
+// pageBack moves v.top back a page of matching records: to the page it
+// was paged forward from, or else counting back a page from v.top
+func (v *viewer) pageBack() error {
+	if n := len(v.history); n > 0 {
+		v.top = v.history[n-1]
+		v.history = v.history[:n-1]
+		return nil
+	}
+	if v.hits != nil {
+		i := sort.Search(len(v.hits), func(i int) bool { return v.hits[i] >= v.top }) - v.page
+		v.top = 0
+		if i >= 0 {
+			v.top = v.hits[i]
+		}
+		return nil
+	}
+	// read back only as far as a page of matches
+	found := 0
+	for n := v.top - 1; n >= 0 && found < v.page; n-- {
+		err := v.d.RecordAt(n)
+		if err == io.EOF {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if v.matches() {
+			v.top = n
+			found++
+		}
+	}
+	if found < v.page {
+		v.top = 0
+	}
+	return nil
+}
+
+func (v *viewer) showRecord(n int64) error {
+	filterField := v.filterField
+	v.filterField = nil
+	err := v.seek(n)
+	v.filterField = filterField
+	if err == io.EOF {
+		return errors.New("no record " + strconv.FormatInt(n, 10))
+	} else if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(v.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "record\t%d\n", v.d.RecordIndex())
+	fmt.Fprintf(tw, "deleted\t%v\n", v.d.IsDeleted())
+	for i, f := range v.d.Fields {
+		fmt.Fprintf(tw, "%s\t%c(%d)\t%s\n", f.Name, f.Type, f.Width, v.d.Fields[i].StringValue())
+	}
+	return tw.Flush()
+}
+
+func (v *viewer) showInfo() {
+	d := v.d
+	fmt.Fprintf(v.out, "version 0x%02x, updated %d-%02d-%02d, %d records (%d by file size), %d bytes per record\n",
+		d.Version, d.Year, d.Month, d.Day, d.NumRecords, d.SizeRecords, d.NumRecordBytes)
+	tw := tabwriter.NewWriter(v.out, 0, 4, 2, ' ', 0)
+	for _, f := range d.Fields {
+		fmt.Fprintf(tw, "%s\t%c\t%d\t%d\n", f.Name, f.Type, f.Width, f.DecimalCount())
+	}
+	tw.Flush()
+}
+
+func (v *viewer) setFilter(arg string) error {
+	v.history = nil
+	v.hits = nil
+	if arg == "" {
+		v.filterField = nil
+		return nil
+	}
+	eq := strings.IndexByte(arg, '=')
+	if eq < 0 {
+		return errors.New("filter is FIELD=VALUE")
+	}
+	name := strings.TrimSpace(arg[:eq])
+	for i := range v.d.Fields {
+		if v.d.Fields[i].Name == name {
+			v.filterField = &v.d.Fields[i]
+			v.filterValue = strings.TrimSpace(arg[eq+1:])
+			v.top = 0
+			if index, ok := v.indexes[strings.ToUpper(name)]; ok {
+				return v.findHits(index)
+			}
+			return nil
+		}
+	}
+	return errors.New("no field " + name)
+}
+
+// findHits looks the filter value up in index. Text keys match by prefix,
+// so each hit is read to keep the exact matches. A value the index can't
+// search for leaves the filter to scan the table.
+func (v *viewer) findHits(index dbf.Index) error {
+	found, err := dbf.FindAll(index, v.filterValue)
+	if err != nil {
+		return nil
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+	hits := make([]int64, 0, len(found))
+	for _, n := range found {
+		err = v.d.RecordAt(n)
+		if err == io.EOF {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if v.matches() {
+			hits = append(hits, n)
+		}
+	}
+	v.hits = hits
+	return nil
+}
+
+// command runs one input line, returning false to quit
+func (v *viewer) command(line string) (bool, error) {
+	line = strings.TrimSpace(line)
+	cmd, arg := line, ""
+	if sp := strings.IndexByte(line, ' '); sp >= 0 {
+		cmd, arg = line[:sp], strings.TrimSpace(line[sp+1:])
+	}
+	switch cmd {
+	case "", "n":
+		if v.nextTop < 0 {
+			fmt.Fprintln(v.out, "(end of file)")
+			return true, nil
+		}
+		if v.nextTop > v.top {
+			v.history = append(v.history, v.top)
+			v.top = v.nextTop
+		}
+		return true, v.showPage()
+	case "p":
+		err := v.pageBack()
+		if err != nil {
+			return true, err
+		}
+		return true, v.showPage()
+	case "g", "r":
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || n < 0 {
+			return true, errors.New(cmd + " needs a record number")
+		}
+		if cmd == "r" {
+			return true, v.showRecord(n)
+		}
+		v.top = n
+		v.history = nil
+		return true, v.showPage()
+	case "f":
+		err := v.setFilter(arg)
+		if err != nil {
+			return true, err
+		}
+		return true, v.showPage()
+	case "i":
+		v.showInfo()
+		return true, nil
+	case "q":
+		return false, nil
+	default:
+		return true, errors.New("commands: n p g N r N f FIELD=VALUE i q")
+	}
+}
+
+// pageColumns are the fields named, or all
+func pageColumns(d *dbf.Dbf, names []string) ([]*dbf.DbfField, error) {
+	var cols []*dbf.DbfField
+	for i := range d.Fields {
+		if len(names) == 0 {
+			cols = append(cols, &d.Fields[i])
+		}
+	}
+	for _, name := range names {
+		found := false
+		for i := range d.Fields {
+			if d.Fields[i].Name == name {
+				cols = append(cols, &d.Fields[i])
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New("no field " + name)
+		}
+	}
+	return cols, nil
+}
+
+// openIndexes opens the .ndx or .mdx at path, or with path "" the
+// production .mdx of the table at name if its header says it has one, and
+// returns the indexes keyed on a single field, by upper case field name.
+// The file stays open for the life of the viewer.
+func openIndexes(d *dbf.Dbf, name, path string) (map[string]dbf.Index, error) {
+	if path == "" {
+		if d.Mdx == 0 {
+			return nil, nil
+		}
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		for _, ext := range []string{".mdx", ".MDX"} {
+			if _, err := os.Stat(base + ext); err == nil {
+				path = base + ext
+				break
+			}
+		}
+		if path == "" {
+			return nil, nil
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make(map[string]dbf.Index)
+	keyed := func(expression string, index dbf.Index) {
+		for _, field := range d.Fields {
+			if strings.EqualFold(expression, field.Name) {
+				indexes[strings.ToUpper(field.Name)] = index
+			}
+		}
+	}
+	if strings.EqualFold(filepath.Ext(path), ".ndx") {
+		x, err := ndx.Open(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		keyed(x.Expression, x)
+	} else {
+		m, err := mdx.Open(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		for _, t := range m.Tags {
+			keyed(t.Expression, t)
+		}
+	}
+	return indexes, nil
+}
+
+func main() {
+	page := flag.Int("page", 20, "records per page")
+	width := flag.Int("width", 20, "maximum column width in the page view")
+	indexPath := flag.String("index", "", "an .ndx or .mdx for the table, to filter by; default the production .mdx")
+	cfg := cliconfig.Register(flag.CommandLine, cliconfig.Fields|cliconfig.Strictness, "")
+	flag.Parse()
+	if flag.NArg() != 1 || *page < 1 || *width < 2 || cfg.Parsed() != nil {
+		fmt.Fprintln(os.Stderr, "usage: dbfview [-page 20] [-width 20] [-fields A,B] [-index file.ndx] [-lenient] file.dbf")
+		os.Exit(2)
+	}
+	fin, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	d, err := dbf.NewDbf(fin, cfg.DbfOptions()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer d.Close()
+	cols, err := pageColumns(d, cfg.Fields)
+	if err != nil {
+		log.Fatal(err)
+	}
+	indexes, err := openIndexes(d, flag.Arg(0), *indexPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	v := &viewer{d: d, out: os.Stdout, page: *page, width: *width, cols: cols, indexes: indexes}
+	v.showInfo()
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !in.Scan() {
+			break
+		}
+		more, err := v.command(in.Text())
+		if err != nil {
+			fmt.Println(err)
+		}
+		if !more {
+			break
+		}
+	}
+}
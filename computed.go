@@ -0,0 +1,87 @@
+package dbf
+
+import (
+	"errors"
+	"strings"
+)
+
+var errComputedSyntax error = errors.New("dbf computed column must be NAME = expression")
+
+// ComputedColumn is a virtual column evaluated from the current record,
+// exported alongside the real fields.
+type ComputedColumn struct {
+	Name string
+	// Type is DbfFieldNumeric for arithmetic results, else DbfFieldChar
+	Type DbfFieldType
+
+	value func() string
+}
+
+// StringValue is the value for the current record
+func (c *ComputedColumn) StringValue() string {
+	return c.value()
+}
+
+// WithComputed adds computed columns when the table is opened, each
+// "NAME = expression", see AddComputed. A bad definition fails NewDbf.
+func WithComputed(definitions ...string) Option {
+	return func(d *Dbf) {
+		d.computedDefs = append(d.computedDefs, definitions...)
+	}
+}
+
+// AddComputed adds a column defined as "NAME = expression". Expressions
+// use field names, earlier computed columns, numbers, 'text' literals,
+// + - * / and parentheses. + of text concatenates, so
+//
+//	GEOID = STATEFP10+COUNTYFP10+TRACTCE10+BLOCKCE10
+//
+// builds a block GEOID; arithmetic on numeric fields gives a numeric
+// column. Blank numeric values count as 0.
+func (d *Dbf) AddComputed(definition string) (*ComputedColumn, error) {
+	eq := strings.IndexByte(definition, '=')
+	if eq < 0 {
+		return nil, errComputedSyntax
+	}
+	name := strings.TrimSpace(definition[:eq])
+	if name == "" {
+		return nil, errComputedSyntax
+	}
+	e, err := compileExpr(d, strings.TrimSpace(definition[eq+1:]))
+	if err != nil {
+		return nil, err
+	}
+	typ := DbfFieldChar
+	if e.numeric() {
+		typ = DbfFieldNumeric
+	}
+	return d.addComputed(name, typ, func() string { return e.eval().String() })
+}
+
+// AddComputedFunc adds a column whose value is fn of the current record
+func (d *Dbf) AddComputedFunc(name string, typ DbfFieldType, fn func(d *Dbf) string) (*ComputedColumn, error) {
+	return d.addComputed(name, typ, func() string { return fn(d) })
+}
+
+func (d *Dbf) addComputed(name string, typ DbfFieldType, value func() string) (*ComputedColumn, error) {
+	for _, f := range d.Fields {
+		if f.Name == name {
+			return nil, errors.New("dbf computed column " + name + " is already a field")
+		}
+	}
+	for _, c := range d.computed {
+		if c.Name == name {
+			return nil, errors.New("dbf computed column " + name + " is already defined")
+		}
+	}
+	c := &ComputedColumn{Name: name, Type: typ, value: value}
+	d.computed = append(d.computed, c)
+	// Scan mappings may now find it
+	d.scan = nil
+	return c, nil
+}
+
+// Computed lists the computed columns in the order they were added
+func (d *Dbf) Computed() []*ComputedColumn {
+	return d.computed
+}
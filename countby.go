@@ -0,0 +1,98 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// CountKeySeparator joins the values of several fields into a CountBy key
+const CountKeySeparator = "|"
+
+var ErrUnsorted error = errors.New("dbf: CountBySorted input is not sorted by key")
+
+func countKey(fields []*DbfField, parts []string) string {
+	if len(fields) == 1 {
+		return fields[0].StringValue()
+	}
+	for i, f := range fields {
+		parts[i] = f.StringValue()
+	}
+	return strings.Join(parts, CountKeySeparator)
+}
+
+// CountBy tallies the remaining live (not deleted) records of d by the
+// values of the named fields, joined by CountKeySeparator when there is
+// more than one, e.g. CountBy(d, "STATEFP", "COUNTYFP") gives records per
+// county. Memory grows with the number of distinct keys; for large sorted
+// input see CountBySorted.
+func CountBy(d *Dbf, fields ...string) (map[string]uint64, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("dbf CountBy needs at least one field")
+	}
+	selected, err := fieldsByName(d, fields)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(selected))
+	counts := make(map[string]uint64)
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			return counts, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if d.IsDeleted() {
+			continue
+		}
+		counts[countKey(selected, parts)]++
+	}
+}
+
+// CountBySorted is CountBy for input sorted by the key fields. Each key
+// is passed to emit with its count as soon as the next key starts, so
+// memory is bounded. Out of order input fails with ErrUnsorted.
+func CountBySorted(d *Dbf, emit func(key string, count uint64) error, fields ...string) error {
+	if len(fields) == 0 {
+		return errors.New("dbf CountBySorted needs at least one field")
+	}
+	selected, err := fieldsByName(d, fields)
+	if err != nil {
+		return err
+	}
+	parts := make([]string, len(selected))
+	var key string
+	var count uint64
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if d.IsDeleted() {
+			continue
+		}
+		k := countKey(selected, parts)
+		if count != 0 && k == key {
+			count++
+			continue
+		}
+		if count != 0 {
+			if k < key {
+				return ErrUnsorted
+			}
+			err = emit(key, count)
+			if err != nil {
+				return err
+			}
+		}
+		key = k
+		count = 1
+	}
+	if count != 0 {
+		return emit(key, count)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is how a D field stores a date, YYYYMMDD
+const DateLayout = "20060102"
+
+// DefaultDateLayouts are the time.Parse layouts tried for dates in
+// character fields, in order.
+var DefaultDateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"2006/01/02",
+	DateLayout,
+}
+
+// DateFormatError is a value that is not a date in the expected layout
+type DateFormatError struct {
+	Field string
+	Value string
+}
+
+func (e *DateFormatError) Error() string {
+	return "dbf field " + e.Field + " value is not a date: " + strconv.Quote(e.Value)
+}
+
+// FormatDate is t as a D field value, "" for the zero time
+func FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(DateLayout)
+}
+
+// DateValue parses the field for the current row as a date. D fields use
+// DateLayout, Visual FoxPro T fields are decoded with their time of day,
+// other fields try layouts, DefaultDateLayouts if none are given. A blank
+// value is the zero time. A bad value fails with a *ParseError wrapping a
+// *DateFormatError.
+func (h *DbfField) DateValue(layouts ...string) (time.Time, error) {
+	raw := h.raw()
+	if raw == nil {
+		return time.Time{}, h.parseError(ErrFieldBounds)
+	}
+	if h.Type == DbfFieldDateTime && h.isBinary() {
+		t, _ := dateTime(raw)
+		return t, nil
+	}
+	v := h.StringValue()
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if h.Type == DbfFieldDate {
+		layouts = []string{DateLayout}
+	} else if len(layouts) == 0 {
+		layouts = DefaultDateLayouts
+	}
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, v)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, h.parseError(&DateFormatError{h.Name, v})
+}
+
+// DetectDateFields scans the remaining records of d for character fields
+// holding dates. It returns the field names mapped to the first of layouts
+// (DefaultDateLayouts if nil) that parses every non-blank value, for
+// fields with at least one non-blank value.
+func DetectDateFields(d *Dbf, layouts []string) (map[string]string, error) {
+	if layouts == nil {
+		layouts = DefaultDateLayouts
+	}
+	// candidates[i][j] is whether layouts[j] still fits field i
+	candidates := make([][]bool, len(d.Fields))
+	seen := make([]bool, len(d.Fields))
+	for i, f := range d.Fields {
+		if f.Type == DbfFieldChar {
+			candidates[i] = make([]bool, len(layouts))
+			for j := range layouts {
+				candidates[i][j] = true
+			}
+		}
+	}
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		for i := range d.Fields {
+			if candidates[i] == nil {
+				continue
+			}
+			v := d.Fields[i].StringValue()
+			if v == "" {
+				continue
+			}
+			seen[i] = true
+			for j, layout := range layouts {
+				if candidates[i][j] {
+					_, err := time.Parse(layout, v)
+					candidates[i][j] = err == nil
+				}
+			}
+		}
+	}
+	out := make(map[string]string)
+	for i, f := range d.Fields {
+		if !seen[i] {
+			continue
+		}
+		for j, ok := range candidates[i] {
+			if ok {
+				out[f.Name] = layouts[j]
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// ConvertDates copies the remaining records of d to a new table on w with
+// the named character fields turned into D fields, parsing each with its
+// layout, as from DetectDateFields. Deleted records are kept unless
+// WithSkipDeleted, and the header record count is the number of records
+// copied, the version and Language byte those of d, as for Rewrite.
+func ConvertDates(d *Dbf, w io.Writer, layouts map[string]string) error {
+	fields := make([]DbfField, len(d.Fields))
+	fieldLayouts := make([]string, len(d.Fields))
+	found := 0
+	for i, f := range d.Fields {
+		f.d = nil
+		if layout, ok := layouts[f.Name]; ok {
+			if f.Type != DbfFieldChar {
+				return errors.New("dbf ConvertDates field " + f.Name + " is not a character field")
+			}
+			f.Type = DbfFieldDate
+			f.Width = 8
+			f.Length = 8
+			f.Count = 0
+			fieldLayouts[i] = layout
+			found++
+		}
+		fields[i] = f
+	}
+	if found != len(layouts) {
+		return errors.New("dbf ConvertDates names a field not in the table")
+	}
+	return copyTable(d, w, fields, func(i int, v string) (string, error) {
+		if fieldLayouts[i] == "" {
+			return v, nil
+		}
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return "", nil
+		}
+		t, err := time.Parse(fieldLayouts[i], v)
+		if err != nil {
+			return "", &DateFormatError{fields[i].Name, v}
+		}
+		return FormatDate(t), nil
+	})
+}
@@ -0,0 +1,49 @@
+package dbf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConvertDatesFilter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []DbfField{{Name: "WHEN", Type: DbfFieldChar, Width: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.NumRecords = 3
+	w.Language = 0xc9
+	for _, v := range []string{"2020-01-31", "", "2019-12-01"} {
+		err = w.WriteRecord(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDbf(bytes.NewReader(buf.Bytes()), WithWhere("WHEN <> ''"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err = ConvertDates(d, &out, map[string]string{"WHEN": "2006-01-02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCount(t, out.Bytes(), 2)
+	converted, err := NewDbf(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted.Language != 0xc9 {
+		t.Errorf("language %#x, want 0xc9", converted.Language)
+	}
+	if converted.Fields[0].Type != DbfFieldDate {
+		t.Errorf("WHEN is %c, want D", converted.Fields[0].Type)
+	}
+	if err = converted.Next(); err != nil || converted.Fields[0].StringValue() != "20200131" {
+		t.Errorf("first date %q, %v", converted.Fields[0].StringValue(), err)
+	}
+}
@@ -1,14 +1,17 @@
 package dbf
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // DBase database file format, just enough to read Census shapefile bundles.
@@ -29,21 +32,212 @@ type Dbf struct {
 	DriverName     string
 	Fields         []DbfField
 
+	// SizeRecords is the record count implied by the input size, -1 if the
+	// input size is not known. Some exporters never fill in NumRecords.
+	SizeRecords int64
+
 	recordLength int
 	recordBuffer []byte
+	// rowWidth is how many bytes are read per record after the flag, see RecordWidthPolicy
+	rowWidth    int
+	widthPolicy RecordWidthPolicy
+	// flag is the deletion flag byte that precedes the current record
+	flag [1]byte
+
+	// reader is closed at the end of data or Close if it is an io.Closer
+	reader io.Reader
+	// buffered reads reader, nil to read it directly, see WithReadBuffer
+	buffered       *bufio.Reader
+	readBufferSize int
+
+	// pos is the logical position in the file, bytes consumed from reader less any pushed back in unread
+	pos int64
+	// unread are bytes to return before reading more from reader
+	unread []byte
+	// recno is the index of the current record, -1 before the first Next
+	recno int64
+	// recordPos is the position of the flag byte of the current record
+	recordPos int64
+	// dataStart is the position of the first record
+	dataStart int64
+
+	// mark is set by Mark, the reader is left open at end of data while marked
+	mark   dbfMark
+	marked bool
+	eof    bool
+	// keepOpen is set by RecordAt and WithRewind, the reader is left open
+	// at end of data
+	keepOpen bool
+	// reopen is set by WithReopen
+	reopen func() (io.Reader, error)
+
+	logf func(format string, v ...interface{})
+
+	// resync is set by WithResync, gaps are the corrupt spans skipped
+	resync bool
+	gaps   []Gap
+	// profile has the byte classes seen per column in the first profiled good records
+	profile  []byte
+	profiled int
+
+	// skipDeleted is set by WithSkipDeleted
+	skipDeleted bool
+	// filter is set by WithFilter, or from whereDef by WithWhere
+	filter   func(d *Dbf) bool
+	whereDef string
+
+	// strict is set by WithStrict
+	strict bool
+	// anomalies are the header inconsistencies found by NewDbf
+	anomalies []Anomaly
+
+	// detectTruncation is set by WithDetectTruncation
+	detectTruncation bool
+
+	// repairCount is set by WithRecordCountRepair
+	repairCount bool
+
+	// fixed is set by WithBuffers, Fields and recordBuffer must not grow
+	fixed bool
+	// scratch holds header bytes being parsed, here so that reading them
+	// does not allocate
+	scratch [48]byte
+
+	// follow is set by WithFollow
+	follow *followState
+
+	// source is set by OpenReaderAt; a Cursor stops before record limit
+	source  *readerAtSource
+	limited bool
+	limit   int64
+
+	// memo is set by AttachMemo, memoBlockSize by WithMemoBlockSize
+	memo          *memoFile
+	memoBlockSize int64
+
+	// mapped is set by OpenMmap, recordBuffer then points into the
+	// mapping or at ownBuffer
+	mapped    *mmapReader
+	ownBuffer []byte
+
+	// decoder converts character fields to UTF-8, from the Language byte
+	// if decodeLanguage is set by WithLanguageDecoding
+	decoder        Decoder
+	decodeLanguage bool
+
+	// nullFlags is the Visual FoxPro _NullFlags column, if any
+	nullFlags *DbfField
+
+	// scan caches the struct field mappings of Scan, nil until it is used
+	scan *scanCache
+
+	// aliases are the fields by logical name, set by Alias
+	aliases map[string]*DbfField
+
+	// projected are the fields set by Project, nil for all
+	projected []*DbfField
+
+	// computed are the virtual columns, computedDefs from WithComputed
+	computed     []*ComputedColumn
+	computedDefs []string
+}
+
+// Option configures a Dbf at NewDbf
+type Option func(d *Dbf)
 
-	reader io.ReadCloser
+// WithLogger sends warnings about questionable input to logf instead of the
+// standard logger. nil discards them.
+func WithLogger(logf func(format string, v ...interface{})) Option {
+	return func(d *Dbf) {
+		d.logf = logf
+	}
+}
+
+// RecordWidthPolicy chooses the record size when NumRecordBytes in the
+// header disagrees with the sum of the field widths.
+type RecordWidthPolicy int
+
+const (
+	// TrustFieldWidths reads the sum of the field widths per record, ignoring NumRecordBytes.
+	TrustFieldWidths RecordWidthPolicy = iota
+
+	// TrustHeaderWidth reads NumRecordBytes per record, skipping any extra
+	// bytes after the last field, or leaving the fields past the end blank.
+	TrustHeaderWidth
+)
+
+func (p RecordWidthPolicy) String() string {
+	switch p {
+	case TrustFieldWidths:
+		return "field widths"
+	case TrustHeaderWidth:
+		return "header width"
+	}
+	return "RecordWidthPolicy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// WithRecordWidthPolicy sets how records are read when NumRecordBytes
+// disagrees with the field widths. The default is TrustFieldWidths.
+func WithRecordWidthPolicy(p RecordWidthPolicy) Option {
+	return func(d *Dbf) {
+		d.widthPolicy = p
+	}
 }
+
+// WithBuffers makes the Dbf use caller provided memory for the field list
+// and the record buffer instead of allocating them. A header needing more
+// than cap(fields) fields or more than cap(record) bytes per record fails
+// with ErrBufferTooSmall. record also needs room past the record for the
+// gap between the field descriptors and NumHeaderBytes, plus one byte:
+// 264 more covers a Visual FoxPro backlink. No read buffer is allocated
+// either, see WithReadBuffer. Reading the header then allocates only the
+// Dbf, the field names and any anomaly records. Together with
+// WithLogger(nil) this bounds memory use for constrained targets (tinygo,
+// WASM).
+//
+// Next, RecordAt and the typed field accessors (BytesValue, Int64,
+// Float64, Bool, ...) keep to that memory. These do not, and allocate as
+// usual: Scan, Schema and GoType (reflection, Scan is left out of tinygo
+// builds), Describe and Summary, ReadAll, NextN, RecordMap, Map and
+// StringValue, computed columns and WithWhere, AttachMemo and MemoValue,
+// Cursor, and the exports.
+func WithBuffers(record []byte, fields []DbfField) Option {
+	return func(d *Dbf) {
+		d.recordBuffer = record[:0]
+		d.Fields = fields[:0]
+		d.fixed = true
+		d.readBufferSize = 0
+	}
+}
+
 type DbfFieldType uint8
 type DbfField struct {
 	Name   string
 	Type   DbfFieldType
 	Length uint8
-	Count  uint8
+	// Count is descriptor byte 17, the decimal count, except for character
+	// fields where it may be the high byte of the width. See DecimalCount.
+	Count uint8
+
+	// Width is the size in bytes of the field within a record. It is Length,
+	// except FoxPro/Clipper character fields longer than 255 keep the high
+	// byte of the width in Count.
+	Width int
 
 	// StartPos is the calulated (not read from file) position within fixed size record row
 	StartPos int
 
+	// Flags are the Visual FoxPro field flags, FieldFlagSystem etc.
+	Flags byte
+	// WorkAreaID is the dBASE III/IV work area ID, descriptor byte 20
+	WorkAreaID byte
+	// SetFields is the dBASE III/IV SET FIELDS flag, descriptor byte 23
+	SetFields bool
+	// Indexed is set for fields with a tag in the production .mdx index
+	Indexed bool
+	// nullBit is the bit of the field in _NullFlags, -1 for none
+	nullBit int
+
 	// reference to the db containing this field, so we can read a value out of the current row buffer
 	d *Dbf
 }
@@ -51,9 +245,26 @@ type DbfField struct {
 const (
 	DbfFieldNumeric DbfFieldType = DbfFieldType('N')
 	DbfFieldChar    DbfFieldType = DbfFieldType('C')
+	DbfFieldDate    DbfFieldType = DbfFieldType('D')
+	DbfFieldLogical DbfFieldType = DbfFieldType('L')
+	// DbfFieldFloat is the dBase IV floating point field, text like N but
+	// may be in exponent form
+	DbfFieldFloat DbfFieldType = DbfFieldType('F')
 )
 
 var BadHeaderLength error = errors.New("Bad dbf header length")
+var ErrBufferTooSmall error = errors.New("dbf buffer provided by WithBuffers is too small")
+
+// ErrFieldBounds is a field that does not fit in the current record, such
+// as one whose StartPos or Width was changed, or one not read from a table
+var ErrFieldBounds error = errors.New("dbf field outside the record")
+
+// UnknownVersionError is the unsupported version byte from the start of the file
+type UnknownVersionError byte
+
+func (v UnknownVersionError) Error() string {
+	return "Unknown dbf version " + strconv.FormatUint(uint64(v), 16)
+}
 
 func dbtrim(x string) string {
 	return strings.TrimFunc(x, func(r rune) bool {
@@ -61,24 +272,93 @@ func dbtrim(x string) string {
 	})
 }
 
+// trimPadding is field bytes without the spaces around them, and NULs too
+// if nul, as strings.TrimSpace or dbtrim but by a scan of the bytes so
+// that only the value is converted to a string. Non-ASCII spaces at
+// either end, rare in practice, are left to bytes.TrimFunc.
+func trimPadding(raw []byte, nul bool) []byte {
+	pad := &asciiSpace
+	if nul {
+		pad = &asciiSpaceNul
+	}
+	start, end := 0, len(raw)
+	for start < end && pad[raw[start]] {
+		start++
+	}
+	// character fields are mostly trailing spaces, skip them a word at a time
+	for end-start >= 8 && binary.LittleEndian.Uint64(raw[end-8:end]) == eightSpaces {
+		end -= 8
+	}
+	for end > start && pad[raw[end-1]] {
+		end--
+	}
+	raw = raw[start:end]
+	if len(raw) != 0 && (raw[0] >= utf8.RuneSelf || raw[len(raw)-1] >= utf8.RuneSelf) {
+		return bytes.TrimFunc(raw, func(r rune) bool {
+			return (nul && r == rune(0)) || unicode.IsSpace(r)
+		})
+	}
+	return raw
+}
+
+const eightSpaces = 0x2020202020202020
+
+// asciiSpace are the padding bytes trimPadding drops, asciiSpaceNul with NUL
+var (
+	asciiSpace    = [256]bool{' ': true, '\t': true, '\n': true, '\v': true, '\f': true, '\r': true}
+	asciiSpaceNul = [256]bool{' ': true, '\t': true, '\n': true, '\v': true, '\f': true, '\r': true, 0: true}
+)
+
 // Parse loads the next chunk of DBF header into this field record
 func (h *DbfField) Parse(data []byte) error {
-	if len(data) == 32 {
+	if len(data) == 16 {
+		// dBASE II
+		h.Name = dbtrim(string(data[0:11]))
+		h.Type = DbfFieldType(data[11])
+		h.Length = data[12]
+		h.Count = data[15]
+	} else if len(data) == 32 {
 		h.Name = dbtrim(string(data[0:11]))
 		h.Type = DbfFieldType(data[11])
 		h.Length = data[16]
 		h.Count = data[17]
+		h.Flags = data[18]
+		h.WorkAreaID = data[20]
+		h.SetFields = data[23] != 0
+		h.Indexed = data[31] != 0
 	} else if len(data) == 48 {
 		h.Name = dbtrim(string(data[0:32]))
 		h.Type = DbfFieldType(data[32])
 		h.Length = data[33]
 		h.Count = data[34]
+		h.Indexed = data[37] != 0
 	} else {
 		return BadHeaderLength
 	}
+	h.Width = int(h.Length)
+	if h.Type == DbfFieldChar {
+		h.Width += int(h.Count) << 8
+	}
 	return nil
 }
 
+// dBaseII is the version byte of dBASE II (and FoxBASE) files, which have
+// their own header layout
+const dBaseII = 0x02
+
+// dBaseIIHeaderLength is where dBASE II records start, after room for 32
+// field descriptors
+const dBaseIIHeaderLength = 8 + 32*16 + 1
+
+// isFoxPro is true for FoxPro and Visual FoxPro version bytes
+func isFoxPro(version byte) bool {
+	switch version {
+	case 0x30, 0x31, 0x32, 0xf5, 0xfb:
+		return true
+	}
+	return false
+}
+
 // GoString is the debug string describing the field header information
 func (h *DbfField) GoString() string {
 	return fmt.Sprintf("(%#v %c l=%d c=%d)", h.Name, rune(h.Type), h.Length, h.Count)
@@ -87,69 +367,221 @@ func (h *DbfField) String() string {
 	return h.GoString()
 }
 
-// StringValue is the value of this field for the current row.
+// StringValue is the value of this field for the current row. F fields
+// also drop the NUL padding some writers leave. Visual FoxPro binary
+// fields (I, Y, B, T) are decoded to text, and C fields to UTF-8 when a
+// decoder is set by WithLanguageDecoding.
 func (h *DbfField) StringValue() string {
-	return strings.TrimSpace(string(h.d.recordBuffer[h.StartPos : h.StartPos+int(h.Length)]))
+	raw := h.raw()
+	if raw == nil {
+		return ""
+	}
+	if h.isBinary() {
+		return h.binaryString(raw)
+	}
+	if h.Type == DbfFieldFloat {
+		return string(trimPadding(raw, true))
+	}
+	if h.Type == DbfFieldChar && h.d.decoder != nil {
+		// the decoded text may have non-ASCII spaces at the ends
+		return strings.TrimSpace(h.d.decodeText(trimPadding(raw, false)))
+	}
+	return string(trimPadding(raw, false))
+}
+
+// BytesValue is the field's bytes in the current row without copying them,
+// for hot loops that would rather not allocate a string per field. It is
+// only valid until the next call to Next and must not be modified. Text
+// fields have their padding trimmed as in StringValue but are not decoded;
+// Visual FoxPro binary fields (I, Y, B, T) are the raw little endian bytes.
+func (h *DbfField) BytesValue() []byte {
+	raw := h.raw()
+	if raw == nil || h.isBinary() {
+		return raw
+	}
+	return trimPadding(raw, h.Type == DbfFieldFloat)
+}
+
+// raw is the field's bytes in the current row, nil if they are not all
+// within the record buffer
+func (h *DbfField) raw() []byte {
+	if h.d == nil || h.StartPos < 0 || h.Width < 0 || h.StartPos+h.Width > len(h.d.recordBuffer) {
+		return nil
+	}
+	end := h.StartPos + h.Width
+	return h.d.recordBuffer[h.StartPos:end:end]
 }
 
+// Int64 parses the field for the current row, failing with a *ParseError.
+// F fields may be in exponent form, and N fields with decimals written
+// out, but the value must be a whole number.
 func (h *DbfField) Int64() (i int64, err error) {
-	return strconv.ParseInt(h.StringValue(), 10, 64)
+	if h.raw() == nil {
+		return 0, h.parseError(ErrFieldBounds)
+	}
+	v := h.StringValue()
+	i, err = strconv.ParseInt(v, 10, 64)
+	if err != nil && (h.Type == DbfFieldFloat || (h.Type == DbfFieldNumeric && h.Count != 0)) {
+		f, ferr := strconv.ParseFloat(v, 64)
+		if ferr == nil && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			i, err = int64(f), nil
+		}
+	}
+	if err != nil {
+		i = 0
+		err = h.parseError(err)
+	}
+	return
 }
 
-// NewDbf reads the header immediately and may return (nil, error)
-func NewDbf(reader io.ReadCloser) (d *Dbf, err error) {
-	d = &Dbf{reader: reader}
+// DecimalCount is the number of decimal places of a numeric field, 0 for
+// character fields whose Count is part of the width
+func (h *DbfField) DecimalCount() int {
+	if h.Type == DbfFieldChar {
+		return 0
+	}
+	return int(h.Count)
+}
+
+// Float64 parses the field for the current row, failing with a
+// *ParseError. N and F values carry their decimals as text, the
+// descriptor's decimal count says how many; Visual FoxPro binary fields
+// are decoded.
+func (h *DbfField) Float64() (float64, error) {
+	if h.raw() == nil {
+		return 0, h.parseError(ErrFieldBounds)
+	}
+	f, err := strconv.ParseFloat(h.StringValue(), 64)
+	if err != nil {
+		return 0, h.parseError(err)
+	}
+	return f, nil
+}
+
+// NewDbf reads the header immediately and may return (nil, error).
+//
+// Any io.Reader streams. What more the reader can do is detected: an
+// io.Seeker allows Mark/ResetToMark and seeking in ResumeDbf, an
+// io.ReaderAt allows WithFollow, and a known size sets SizeRecords. If
+// reader is an io.Closer, the Dbf owns it and closes it at the end of the
+// data or on Close; wrap it to keep ownership.
+func NewDbf(reader io.Reader, opts ...Option) (d *Dbf, err error) {
+	d = &Dbf{reader: reader, logf: defaultLogf, recno: -1, SizeRecords: -1, readBufferSize: DefaultReadBufferSize}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.startBuffering()
 	err = d.readHeader()
+	if err != nil {
+		d = nil
+		return
+	}
+	d.dataStart = d.pos
+	d.assignNullBits()
+	if d.decodeLanguage && d.decoder == nil {
+		d.decoder = NewDecoder(LanguageEncoding(d.Language))
+	}
+	err = d.countRecordsFromSize()
+	if err == nil && d.follow != nil {
+		err = d.startFollow()
+	}
+	if err == nil {
+		err = d.compileDefs()
+	}
 	if err != nil {
 		d = nil
 	}
 	return
 }
 
+// compileDefs adds the computed columns of WithComputed and the filter of
+// WithWhere
+func (d *Dbf) compileDefs() error {
+	for _, def := range d.computedDefs {
+		_, err := d.AddComputed(def)
+		if err != nil {
+			return err
+		}
+	}
+	if d.whereDef != "" {
+		return d.Where(d.whereDef)
+	}
+	return nil
+}
+
 func (d *Dbf) readHeader() error {
-	var scratch [32]byte
-	_, err := io.ReadFull(d.reader, scratch[:])
+	scratch := d.scratch[:32]
+	_, err := d.readFull(scratch[:1])
 	if err != nil {
 		return err
 	}
 	d.Version = scratch[0]
-	d.Year = int(uint8(scratch[1])) + 1900
-	d.Month = int(scratch[2])
-	d.Day = int(scratch[3])
-	d.NumRecords = binary.LittleEndian.Uint32(scratch[4:8])
-	d.NumHeaderBytes = binary.LittleEndian.Uint16(scratch[8:10])
-	d.NumRecordBytes = binary.LittleEndian.Uint16(scratch[10:12])
-	d.Incomplete = scratch[14]
-	d.Encrypted = scratch[15]
-	d.Mdx = scratch[28]
-	d.Language = scratch[29]
 	var headerSize int
-	if (d.Version & 0x07) == 4 {
-		namebuf := make([]byte, 32)
-		_, err = io.ReadFull(d.reader, namebuf)
+	// maxFields limits the descriptors read, 0 for up to the 0x0d terminator
+	maxFields := 0
+	if d.Version == dBaseII {
+		// 8 byte header then 16 byte field descriptors, data at a fixed offset
+		_, err = d.readFull(scratch[1:8])
+		if err == io.EOF {
+			// the header is cut short
+			err = io.ErrUnexpectedEOF
+		}
 		if err != nil {
 			return err
 		}
-		d.DriverName = strings.TrimSpace(string(namebuf))
-		// skip 4 bytes
-		_, err = io.ReadFull(d.reader, scratch[0:4])
+		d.NumRecords = uint32(binary.LittleEndian.Uint16(scratch[1:3]))
+		d.Month = int(scratch[3])
+		d.Day = int(scratch[4])
+		d.Year = int(scratch[5]) + 1900
+		d.NumRecordBytes = binary.LittleEndian.Uint16(scratch[6:8])
+		d.NumHeaderBytes = dBaseIIHeaderLength
+		headerSize = 16
+		maxFields = 32
+	} else {
+		_, err = d.readFull(scratch[1:])
+		if err == io.EOF {
+			// the header is cut short
+			err = io.ErrUnexpectedEOF
+		}
 		if err != nil {
 			return err
 		}
-		headerSize = 48
-	} else if (d.Version & 0x07) == 3 {
-		headerSize = 32
-	} else {
-		return fmt.Errorf("Unkown dbf version %x", d.Version)
+		d.Year = int(uint8(scratch[1])) + 1900
+		d.Month = int(scratch[2])
+		d.Day = int(scratch[3])
+		d.NumRecords = binary.LittleEndian.Uint32(scratch[4:8])
+		d.NumHeaderBytes = binary.LittleEndian.Uint16(scratch[8:10])
+		d.NumRecordBytes = binary.LittleEndian.Uint16(scratch[10:12])
+		d.Incomplete = scratch[14]
+		d.Encrypted = scratch[15]
+		d.Mdx = scratch[28]
+		d.Language = scratch[29]
+		if (d.Version & 0x07) == 4 {
+			_, err = d.readFull(scratch[:])
+			if err != nil {
+				return err
+			}
+			d.DriverName = strings.TrimSpace(string(scratch[:]))
+			// skip 4 bytes
+			_, err = d.readFull(scratch[0:4])
+			if err != nil {
+				return err
+			}
+			headerSize = 48
+		} else if (d.Version&0x07) == 3 || isFoxPro(d.Version) {
+			headerSize = 32
+		} else {
+			return UnknownVersionError(d.Version)
+		}
 	}
-	hbuf := make([]byte, headerSize)
-	_, err = io.ReadFull(d.reader, hbuf[0:1])
+	hbuf := d.scratch[:headerSize]
+	_, err = d.readFull(hbuf[0:1])
 	if err != nil {
 		return err
 	}
 	startPos := 0
 	for hbuf[0] != 0x0d {
-		_, err = io.ReadFull(d.reader, hbuf[1:])
+		_, err = d.readFull(hbuf[1:])
 		if err != nil {
 			return err
 		}
@@ -160,47 +592,244 @@ func (d *Dbf) readHeader() error {
 		}
 		field.StartPos = startPos
 		field.d = d
-		startPos += int(field.Length)
+		startPos += field.Width
+		if d.fixed && len(d.Fields) == cap(d.Fields) {
+			return ErrBufferTooSmall
+		}
 		d.Fields = append(d.Fields, field)
-		_, err = io.ReadFull(d.reader, hbuf[0:1])
+		if len(d.Fields) == maxFields {
+			// a full dBASE II descriptor table has no terminator
+			break
+		}
+		_, err = d.readFull(hbuf[0:1])
 		if err != nil {
 			return err
 		}
 	}
 	d.recordLength = startPos
+	d.rowWidth = d.recordLength
+	err = d.checkFields()
+	if err != nil {
+		return err
+	}
 	if d.recordLength+1 != int(d.NumRecordBytes) {
-		log.Print("NumRecordBytes=", d.NumRecordBytes, " calculated record length=", d.recordLength)
+		if d.widthPolicy == TrustHeaderWidth && d.NumRecordBytes > 0 {
+			d.rowWidth = int(d.NumRecordBytes) - 1
+		}
+		err = d.anomaly(AnomalyRecordLength, int64(d.NumRecordBytes), int64(d.recordLength+1), "NumRecordBytes=%d calculated record length=%d, using %s", d.NumRecordBytes, d.recordLength, d.widthPolicy)
+		if err != nil {
+			return err
+		}
+	}
+	bufferLength := d.recordLength
+	if d.rowWidth > bufferLength {
+		bufferLength = d.rowWidth
+	}
+	if d.fixed {
+		if cap(d.recordBuffer) < bufferLength {
+			return ErrBufferTooSmall
+		}
+		d.recordBuffer = d.recordBuffer[:bufferLength]
+	} else {
+		d.recordBuffer = make([]byte, bufferLength)
+	}
+	for i := d.rowWidth; i < bufferLength; i++ {
+		// fields past a short header width read as blank
+		d.recordBuffer[i] = ' '
 	}
-	d.recordBuffer = make([]byte, d.recordLength)
 
-	return nil
+	return d.syncDataStart()
+}
+
+// isRecordFlag is true for the live and deleted record flags
+func isRecordFlag(b byte) bool {
+	return b == ' ' || b == '*'
+}
+
+func isRecordStart(b byte) bool {
+	return isRecordFlag(b) || b == 0x1a
+}
+
+// syncDataStart skips any bytes between the 0x0d header terminator and
+// NumHeaderBytes (Visual FoxPro backlink, vendor padding), checking that
+// the first record starts with a plausible deletion flag. If it doesn't but
+// the byte right after the terminator does, NumHeaderBytes was wrong and
+// the records start there instead. A NumHeaderBytes inside the field
+// descriptors is wrong too, the records follow the terminator.
+func (d *Dbf) syncDataStart() error {
+	padLength := int64(d.NumHeaderBytes) - d.pos
+	if padLength < 0 {
+		return d.anomaly(AnomalyHeaderLength, int64(d.NumHeaderBytes), d.pos, "NumHeaderBytes=%d but the field descriptors end at %d", d.NumHeaderBytes, d.pos)
+	}
+	if expected := expectedHeaderPadding(d.Version); expected >= 0 && padLength != expected {
+		d.addAnomaly(AnomalyHeaderPadding, expected, padLength, "%d bytes after the field descriptors to NumHeaderBytes=%d, %d usual for version %#x", padLength, d.NumHeaderBytes, expected, d.Version)
+	}
+	if padLength == 0 {
+		return nil
+	}
+	var pad []byte
+	if d.fixed {
+		// after the record, where pushing back some of it leaves the
+		// record alone
+		end := len(d.recordBuffer) + int(padLength) + 1
+		if end > cap(d.recordBuffer) {
+			return ErrBufferTooSmall
+		}
+		pad = d.recordBuffer[len(d.recordBuffer):end]
+	} else {
+		pad = make([]byte, padLength+1)
+	}
+	n, err := d.readFull(pad)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		// no records after the header
+		d.unreadBytes(pad[:n])
+		return nil
+	} else if err != nil {
+		return err
+	}
+	first := pad[padLength]
+	if isRecordStart(first) {
+		d.unreadBytes(pad[padLength:])
+		return nil
+	}
+	if isRecordStart(pad[0]) {
+		start := d.pos - int64(len(pad))
+		err = d.anomaly(AnomalyRecordStart, int64(d.NumHeaderBytes), start, "NumHeaderBytes=%d but records start at %d", d.NumHeaderBytes, start)
+		d.unreadBytes(pad)
+		return err
+	}
+	err = d.anomaly(AnomalyRecordStart, int64(d.NumHeaderBytes), int64(d.NumHeaderBytes), "no plausible record start after header, byte %d=%#x", d.NumHeaderBytes, first)
+	d.unreadBytes(pad[padLength:])
+	return err
+}
+
+// WithSkipDeleted makes Next pass over records marked deleted, so only
+// live records are seen. RecordIndex still counts the deleted ones.
+func WithSkipDeleted() Option {
+	return func(d *Dbf) {
+		d.skipDeleted = true
+	}
 }
 
 // Next returns nil error when ok, io.EOF as apporpriate, or other underlying errors.
 func (d *Dbf) Next() error {
-	if d.reader == nil {
+	err := d.next()
+	for err == nil && d.passOver() {
+		err = d.next()
+	}
+	if err == nil {
+		return d.checkRecord(d.recordBuffer, d.recno, d.recordPos)
+	}
+	return d.wrapNextError(err)
+}
+
+// wrapNextError is a *ParseError for the record next failed to read
+func (d *Dbf) wrapNextError(err error) error {
+	if err != nil && err != io.EOF {
+		return &ParseError{Offset: d.recordPos, Record: d.recno + 1, Err: err}
+	}
+	return err
+}
+
+func (d *Dbf) next() error {
+	if d.reader == nil || d.eof || d.atLimit() {
 		return io.EOF
 	}
-	actual, err := d.reader.Read(d.recordBuffer[0:1])
-	if err != nil {
+	if d.follow != nil {
+		err := d.waitForRecord()
+		if err != nil {
+			return err
+		}
+	}
+	d.recordPos = d.pos
+	actual, err := d.read(d.flag[:])
+	if d.detectTruncation && actual != 1 && (err == nil || err == io.EOF) {
+		// the data ran out without an end of file marker
+		d.atEnd()
+		return d.truncated(nil)
+	} else if err != nil {
 		return err
 	} else if actual != 1 {
-		d.Close()
+		d.atEnd()
 		return io.EOF
 	}
-	if d.recordBuffer[0] == 0x1a {
-		d.Close()
+	if d.flag[0] == 0x1a {
+		d.atEnd()
 		return io.EOF
 	}
-	_, err = io.ReadFull(d.reader, d.recordBuffer)
+	if d.resync && !isRecordFlag(d.flag[0]) {
+		err = d.resyncAfter(d.flag[0])
+		if err != nil {
+			return err
+		}
+		return d.next()
+	}
+	n, err := d.readRow()
+	if d.detectTruncation && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+		partial := append([]byte{d.flag[0]}, d.recordBuffer[:n]...)
+		d.atEnd()
+		return d.truncated(partial)
+	}
+	if err == nil && d.resync && !d.plausibleRecord(d.recordBuffer[:d.rowWidth]) {
+		// looks shifted, look for a good start after this flag byte
+		d.unreadBytes(append([]byte(nil), d.recordBuffer[:d.rowWidth]...))
+		err = d.resyncAfter(d.flag[0])
+		if err != nil {
+			return err
+		}
+		return d.next()
+	}
+	if err == nil {
+		d.recno++
+		if d.resync {
+			d.learnProfile(d.recordBuffer[:d.rowWidth])
+		}
+	}
 	return err
 }
 
+// RawRecord is the bytes of the current record after the deletion flag,
+// laid out by field StartPos and Width. It is only valid until the next
+// call to Next.
+func (d *Dbf) RawRecord() []byte {
+	return d.recordBuffer[:d.recordLength]
+}
+
+// IsDeleted is true if the current record is marked deleted ('*' flag).
+func (d *Dbf) IsDeleted() bool {
+	return d.flag[0] == '*'
+}
+
+// atEnd closes the reader when the data runs out, unless a Mark, RecordAt
+// or Reset may still need it
+func (d *Dbf) atEnd() {
+	if d.marked || d.keepOpen {
+		d.eof = true
+	} else {
+		d.Close()
+	}
+}
+
+// RecordIndex is the 0 based index of the current record, -1 before the first Next.
+func (d *Dbf) RecordIndex() int64 {
+	return d.recno
+}
+
+// Seekable is true when the input is an io.Seeker, needed by Mark and
+// ResetToMark. It is false once the input is done with.
+func (d *Dbf) Seekable() bool {
+	_, ok := d.reader.(io.Seeker)
+	return ok
+}
+
+// Close closes the input if it is an io.Closer. No more records can be read.
 func (d *Dbf) Close() error {
-	if d.reader != nil {
-		err := d.reader.Close()
+	d.buffered = nil
+	d.unmapRow()
+	if closer, ok := d.reader.(io.Closer); ok {
 		d.reader = nil
-		return err
+		return closer.Close()
 	}
+	d.reader = nil
 	return nil
 }
@@ -0,0 +1,166 @@
+package dbf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Description is catalog metadata for a table, from Describe
+type Description struct {
+	Version     byte   `json:"version"`
+	Updated     string `json:"updated"`
+	Language    byte   `json:"language"`
+	HeaderBytes uint16 `json:"header_bytes"`
+	RecordBytes uint16 `json:"record_bytes"`
+	// DeclaredRecords is the count from the header
+	DeclaredRecords uint32 `json:"declared_records"`
+	// Records is how many were read, Deleted of them marked deleted
+	Records int64 `json:"records"`
+	Deleted int64 `json:"deleted"`
+
+	Columns []ColumnProfile `json:"columns"`
+
+	// Sample is the first live records, field name to value
+	Sample []map[string]string `json:"sample"`
+}
+
+// ColumnProfile summarizes the values of one field over the live records
+type ColumnProfile struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Width    int    `json:"width"`
+	Decimals uint8  `json:"decimals"`
+
+	// Blank counts empty or all space values, NonBlank the others
+	Blank    int64 `json:"blank"`
+	NonBlank int64 `json:"non_blank"`
+	// Distinct is a HyperLogLog estimate of the number of distinct values
+	Distinct  uint64 `json:"distinct_estimate"`
+	MinLength int    `json:"min_length"`
+	MaxLength int    `json:"max_length"`
+
+	// Numeric columns (N, F) also get these; Invalid counts values that
+	// do not parse.
+	Invalid int64    `json:"invalid,omitempty"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Mean    *float64 `json:"mean,omitempty"`
+	Median  *float64 `json:"median,omitempty"`
+}
+
+// columnProfiler accumulates a ColumnProfile
+type columnProfiler struct {
+	distinct *HyperLogLog
+	digest   *TDigest
+	sum      float64
+	seen     bool
+}
+
+func isNumericType(t DbfFieldType) bool {
+	return t == DbfFieldNumeric || t == DbfFieldFloat
+}
+
+// Describe reads the remaining records of d and returns its header,
+// schema, a profile of each column and the first sampleN live records,
+// ready to marshal as JSON for a data catalog. Memory is bounded by
+// sampleN and the fixed size estimators, not by the file size.
+func Describe(d *Dbf, sampleN int) (*Description, error) {
+	desc := &Description{
+		Version:         d.Version,
+		Updated:         fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day),
+		Language:        d.Language,
+		HeaderBytes:     d.NumHeaderBytes,
+		RecordBytes:     d.NumRecordBytes,
+		DeclaredRecords: d.NumRecords,
+		Columns:         make([]ColumnProfile, len(d.Fields)),
+		Sample:          []map[string]string{},
+	}
+	profilers := make([]columnProfiler, len(d.Fields))
+	for i, f := range d.Fields {
+		desc.Columns[i] = ColumnProfile{
+			Name:      f.Name,
+			Type:      string(rune(f.Type)),
+			Width:     f.Width,
+			Decimals:  uint8(f.DecimalCount()),
+			MinLength: -1,
+		}
+		profilers[i].distinct = NewHyperLogLog(DefaultPrecision)
+		if isNumericType(f.Type) {
+			profilers[i].digest = NewTDigest(DefaultCompression)
+		}
+	}
+	live := int64(0)
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		desc.Records++
+		if d.IsDeleted() {
+			desc.Deleted++
+			continue
+		}
+		live++
+		var row map[string]string
+		if len(desc.Sample) < sampleN {
+			row = make(map[string]string, len(d.Fields))
+			desc.Sample = append(desc.Sample, row)
+		}
+		for i := range d.Fields {
+			v := d.Fields[i].StringValue()
+			if row != nil {
+				row[d.Fields[i].Name] = v
+			}
+			col := &desc.Columns[i]
+			p := &profilers[i]
+			if len(v) > col.MaxLength {
+				col.MaxLength = len(v)
+			}
+			if col.MinLength < 0 || len(v) < col.MinLength {
+				col.MinLength = len(v)
+			}
+			if v == "" {
+				col.Blank++
+				continue
+			}
+			p.distinct.AddString(v)
+			if p.digest != nil {
+				x, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					col.Invalid++
+					continue
+				}
+				p.digest.Add(x)
+				p.sum += x
+				p.seen = true
+			}
+		}
+	}
+	for i := range desc.Columns {
+		col := &desc.Columns[i]
+		p := &profilers[i]
+		if col.MinLength < 0 {
+			col.MinLength = 0
+		}
+		col.NonBlank = live - col.Blank
+		col.Distinct = p.distinct.Estimate()
+		if p.seen {
+			min, max := p.digest.Min(), p.digest.Max()
+			mean := p.sum / float64(p.digest.Count())
+			median := p.digest.Quantile(0.5)
+			col.Min, col.Max, col.Mean, col.Median = &min, &max, &mean, &median
+		}
+	}
+	return desc, nil
+}
+
+// Summary reads the remaining records of d once and returns the record
+// and deleted counts and a profile of each field: blank and non-blank
+// counts, value lengths, a distinct estimate, and for N and F fields the
+// numeric range. It is Describe without a sample.
+func (d *Dbf) Summary() (*Description, error) {
+	return Describe(d, 0)
+}
@@ -0,0 +1,364 @@
+package dbf
+
+import (
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Encoding names, as returned by GuessEncoding and LanguageEncoding
+const (
+	EncodingASCII       = "US-ASCII"
+	EncodingUTF8        = "UTF-8"
+	EncodingWindows1252 = "windows-1252"
+	EncodingCP437       = "IBM437"
+	EncodingCP850       = "IBM850"
+
+	EncodingCP737       = "IBM737"
+	EncodingCP852       = "IBM852"
+	EncodingCP857       = "IBM857"
+	EncodingCP861       = "IBM861"
+	EncodingCP865       = "IBM865"
+	EncodingCP866       = "IBM866"
+	EncodingWindows874  = "windows-874"
+	EncodingWindows1250 = "windows-1250"
+	EncodingWindows1251 = "windows-1251"
+	EncodingWindows1253 = "windows-1253"
+	EncodingWindows1254 = "windows-1254"
+	EncodingWindows1255 = "windows-1255"
+	EncodingWindows1256 = "windows-1256"
+	EncodingWindows1257 = "windows-1257"
+)
+
+// languageEncodings are the single byte code pages of the language driver
+// IDs used by dBASE, FoxPro and ArcGIS. Multibyte Asian code pages are
+// not included.
+var languageEncodings = map[byte]string{
+	0x01: EncodingCP437,
+	0x02: EncodingCP850,
+	0x03: EncodingWindows1252,
+	0x08: EncodingCP865,
+	0x09: EncodingCP437,
+	0x0a: EncodingCP850,
+	0x0b: EncodingCP437,
+	0x0d: EncodingCP437,
+	0x0e: EncodingCP850,
+	0x0f: EncodingCP437,
+	0x10: EncodingCP850,
+	0x11: EncodingCP437,
+	0x12: EncodingCP850,
+	0x14: EncodingCP850,
+	0x15: EncodingCP437,
+	0x16: EncodingCP850,
+	0x17: EncodingCP865,
+	0x18: EncodingCP437,
+	0x19: EncodingCP437,
+	0x1a: EncodingCP850,
+	0x1b: EncodingCP437,
+	0x1d: EncodingCP850,
+	0x1f: EncodingCP852,
+	0x22: EncodingCP852,
+	0x23: EncodingCP852,
+	0x26: EncodingCP866,
+	0x37: EncodingCP850,
+	0x40: EncodingCP852,
+	0x57: EncodingWindows1252,
+	0x58: EncodingWindows1252,
+	0x59: EncodingWindows1252,
+	0x64: EncodingCP852,
+	0x65: EncodingCP866,
+	0x66: EncodingCP865,
+	0x67: EncodingCP861,
+	0x6a: EncodingCP737,
+	0x6b: EncodingCP857,
+	0x7c: EncodingWindows874,
+	0x7d: EncodingWindows1255,
+	0x7e: EncodingWindows1256,
+	0x86: EncodingCP737,
+	0x87: EncodingCP852,
+	0x88: EncodingCP857,
+	0xc8: EncodingWindows1250,
+	0xc9: EncodingWindows1251,
+	0xca: EncodingWindows1254,
+	0xcb: EncodingWindows1253,
+	0xcc: EncodingWindows1257,
+}
+
+// LanguageEncoding is the encoding named by a header Language byte, "" for
+// zero, unknown or multibyte language drivers.
+func LanguageEncoding(language byte) string {
+	return languageEncodings[language]
+}
+
+// languageFor is the header Language byte of an encoding, the lowest
+// language driver ID naming it, 0 for none
+func languageFor(encoding string) byte {
+	language := 0
+	for id, e := range languageEncodings {
+		if e == encoding && (language == 0 || int(id) < language) {
+			language = int(id)
+		}
+	}
+	return byte(language)
+}
+
+// Decoder converts the text of character fields to UTF-8. The
+// *encoding.Decoder of golang.org/x/text/encoding has this method.
+type Decoder interface {
+	Bytes(b []byte) ([]byte, error)
+}
+
+// charmapDecoder decodes a single byte code page
+type charmapDecoder struct {
+	high *[128]rune
+}
+
+func (c charmapDecoder) Bytes(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b)+len(b)/2)
+	for _, x := range b {
+		if x < 0x80 {
+			out = append(out, x)
+			continue
+		}
+		var buf [utf8.UTFMax]byte
+		n := utf8.EncodeRune(buf[:], c.high[x-0x80])
+		out = append(out, buf[:n]...)
+	}
+	return out, nil
+}
+
+// NewDecoder returns a Decoder for one of the single byte Encoding names,
+// or nil if there is none built in. US-ASCII and UTF-8 need no decoding
+// and also return nil.
+func NewDecoder(encoding string) Decoder {
+	high := charmapTables[encoding]
+	if high == nil {
+		return nil
+	}
+	return charmapDecoder{high}
+}
+
+// Encoder converts UTF-8 text to a character encoding, the reverse of
+// Decoder. The *encoding.Encoder of golang.org/x/text/encoding has this
+// method.
+type Encoder interface {
+	Bytes(b []byte) ([]byte, error)
+}
+
+// UnencodableError is a character an encoding has no byte for
+type UnencodableError struct {
+	Encoding string
+	Rune     rune
+}
+
+func (e *UnencodableError) Error() string {
+	return "dbf " + e.Encoding + " can not encode " + strconv.QuoteRune(e.Rune)
+}
+
+// charmapEncoder encodes to a single byte code page, or to US-ASCII with
+// no table
+type charmapEncoder struct {
+	encoding string
+	bytes    map[rune]byte
+}
+
+func (c charmapEncoder) Bytes(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		if r < 0x80 {
+			out = append(out, byte(r))
+			continue
+		}
+		x, ok := c.bytes[r]
+		if !ok || r == utf8.RuneError {
+			return nil, &UnencodableError{c.encoding, r}
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+// NewEncoder returns an Encoder to US-ASCII or one of the single byte
+// Encoding names, or nil if there is none built in. UTF-8 needs no
+// encoding and also returns nil. Characters the encoding lacks fail with
+// an *UnencodableError, see ReplaceUnencodable.
+func NewEncoder(encoding string) Encoder {
+	if encoding == EncodingASCII {
+		return charmapEncoder{encoding: encoding}
+	}
+	high := charmapTables[encoding]
+	if high == nil {
+		return nil
+	}
+	c := charmapEncoder{encoding, make(map[rune]byte, len(high))}
+	for i, r := range high {
+		if _, ok := c.bytes[r]; !ok {
+			c.bytes[r] = byte(0x80 + i)
+		}
+	}
+	return c
+}
+
+// replacingEncoder is ReplaceUnencodable
+type replacingEncoder struct {
+	enc         Encoder
+	replacement []byte
+}
+
+func (e replacingEncoder) Bytes(b []byte) ([]byte, error) {
+	out, err := e.enc.Bytes(b)
+	if err == nil {
+		return out, nil
+	}
+	// encode what can be a character at a time
+	out = out[:0]
+	for len(b) > 0 {
+		_, size := utf8.DecodeRune(b)
+		x, err := e.enc.Bytes(b[:size])
+		if err != nil {
+			x = e.replacement
+		}
+		out = append(out, x...)
+		b = b[size:]
+	}
+	return out, nil
+}
+
+// ReplaceUnencodable wraps enc to write replacement, e.g. "?", for each
+// character enc can not encode instead of failing.
+func ReplaceUnencodable(enc Encoder, replacement string) Encoder {
+	return replacingEncoder{enc, []byte(replacement)}
+}
+
+// WithLanguageDecoding decodes character field values to UTF-8 from the
+// code page named by the header Language byte, see LanguageEncoding. Text
+// of tables with a zero or unknown Language byte is left as it is.
+// WithDecoder takes precedence.
+func WithLanguageDecoding() Option {
+	return func(d *Dbf) {
+		d.decodeLanguage = true
+	}
+}
+
+// WithDecoder decodes character field values to UTF-8 with dec, whatever
+// the Language byte says. It is for tables whose Language byte is wrong or
+// zero; dec can be one of NewDecoder or from golang.org/x/text/encoding,
+// e.g. charmap.Windows1250.NewDecoder().
+func WithDecoder(dec Decoder) Option {
+	return func(d *Dbf) {
+		d.decoder = dec
+	}
+}
+
+// SetDecoder changes how character fields are decoded after opening, as
+// WithDecoder, e.g. to use the result of GuessEncoding. nil passes the
+// bytes through.
+func (d *Dbf) SetDecoder(dec Decoder) {
+	d.decoder = dec
+}
+
+// decodeText is a character field value as UTF-8
+func (d *Dbf) decodeText(raw []byte) string {
+	if d.decoder != nil {
+		for _, b := range raw {
+			if b >= 0x80 {
+				text, err := d.decoder.Bytes(raw)
+				if err == nil {
+					return string(text)
+				}
+				break
+			}
+		}
+	}
+	return string(raw)
+}
+
+// guessSampleRecords is how far ahead GuessEncoding looks
+const guessSampleRecords = 1000
+
+// GuessEncoding proposes the character encoding of the text in character
+// fields from a sample of the upcoming records, for files whose Language
+// byte is zero. The records are read ahead and pushed back, so the next
+// call to Next is unaffected. It returns EncodingASCII when there are no
+// bytes over 0x7f, EncodingUTF8 when they are all valid UTF-8 sequences,
+// and otherwise picks between windows-1252 and the DOS code pages 437 and
+// 850 by where the high bytes fall next to letters.
+func (d *Dbf) GuessEncoding() (string, error) {
+	if d.reader == nil {
+		return "", io.EOF
+	}
+	rowBytes := d.rowWidth + 1
+	sample, err := d.peek(guessSampleRecords * rowBytes)
+	if err != nil {
+		return "", err
+	}
+	var text []byte
+	for start := 0; start+rowBytes <= len(sample); start += rowBytes {
+		if sample[start] == 0x1a {
+			break
+		}
+		row := sample[start+1 : start+rowBytes]
+		for _, f := range d.Fields {
+			if f.Type == DbfFieldChar && f.StartPos+f.Width <= len(row) {
+				text = append(text, row[f.StartPos:f.StartPos+f.Width]...)
+				// keep fields apart so sequences don't span them
+				text = append(text, ' ')
+			}
+		}
+	}
+	return guessEncoding(text), nil
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// guessEncoding scores high bytes in text against each candidate
+func guessEncoding(text []byte) string {
+	high := 0
+	for _, b := range text {
+		if b >= 0x80 {
+			high++
+		}
+	}
+	if high == 0 {
+		return EncodingASCII
+	}
+	if utf8.Valid(text) {
+		return EncodingUTF8
+	}
+	var win, dos, cp850 int
+	for i, b := range text {
+		if b < 0x80 {
+			continue
+		}
+		// accented letters sit next to plain letters
+		nearLetter := (i > 0 && isASCIILetter(text[i-1])) || (i+1 < len(text) && isASCIILetter(text[i+1]))
+		switch {
+		case b == 0x81 || b == 0x8d || b == 0x8f || b == 0x90 || b == 0x9d:
+			// undefined in windows-1252, letters in the DOS code pages
+			dos += 2
+		case b >= 0xc0 && nearLetter:
+			// windows-1252 letters, mostly box drawing in the DOS code pages
+			win++
+			if b == 0xc6 || b == 0xc7 || (b >= 0xd0 && b <= 0xd8) || b == 0xde || (b >= 0xe0 && b <= 0xed) {
+				cp850++
+			}
+		case b <= 0xa5 && nearLetter:
+			// DOS letters, punctuation or symbols in windows-1252
+			dos++
+		case b >= 0xb5 && b <= 0xb7 && nearLetter:
+			// accented capitals in 850, box drawing in 437
+			cp850++
+		}
+	}
+	if win >= dos {
+		return EncodingWindows1252
+	}
+	if cp850 > 0 {
+		return EncodingCP850
+	}
+	return EncodingCP437
+}
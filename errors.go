@@ -0,0 +1,41 @@
+package dbf
+
+import "strconv"
+
+// ParseError locates a failure reading a record or a field value. Err is
+// the underlying error, also available by Unwrap.
+type ParseError struct {
+	// Offset is the byte position in the file of the record, or of the
+	// field value when Field is set
+	Offset int64
+	// Record is the 0 based record index
+	Record int64
+	// Field is the field name, "" for errors reading the whole record
+	Field string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	msg := "dbf record " + strconv.FormatInt(e.Record, 10)
+	if e.Field != "" {
+		msg += " field " + e.Field
+	}
+	return msg + " (offset " + strconv.FormatInt(e.Offset, 10) + "): " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError locates err at this field of the current record
+func (h *DbfField) parseError(err error) error {
+	if h.d == nil {
+		return &ParseError{Offset: -1, Record: -1, Field: h.Name, Err: err}
+	}
+	return &ParseError{
+		Offset: h.d.recordPos + 1 + int64(h.StartPos),
+		Record: h.d.recno,
+		Field:  h.Name,
+		Err:    err,
+	}
+}
@@ -0,0 +1,394 @@
+package dbf
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ExportOptions controls WriteCSV and WriteNDJSON. A nil *ExportOptions uses defaults.
+type ExportOptions struct {
+	// Comma is the CSV field delimiter, ',' if zero.
+	Comma rune
+
+	// IncludeDeleted exports records marked deleted too, with an extra
+	// DeletedColumn holding true/false. Otherwise they are skipped.
+	IncludeDeleted bool
+
+	// NextPart splits the export into parts. When set, the w passed to the
+	// exporter is not used; parts come from NextPart(1), NextPart(2), ...
+	// and are closed when full if they are io.Closers. See PartFiles.
+	NextPart func(part int) (io.Writer, error)
+
+	// PartRows starts a new part after this many rows, 0 for no limit
+	PartRows int64
+
+	// PartBytes starts a new part once a part has reached this size, 0
+	// for no limit. A part may exceed it by up to one row.
+	PartBytes int64
+
+	// Fields selects the columns to export by name, in this order. All
+	// fields and computed columns are exported if empty.
+	Fields []string
+
+	// Null is written by WriteCSV for null values, those Value gives as
+	// nil: blank numbers, dates and logicals and values null by
+	// _NullFlags. They are empty otherwise, as are blank character fields.
+	Null string
+
+	// Typed writes NDJSON values as JSON types by field type, see
+	// DbfField.Value: numbers, true/false, null for blanks, dates as
+	// "2006-01-02" and date times as DateTimeLayout. Otherwise every value
+	// is the StringValue text. Redacted columns stay text.
+	Typed bool
+
+	// Skip leaves out the first Skip records that would be exported, and
+	// Limit ends the export after Limit rows if not 0
+	Skip  int64
+	Limit int64
+
+	// BatchRows is the rows per record batch of WriteArrow,
+	// DefaultArrowBatchRows if 0
+	BatchRows int
+
+	// Redact drops, hashes or masks named columns. DescribeRedactions
+	// records what was done.
+	Redact []Redaction
+
+	// XMLRoot and XMLRow name the document and record elements of
+	// WriteXML, "records" and "record" if empty.
+	XMLRoot string
+	XMLRow  string
+}
+
+// PartFiles is a NextPart function creating files named by a pattern with
+// a %d verb for the part number, e.g. "tabblock-part-%04d.csv".
+func PartFiles(pattern string) func(part int) (io.Writer, error) {
+	return func(part int) (io.Writer, error) {
+		return os.Create(fmt.Sprintf(pattern, part))
+	}
+}
+
+// DeletedColumn is the extra column written with ExportOptions.IncludeDeleted
+const DeletedColumn = "_deleted"
+
+func (opts *ExportOptions) includeDeleted() bool {
+	return opts != nil && opts.IncludeDeleted
+}
+
+// nextExported advances d to the next record the options want exported,
+// io.EOF after opts.Limit of them
+func (out *exportOutput) nextExported(d *Dbf) error {
+	opts := out.opts
+	for {
+		if opts != nil && opts.Limit > 0 && out.exported >= opts.Limit {
+			return io.EOF
+		}
+		err := d.Next()
+		if err != nil {
+			return err
+		}
+		if !opts.includeDeleted() && d.IsDeleted() {
+			continue
+		}
+		out.seen++
+		if opts != nil && out.seen <= opts.Skip {
+			continue
+		}
+		out.exported++
+		return nil
+	}
+}
+
+// countingWriter counts bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// exportOutput is where an exporter writes, rolling over to new parts per ExportOptions
+type exportOutput struct {
+	*bufio.Writer
+
+	opts   *ExportOptions
+	count  countingWriter
+	closer io.Closer
+	part   int
+	rows   int64
+
+	// seen counts the records that could be exported, for
+	// ExportOptions.Skip; exported counts the rows of all parts
+	seen     int64
+	exported int64
+}
+
+func newExportOutput(w io.Writer, opts *ExportOptions) (*exportOutput, error) {
+	out := &exportOutput{opts: opts}
+	if opts != nil && opts.NextPart != nil {
+		err := out.nextPart()
+		return out, err
+	}
+	out.count.w = w
+	out.Writer = bufio.NewWriter(&out.count)
+	return out, nil
+}
+
+// full is true when the next row should start a new part
+func (out *exportOutput) full() bool {
+	opts := out.opts
+	if opts == nil || opts.NextPart == nil {
+		return false
+	}
+	if opts.PartRows > 0 && out.rows >= opts.PartRows {
+		return true
+	}
+	return opts.PartBytes > 0 && out.count.n+int64(out.Buffered()) >= opts.PartBytes
+}
+
+func (out *exportOutput) nextPart() error {
+	err := out.finishPart()
+	if err != nil {
+		return err
+	}
+	out.part++
+	w, err := out.opts.NextPart(out.part)
+	if err != nil {
+		return err
+	}
+	out.closer, _ = w.(io.Closer)
+	out.count = countingWriter{w: w}
+	out.rows = 0
+	if out.Writer == nil {
+		out.Writer = bufio.NewWriter(&out.count)
+	} else {
+		out.Reset(&out.count)
+	}
+	return nil
+}
+
+// finishPart flushes, and closes a part from NextPart
+func (out *exportOutput) finishPart() error {
+	if out.Writer == nil {
+		return nil
+	}
+	err := out.Flush()
+	if out.closer != nil {
+		cerr := out.closer.Close()
+		if err == nil {
+			err = cerr
+		}
+		out.closer = nil
+	}
+	return err
+}
+
+// WriteCSV writes a header row of field names and then every remaining
+// record of d, quoted as encoding/csv does.
+func WriteCSV(d *Dbf, w io.Writer, opts *ExportOptions) error {
+	cols, err := exportColumns(d, opts)
+	if err != nil {
+		return err
+	}
+	out, err := newExportOutput(w, opts)
+	if err != nil {
+		return err
+	}
+	comma := ','
+	if opts != nil && opts.Comma != 0 {
+		comma = opts.Comma
+	}
+	null := ""
+	if opts != nil {
+		null = opts.Null
+	}
+	var cw *csv.Writer
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.field.Name
+	}
+	if opts.includeDeleted() {
+		header = append(header, DeletedColumn)
+	}
+	startPart := func() error {
+		cw = csv.NewWriter(out)
+		cw.Comma = comma
+		return cw.Write(header)
+	}
+	err = startPart()
+	if err != nil {
+		return err
+	}
+	row := make([]string, len(header))
+	for {
+		err = out.nextExported(d)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		// csv.Writer buffers too, flush it so part size is known
+		cw.Flush()
+		if out.full() {
+			err = out.nextPart()
+			if err == nil {
+				err = startPart()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for i := range cols {
+			if null != "" && cols[i].isNull() {
+				row[i] = null
+			} else {
+				row[i] = cols[i].value()
+			}
+		}
+		if opts.includeDeleted() {
+			row[len(cols)] = strconv.FormatBool(d.IsDeleted())
+		}
+		err = cw.Write(row)
+		if err != nil {
+			return err
+		}
+		out.rows++
+	}
+	cw.Flush()
+	err = cw.Error()
+	if err != nil {
+		return err
+	}
+	return out.finishPart()
+}
+
+// WriteNDJSON writes every remaining record of d as one JSON object per line, keyed by field name in field order.
+// Values are strings unless opts.Typed is set.
+func WriteNDJSON(d *Dbf, w io.Writer, opts *ExportOptions) error {
+	cols, err := exportColumns(d, opts)
+	if err != nil {
+		return err
+	}
+	bw, err := newExportOutput(w, opts)
+	if err != nil {
+		return err
+	}
+	typed := opts != nil && opts.Typed
+	for {
+		err := bw.nextExported(d)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if bw.full() {
+			err = bw.nextPart()
+			if err != nil {
+				return err
+			}
+		}
+		bw.WriteByte('{')
+		for i := range cols {
+			if i != 0 {
+				bw.WriteByte(',')
+			}
+			writeJSONString(bw, cols[i].field.Name)
+			bw.WriteByte(':')
+			if typed {
+				err = cols[i].writeTypedJSON(bw)
+				if err != nil {
+					return err
+				}
+			} else {
+				writeJSONString(bw, cols[i].value())
+			}
+		}
+		if opts.includeDeleted() {
+			if len(cols) != 0 {
+				bw.WriteByte(',')
+			}
+			bw.WriteString("\"" + DeletedColumn + "\":")
+			bw.WriteString(strconv.FormatBool(d.IsDeleted()))
+		}
+		bw.WriteString("}\n")
+		bw.rows++
+	}
+	return bw.finishPart()
+}
+
+func writeJSONString(w io.Writer, s string) {
+	blob, _ := json.Marshal(s)
+	w.Write(blob)
+}
+
+// writeTypedJSON writes the column value as the JSON type for its field
+// type, for ExportOptions.Typed
+func (c *exportColumn) writeTypedJSON(w *exportOutput) error {
+	if c.redact != nil {
+		writeJSONString(w, c.value())
+		return nil
+	}
+	if c.computed != nil {
+		v := c.computed.StringValue()
+		if c.computed.Type != DbfFieldNumeric || !writeJSONNumber(w, v) {
+			writeJSONString(w, v)
+		}
+		return nil
+	}
+	v, err := c.field.Value()
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		w.WriteString("null")
+	case bool:
+		w.WriteString(strconv.FormatBool(v))
+	case int64:
+		w.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if writeJSONNumber(w, c.field.StringValue()) {
+			break
+		}
+		if s := strconv.FormatFloat(v, 'g', -1, 64); !writeJSONNumber(w, s) {
+			// NaN or an infinity
+			writeJSONString(w, s)
+		}
+	case time.Time:
+		if c.field.Type == DbfFieldDate {
+			writeJSONString(w, v.Format("2006-01-02"))
+		} else {
+			writeJSONString(w, v.Format(DateTimeLayout))
+		}
+	case []byte:
+		writeJSONString(w, string(v))
+	case string:
+		writeJSONString(w, v)
+	default:
+		writeJSONString(w, c.field.StringValue())
+	}
+	return nil
+}
+
+// writeJSONNumber writes v as a JSON number, keeping the digits as stored,
+// if it is one. NaN, infinities and hex floats are not.
+func writeJSONNumber(w *exportOutput, v string) bool {
+	if !json.Valid([]byte(v)) {
+		return false
+	}
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return false
+	}
+	w.WriteString(v)
+	return true
+}
@@ -0,0 +1,433 @@
+package dbf
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprValue is the result of evaluating an expression on a record
+type exprValue struct {
+	s     string
+	n     float64
+	isNum bool
+}
+
+func (v exprValue) String() string {
+	if v.isNum {
+		return strconv.FormatFloat(v.n, 'f', -1, 64)
+	}
+	return v.s
+}
+
+// expr is a compiled expression over the fields of a Dbf
+type expr interface {
+	eval() exprValue
+	numeric() bool
+}
+
+type literalExpr exprValue
+
+func (e literalExpr) eval() exprValue { return exprValue(e) }
+func (e literalExpr) numeric() bool   { return e.isNum }
+
+// fieldExpr reads a field of the current record. Numeric fields that are
+// blank or do not parse are 0.
+type fieldExpr struct {
+	f *DbfField
+}
+
+func (e fieldExpr) numeric() bool { return isNumericType(e.f.Type) }
+
+func (e fieldExpr) eval() exprValue {
+	v := e.f.StringValue()
+	if e.numeric() {
+		n, _ := strconv.ParseFloat(v, 64)
+		return exprValue{n: n, isNum: true}
+	}
+	return exprValue{s: v}
+}
+
+// computedExpr reads an earlier computed column
+type computedExpr struct {
+	c *ComputedColumn
+}
+
+func (e computedExpr) numeric() bool { return e.c.Type == DbfFieldNumeric }
+
+func (e computedExpr) eval() exprValue {
+	v := e.c.StringValue()
+	if e.numeric() {
+		n, _ := strconv.ParseFloat(v, 64)
+		return exprValue{n: n, isNum: true}
+	}
+	return exprValue{s: v}
+}
+
+// binaryExpr is arithmetic on numbers, or + concatenating text if either
+// side is text
+type binaryExpr struct {
+	op          byte
+	left, right expr
+}
+
+func (e binaryExpr) numeric() bool { return e.left.numeric() && e.right.numeric() }
+
+func (e binaryExpr) eval() exprValue {
+	l, r := e.left.eval(), e.right.eval()
+	if !l.isNum || !r.isNum {
+		return exprValue{s: l.String() + r.String()}
+	}
+	switch e.op {
+	case '+':
+		return exprValue{n: l.n + r.n, isNum: true}
+	case '-':
+		return exprValue{n: l.n - r.n, isNum: true}
+	case '*':
+		return exprValue{n: l.n * r.n, isNum: true}
+	default:
+		return exprValue{n: l.n / r.n, isNum: true}
+	}
+}
+
+type negExpr struct {
+	x expr
+}
+
+func (e negExpr) numeric() bool { return true }
+
+func (e negExpr) eval() exprValue {
+	return exprValue{n: -e.x.eval().n, isNum: true}
+}
+
+// exprParser compiles expressions of field names, numbers, 'text' and
+// "text" literals, + - * / and parentheses, and conditions comparing them
+// joined by AND, OR and NOT.
+type exprParser struct {
+	d   *Dbf
+	src string
+	pos int
+}
+
+// ExprError is a syntax or name error in an expression
+type ExprError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *ExprError) Error() string {
+	return "dbf expression " + strconv.Quote(e.Expr) + " at " + strconv.Itoa(e.Pos) + ": " + e.Msg
+}
+
+func (p *exprParser) fail(msg string) error {
+	return &ExprError{p.src, p.pos, msg}
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' || p.pos < len(p.src) && p.src[p.pos] == '\t' {
+		p.pos++
+	}
+}
+
+// peek is the next non-space byte, 0 at the end
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func compileExpr(d *Dbf, src string) (expr, error) {
+	p := &exprParser{d: d, src: src}
+	e, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.fail("unexpected " + strconv.Quote(p.src[p.pos:p.pos+1]))
+	}
+	return e, nil
+}
+
+func (p *exprParser) sum() (expr, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		if op == '-' && !(left.numeric() && right.numeric()) {
+			return nil, p.fail("- needs numbers")
+		}
+		left = binaryExpr{op, left, right}
+	}
+}
+
+func (p *exprParser) product() (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !(left.numeric() && right.numeric()) {
+			return nil, p.fail(string(op) + " needs numbers")
+		}
+		left = binaryExpr{op, left, right}
+	}
+}
+
+func (p *exprParser) unary() (expr, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !x.numeric() {
+			return nil, p.fail("- needs a number")
+		}
+		return negExpr{x}, nil
+	}
+	return p.operand()
+}
+
+func (p *exprParser) operand() (expr, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		e, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.fail("missing )")
+		}
+		p.pos++
+		return e, nil
+	case c == '\'' || c == '"':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end < 0 {
+			return nil, p.fail("unterminated text")
+		}
+		s := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return literalExpr{s: s}, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, p.fail("bad number")
+		}
+		return literalExpr{n: n, isNum: true}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if f := p.d.Field(name); f != nil {
+			return fieldExpr{f}, nil
+		}
+		for _, c := range p.d.computed {
+			if c.Name == name {
+				return computedExpr{c}, nil
+			}
+		}
+		p.pos = start
+		return nil, p.fail("no field " + name)
+	case c == 0:
+		return nil, p.fail("unexpected end")
+	}
+	return nil, p.fail("unexpected " + strconv.Quote(string(c)))
+}
+
+// cond is a compiled condition over the fields of a Dbf
+type cond interface {
+	test() bool
+}
+
+// compareCond compares numbers if both sides are numeric, else text
+type compareCond struct {
+	op          string
+	left, right expr
+}
+
+func (c compareCond) test() bool {
+	l, r := c.left.eval(), c.right.eval()
+	cmp := 0
+	if l.isNum && r.isNum {
+		if l.n < r.n {
+			cmp = -1
+		} else if l.n > r.n {
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(l.String(), r.String())
+	}
+	switch c.op {
+	case "==", "=":
+		return cmp == 0
+	case "!=", "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+type logicCond struct {
+	and         bool
+	left, right cond
+}
+
+func (c logicCond) test() bool {
+	if c.and {
+		return c.left.test() && c.right.test()
+	}
+	return c.left.test() || c.right.test()
+}
+
+type notCond struct {
+	x cond
+}
+
+func (c notCond) test() bool {
+	return !c.x.test()
+}
+
+// compareOps are longest first so "<=" is not read as "<"
+var compareOps = []string{"==", "!=", "<>", "<=", ">=", "=", "<", ">"}
+
+func compileCond(d *Dbf, src string) (cond, error) {
+	p := &exprParser{d: d, src: src}
+	c, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.fail("unexpected " + strconv.Quote(p.src[p.pos:p.pos+1]))
+	}
+	return c, nil
+}
+
+// keyword consumes word, in any case, or symbol if it is next
+func (p *exprParser) keyword(word, symbol string) bool {
+	p.skipSpace()
+	rest := p.src[p.pos:]
+	if strings.HasPrefix(rest, symbol) {
+		p.pos += len(symbol)
+		return true
+	}
+	if len(rest) < len(word) || !strings.EqualFold(rest[:len(word)], word) {
+		return false
+	}
+	if len(rest) > len(word) {
+		// not the start of a field name
+		c := rune(rest[len(word)])
+		if c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) {
+			return false
+		}
+	}
+	p.pos += len(word)
+	return true
+}
+
+func (p *exprParser) or() (cond, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR", "||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = logicCond{false, left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) and() (cond, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND", "&&") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = logicCond{true, left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) not() (cond, error) {
+	if p.keyword("NOT", "!") {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return notCond{x}, nil
+	}
+	if p.peek() == '(' {
+		// a parenthesized condition, or else a comparison starting with
+		// a parenthesized sum
+		start := p.pos
+		p.pos++
+		c, err := p.or()
+		if err == nil && p.peek() == ')' {
+			p.pos++
+			return c, nil
+		}
+		p.pos = start
+	}
+	return p.compare()
+}
+
+func (p *exprParser) compare() (cond, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range compareOps {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			right, err := p.sum()
+			if err != nil {
+				return nil, err
+			}
+			return compareCond{op, left, right}, nil
+		}
+	}
+	return nil, p.fail("expected a comparison")
+}
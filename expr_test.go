@@ -0,0 +1,131 @@
+package dbf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// exprTable is one record: NAME "Alpha", POP 120, RATE 2.50
+func exprTable(t *testing.T) *Dbf {
+	fields := []fuzzField{{"NAME", 'C', 8, 0}, {"POP", 'N', 6, 0}, {"RATE", 'N', 6, 2}}
+	table := fuzzTable(fields, []string{" Alpha      120  2.50"}, 0, 0, true)
+	d, err := NewDbf(bytes.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestExpr(t *testing.T) {
+	d := exprTable(t)
+	for _, c := range []struct {
+		src, want string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"(1 + 2) * 3", "9"},
+		{"10 - 4 - 3", "3"},
+		{"8 / 4 / 2", "1"},
+		{"2 * -3", "-6"},
+		{"-POP + 20", "-100"},
+		{"POP * RATE", "300"},
+		{"(POP + 30) / 3", "50"},
+		{"NAME + '!'", "Alpha!"},
+		{"'n=' + POP", "n=120"},
+		{`"it's"`, "it's"},
+		{`'say "hi"'`, `say "hi"`},
+		{"''", ""},
+		{"\tPOP\t", "120"},
+	} {
+		e, err := compileExpr(d, c.src)
+		if err != nil {
+			t.Errorf("%s: %v", c.src, err)
+			continue
+		}
+		if got := e.eval().String(); got != c.want {
+			t.Errorf("%s = %q, want %q", c.src, got, c.want)
+		}
+	}
+}
+
+func TestCond(t *testing.T) {
+	d := exprTable(t)
+	for _, c := range []struct {
+		src  string
+		want bool
+	}{
+		{"NAME == 'Alpha'", true},
+		{"NAME = \"Alpha\"", true},
+		{"NAME != 'Alpha'", false},
+		{"NAME <> 'Beta'", true},
+		{"NAME < 'B'", true},
+		{"POP > 100", true},
+		{"POP >= 120", true},
+		{"POP <= 119.5", false},
+		{"POP < 1000", true},
+		{"POP > 100 AND RATE < 3", true},
+		{"POP > 200 or NAME == 'Alpha'", true},
+		{"NOT POP > 100", false},
+		{"!(POP < 100) && NAME <> 'x'", true},
+		{"POP > 500 || RATE == 2.5", true},
+		// AND binds tighter than OR
+		{"NAME = 'Alpha' OR POP > 500 AND RATE > 3", true},
+		{"(NAME = 'Alpha' OR POP > 500) AND RATE > 3", false},
+		{"(POP + 30) / 3 == 50", true},
+		{"NOT NOT POP = 120", true},
+	} {
+		cond, err := compileCond(d, c.src)
+		if err != nil {
+			t.Errorf("%s: %v", c.src, err)
+			continue
+		}
+		if got := cond.test(); got != c.want {
+			t.Errorf("%s = %v, want %v", c.src, got, c.want)
+		}
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	d := exprTable(t)
+	for _, c := range []struct {
+		src, msg string
+		isCond   bool
+	}{
+		// type errors
+		{"NAME - 1", "- needs numbers", false},
+		{"NAME * 2", "* needs numbers", false},
+		{"'a' / 2", "/ needs numbers", false},
+		{"-NAME", "- needs a number", false},
+		// malformed input
+		{"", "unexpected end", false},
+		{"1 +", "unexpected end", false},
+		{"(1 + 2", "missing )", false},
+		{"'abc", "unterminated text", false},
+		{"POP POP", "unexpected \"P\"", false},
+		{"NOPE + 1", "no field NOPE", false},
+		{"1..2", "bad number", false},
+		{"#", "unexpected \"#\"", false},
+		{"POP", "expected a comparison", true},
+		{"POP >", "unexpected end", true},
+		{"POP > 1 AND", "unexpected end", true},
+		{"POP > 1 POP", "unexpected \"P\"", true},
+	} {
+		var err error
+		if c.isCond {
+			_, err = compileCond(d, c.src)
+		} else {
+			_, err = compileExpr(d, c.src)
+		}
+		ee, ok := err.(*ExprError)
+		if !ok {
+			t.Errorf("%s: %v, want an ExprError", c.src, err)
+			continue
+		}
+		if !strings.Contains(ee.Msg, c.msg) {
+			t.Errorf("%s: %q, want %q", c.src, ee.Msg, c.msg)
+		}
+	}
+}
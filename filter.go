@@ -0,0 +1,71 @@
+package dbf
+
+import "strings"
+
+// WithFilter makes Next, NextN and Records pass over records for which keep
+// is false, so selection logic lives in one place:
+//
+//	dbf.WithFilter(func(d *dbf.Dbf) bool {
+//		return d.Fields[county].StringValue() == "025"
+//	})
+//
+// keep sees each record as the current row before it is returned, and
+// only the fields it reads are decoded. RecordIndex still counts the
+// records passed over. RecordAt is not filtered.
+func WithFilter(keep func(d *Dbf) bool) Option {
+	return func(d *Dbf) {
+		d.filter = keep
+	}
+}
+
+// Filter changes the filter of WithFilter after opening, nil to see all
+// records again. It applies from the next record read.
+func (d *Dbf) Filter(keep func(d *Dbf) bool) {
+	d.filter = keep
+}
+
+// passOver is true if Next should not return the current record, it being
+// deleted with WithSkipDeleted or not kept by the filter
+func (d *Dbf) passOver() bool {
+	if d.skipDeleted && d.IsDeleted() {
+		return true
+	}
+	return d.filter != nil && !d.filter(d)
+}
+
+// readsAll is true when Next returns every remaining record, so that
+// EffectiveRecords counts what a copy will write
+func (d *Dbf) readsAll() bool {
+	return !d.skipDeleted && d.filter == nil && !d.limited
+}
+
+// WithWhere keeps only records for which condition holds, see Where. A bad
+// condition fails NewDbf.
+func WithWhere(condition string) Option {
+	return func(d *Dbf) {
+		d.whereDef = condition
+	}
+}
+
+// Where sets the filter to a condition such as
+//
+//	COUNTYFP10 == '025' AND (ALAND10 > 1000000 OR NAME = 'Ames')
+//
+// Conditions compare expressions as in AddComputed with == (or =), != (or
+// <>), <, <=, > and >=, numerically if both sides are numeric and as text
+// otherwise, and join them with AND, OR and NOT (or &&, || and !), in any
+// case. Computed columns added before can be compared too. "" removes the
+// filter. A bad condition fails with an *ExprError, leaving the filter as
+// it was.
+func (d *Dbf) Where(condition string) error {
+	if strings.TrimSpace(condition) == "" {
+		d.Filter(nil)
+		return nil
+	}
+	c, err := compileCond(d, condition)
+	if err != nil {
+		return err
+	}
+	d.Filter(func(*Dbf) bool { return c.test() })
+	return nil
+}
@@ -0,0 +1,46 @@
+package dbf
+
+import "errors"
+
+var ErrNotFound error = errors.New("dbf key not found in index")
+
+// Index finds records by key: an open .ndx (*ndx.Index) or a tag of an
+// .mdx (*mdx.Tag). Find calls fn with the 1 based record number of each
+// match in index order, stopping at the first error fn returns.
+type Index interface {
+	Find(key string, fn func(record uint32) error) error
+}
+
+// errFound stops an index search at the first match
+var errFound error = errors.New("dbf found")
+
+// Find makes the first record matching key in index the current record,
+// as RecordAt, or returns ErrNotFound. Next then continues in table order;
+// FindAll lists every match. The index must belong to this table.
+func (d *Dbf) Find(index Index, key string) error {
+	var found uint32
+	err := index.Find(key, func(record uint32) error {
+		found = record
+		return errFound
+	})
+	if err != nil && err != errFound {
+		return err
+	}
+	if found == 0 {
+		return ErrNotFound
+	}
+	return d.RecordAt(int64(found) - 1)
+}
+
+// FindAll is the 0 based indexes of the records matching key in index, in
+// index order, for RecordAt
+func FindAll(index Index, key string) ([]int64, error) {
+	var found []int64
+	err := index.Find(key, func(record uint32) error {
+		if record > 0 {
+			found = append(found, int64(record)-1)
+		}
+		return nil
+	})
+	return found, err
+}
@@ -0,0 +1,157 @@
+package dbf
+
+import (
+	"encoding/binary"
+)
+
+// A minimal flatbuffers encoder, enough for the Arrow IPC metadata of
+// WriteArrow. Objects are laid out front to back, each table's vtable just
+// before it and everything a table refers to after it, so offsets always
+// point forward as flatbuffers requires.
+
+// fbObject is a flatbuffers table, string or vector
+type fbObject interface {
+	// place appends the object to b and returns its position
+	place(b *fbBuilder) int
+}
+
+// fbField is one field of a table: a scalar of size 1, 2, 4 or 8 bytes,
+// or with ref set an offset to another object. The zero fbField is absent.
+type fbField struct {
+	size int
+	bits uint64
+	ref  fbObject
+}
+
+func fbScalar(size int, v uint64) fbField {
+	return fbField{size: size, bits: v}
+}
+
+func fbRef(o fbObject) fbField {
+	return fbField{size: 4, ref: o}
+}
+
+// fbTable is a table by field id
+type fbTable []fbField
+
+// fbString is a string
+type fbString string
+
+// fbVector is a vector of tables
+type fbVector []fbObject
+
+// fbStructs is a vector of structs of 8 byte aligned fields, already
+// encoded
+type fbStructs struct {
+	count int
+	data  []byte
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) align(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) grow(n int) int {
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, n)...)
+	return pos
+}
+
+// patch points the offset slot at pos to target
+func (b *fbBuilder) patch(slot, target int) {
+	binary.LittleEndian.PutUint32(b.buf[slot:], uint32(target-slot))
+}
+
+// fbFinish encodes root as a flatbuffer padded to 8 bytes
+func fbFinish(root fbObject) []byte {
+	b := &fbBuilder{}
+	b.grow(4)
+	b.patch(0, root.place(b))
+	b.align(8)
+	return b.buf
+}
+
+func (t fbTable) place(b *fbBuilder) int {
+	// fields are laid out in id order, each aligned to its size, after
+	// the offset to the vtable
+	offsets := make([]int, len(t))
+	size := 4
+	for i, f := range t {
+		if f.size == 0 {
+			continue
+		}
+		for size%f.size != 0 {
+			size++
+		}
+		offsets[i] = size
+		size += f.size
+	}
+	b.align(2)
+	vtable := b.grow(4 + 2*len(t))
+	binary.LittleEndian.PutUint16(b.buf[vtable:], uint16(4+2*len(t)))
+	binary.LittleEndian.PutUint16(b.buf[vtable+2:], uint16(size))
+	for i, off := range offsets {
+		binary.LittleEndian.PutUint16(b.buf[vtable+4+2*i:], uint16(off))
+	}
+	b.align(8)
+	table := b.grow(size)
+	binary.LittleEndian.PutUint32(b.buf[table:], uint32(table-vtable))
+	for i, f := range t {
+		if f.size == 0 || f.ref != nil {
+			continue
+		}
+		field := b.buf[table+offsets[i]:]
+		switch f.size {
+		case 1:
+			field[0] = byte(f.bits)
+		case 2:
+			binary.LittleEndian.PutUint16(field, uint16(f.bits))
+		case 4:
+			binary.LittleEndian.PutUint32(field, uint32(f.bits))
+		case 8:
+			binary.LittleEndian.PutUint64(field, f.bits)
+		}
+	}
+	for i, f := range t {
+		if f.ref != nil {
+			b.patch(table+offsets[i], f.ref.place(b))
+		}
+	}
+	return table
+}
+
+func (s fbString) place(b *fbBuilder) int {
+	b.align(4)
+	pos := b.grow(4)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+func (v fbVector) place(b *fbBuilder) int {
+	b.align(4)
+	pos := b.grow(4 + 4*len(v))
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(len(v)))
+	for i, o := range v {
+		b.patch(pos+4+4*i, o.place(b))
+	}
+	return pos
+}
+
+func (v fbStructs) place(b *fbBuilder) int {
+	// the elements after the length are 8 byte aligned
+	for len(b.buf)%8 != 4 {
+		b.buf = append(b.buf, 0)
+	}
+	pos := b.grow(4)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(v.count))
+	b.buf = append(b.buf, v.data...)
+	return pos
+}
@@ -0,0 +1,80 @@
+package dbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrCannotFollow error = errors.New("dbf WithFollow needs an input with ReadAt, such as *os.File")
+
+// followState is set by WithFollow
+type followState struct {
+	interval time.Duration
+	stop     <-chan struct{}
+	// dataEnd is the end of the last complete record known to be present
+	dataEnd int64
+}
+
+// WithFollow makes Next wait for records to be appended, like tail -f,
+// instead of stopping at the end of the data. Each interval the header
+// record count and the file size are checked again; records are read once
+// both show them complete, so the 0x1a terminator an appending program
+// moves along is never read. Next returns io.EOF once stop is closed; a
+// nil stop follows forever. The input must have ReadAt, as *os.File does.
+func WithFollow(interval time.Duration, stop <-chan struct{}) Option {
+	return func(d *Dbf) {
+		d.follow = &followState{interval: interval, stop: stop}
+	}
+}
+
+// startFollow checks the input can be followed, after the header is read
+func (d *Dbf) startFollow() error {
+	if _, ok := d.reader.(io.ReaderAt); !ok {
+		return ErrCannotFollow
+	}
+	return d.refreshFollow()
+}
+
+// refreshFollow rereads the header record count and the input size
+func (d *Dbf) refreshFollow() error {
+	var count [4]byte
+	_, err := d.reader.(io.ReaderAt).ReadAt(count[:], 4)
+	if err != nil {
+		return err
+	}
+	d.NumRecords = binary.LittleEndian.Uint32(count[:])
+	records := int64(d.NumRecords)
+	size, ok, err := inputSize(d.reader)
+	if err != nil {
+		return err
+	}
+	if ok {
+		d.SizeRecords = (size - d.dataStart) / int64(d.rowWidth+1)
+		if d.SizeRecords < records {
+			records = d.SizeRecords
+		}
+	}
+	d.follow.dataEnd = d.dataStart + records*int64(d.rowWidth+1)
+	return nil
+}
+
+// waitForRecord returns once a whole record is present after d.pos, or
+// io.EOF when following is stopped.
+func (d *Dbf) waitForRecord() error {
+	f := d.follow
+	for d.pos+int64(d.rowWidth+1) > f.dataEnd {
+		select {
+		case <-f.stop:
+			d.atEnd()
+			return io.EOF
+		case <-time.After(f.interval):
+		}
+		err := d.refreshFollow()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package dbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Visual FoxPro field types stored as binary values rather than text
+const (
+	// DbfFieldInteger is a 4 byte little endian signed integer
+	DbfFieldInteger DbfFieldType = DbfFieldType('I')
+	// DbfFieldCurrency is an 8 byte little endian integer of 1/10000 units
+	DbfFieldCurrency DbfFieldType = DbfFieldType('Y')
+	// DbfFieldDouble is an 8 byte little endian float64. In dBASE tables
+	// B is a binary memo instead.
+	DbfFieldDouble DbfFieldType = DbfFieldType('B')
+	// DbfFieldDateTime is a 4 byte little endian Julian day number and 4
+	// byte little endian milliseconds since midnight
+	DbfFieldDateTime DbfFieldType = DbfFieldType('T')
+)
+
+// DateTimeLayout is how StringValue shows a T field
+const DateTimeLayout = "2006-01-02T15:04:05"
+
+// julianUnixEpoch is the Julian day number of 1970-01-01
+const julianUnixEpoch = 2440588
+
+// isVisualFoxPro is true for Visual FoxPro version bytes
+func isVisualFoxPro(version byte) bool {
+	return version == 0x30 || version == 0x31 || version == 0x32
+}
+
+// isBinary is true for fields holding Visual FoxPro binary values
+func (h *DbfField) isBinary() bool {
+	return h.d != nil && isBinaryType(h.d.Version, h.Type)
+}
+
+// isBinaryType is true for the field types a table of version holds as
+// binary values
+func isBinaryType(version byte, typ DbfFieldType) bool {
+	switch typ {
+	case DbfFieldInteger, DbfFieldCurrency, DbfFieldDouble, DbfFieldDateTime:
+		return isVisualFoxPro(version)
+	}
+	return false
+}
+
+// binaryWidth is the size of a Visual FoxPro binary field
+func binaryWidth(typ DbfFieldType) int {
+	if typ == DbfFieldInteger {
+		return 4
+	}
+	return 8
+}
+
+// isMemo is true for fields that point into a memo file
+func (h *DbfField) isMemo() bool {
+	return isMemoType(h.Type) && !h.isBinary()
+}
+
+// binaryString is the text of a Visual FoxPro binary value: I as an
+// integer, Y with 4 decimals, B with the field's decimal count (all
+// significant digits if 0), T as DateTimeLayout. A short field or an
+// empty date time is "".
+func (h *DbfField) binaryString(raw []byte) string {
+	switch h.Type {
+	case DbfFieldInteger:
+		if len(raw) < 4 {
+			return ""
+		}
+		return strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(raw))), 10)
+	case DbfFieldCurrency:
+		if len(raw) < 8 {
+			return ""
+		}
+		v := int64(binary.LittleEndian.Uint64(raw))
+		sign := ""
+		u := uint64(v)
+		if v < 0 {
+			sign = "-"
+			u = uint64(-v)
+		}
+		frac := strconv.FormatUint(10000+u%10000, 10)[1:]
+		return sign + strconv.FormatUint(u/10000, 10) + "." + frac
+	case DbfFieldDouble:
+		if len(raw) < 8 {
+			return ""
+		}
+		prec := h.DecimalCount()
+		if prec == 0 {
+			prec = -1
+		}
+		return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(raw)), 'f', prec, 64)
+	case DbfFieldDateTime:
+		t, ok := dateTime(raw)
+		if !ok {
+			return ""
+		}
+		return t.Format(DateTimeLayout)
+	}
+	return ""
+}
+
+// dateTime decodes a T field, not ok for a short field or a zero day
+func dateTime(raw []byte) (time.Time, bool) {
+	if len(raw) < 8 {
+		return time.Time{}, false
+	}
+	day := int64(binary.LittleEndian.Uint32(raw[0:4]))
+	ms := int64(binary.LittleEndian.Uint32(raw[4:8]))
+	if day == 0 {
+		return time.Time{}, false
+	}
+	t := time.Unix((day-julianUnixEpoch)*86400, 0).UTC()
+	return t.Add(time.Duration(ms) * time.Millisecond), true
+}
+
+// dateTimeMillis is how a time.Time is written to a T field, to the
+// millisecond the field holds
+const dateTimeMillis = DateTimeLayout + ".000"
+
+// putBinary writes the text of a Visual FoxPro binary value into field
+// bytes fb, as binaryString reads it back: I as an integer, Y as a
+// decimal rounded to 4 places, B as a float, T as DateTimeLayout with
+// optional fractional seconds, or a date as DateLayout or "2006-01-02".
+// Blank is zero, for T an empty date time.
+func putBinary(fb []byte, typ DbfFieldType, v string) error {
+	for j := range fb {
+		fb[j] = 0
+	}
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil
+	}
+	switch typ {
+	case DbfFieldInteger:
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(fb, uint32(int32(n)))
+	case DbfFieldCurrency:
+		n, err := parseCurrency(v)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(fb, uint64(n))
+	case DbfFieldDouble:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(fb, math.Float64bits(f))
+	case DbfFieldDateTime:
+		t, err := parseDateTime(v)
+		if err != nil {
+			return err
+		}
+		sec := t.Unix()
+		day := sec / 86400
+		if sec%86400 < 0 {
+			day--
+		}
+		ms := (sec-day*86400)*1000 + int64(t.Nanosecond()/int(time.Millisecond))
+		if day+julianUnixEpoch <= 0 {
+			return errors.New("date time before the Julian calendar epoch")
+		}
+		binary.LittleEndian.PutUint32(fb[0:4], uint32(day+julianUnixEpoch))
+		binary.LittleEndian.PutUint32(fb[4:8], uint32(ms))
+	}
+	return nil
+}
+
+// parseCurrency is a decimal as 1/10000 units. Up to 4 decimals are
+// taken exactly, more are rounded.
+func parseCurrency(v string) (int64, error) {
+	whole, frac := v, ""
+	if dot := strings.IndexByte(v, '.'); dot >= 0 {
+		whole, frac = v[:dot], v[dot+1:]
+	}
+	if len(frac) <= 4 && strings.Trim(frac, "0123456789") == "" {
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err == nil && n <= math.MaxInt64/10000 && n >= math.MinInt64/10000 {
+			f, _ := strconv.ParseInt((frac + "0000")[:4], 10, 64)
+			if strings.HasPrefix(whole, "-") {
+				f = -f
+			}
+			return n*10000 + f, nil
+		}
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	f = math.Round(f * 10000)
+	if f >= math.MaxInt64 || f < math.MinInt64 || math.IsNaN(f) {
+		return 0, errors.New("currency value out of range: " + strconv.Quote(v))
+	}
+	return int64(f), nil
+}
+
+// parseDateTime reads the text putBinary takes for a T field, as UTC
+func parseDateTime(v string) (time.Time, error) {
+	t, err := time.Parse(DateTimeLayout, v)
+	if err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{DateLayout, "2006-01-02"} {
+		d, derr := time.Parse(layout, v)
+		if derr == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,184 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// fuzzField is a field descriptor for building seed tables
+type fuzzField struct {
+	name  string
+	typ   byte
+	width byte
+	count byte
+}
+
+// fuzzTable builds a dBASE III table of fields and rows. headerBytes and
+// recordBytes override the header values when not 0; terminate leaves out
+// the 0x0d after the descriptors when false.
+func fuzzTable(fields []fuzzField, rows []string, headerBytes, recordBytes uint16, terminate bool) []byte {
+	var buf bytes.Buffer
+	var header [32]byte
+	header[0] = 0x03
+	header[1], header[2], header[3] = 121, 1, 31
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(rows)))
+	width := 1
+	for _, f := range fields {
+		width += int(f.width)
+	}
+	if headerBytes == 0 {
+		headerBytes = uint16(32 + 32*len(fields) + 1)
+	}
+	if recordBytes == 0 {
+		recordBytes = uint16(width)
+	}
+	binary.LittleEndian.PutUint16(header[8:10], headerBytes)
+	binary.LittleEndian.PutUint16(header[10:12], recordBytes)
+	buf.Write(header[:])
+	for _, f := range fields {
+		var fd [32]byte
+		copy(fd[:11], f.name)
+		fd[11] = f.typ
+		fd[16] = f.width
+		fd[17] = f.count
+		buf.Write(fd[:])
+	}
+	if terminate {
+		buf.WriteByte(0x0d)
+	}
+	for _, row := range rows {
+		buf.WriteString(row)
+	}
+	buf.WriteByte(0x1a)
+	return buf.Bytes()
+}
+
+// fuzzSeeds are well formed and malformed tables
+func fuzzSeeds() [][]byte {
+	fields := []fuzzField{
+		{"NAME", 'C', 10, 0},
+		{"COUNT", 'N', 5, 0},
+		{"RATE", 'N', 8, 2},
+		{"WHEN", 'D', 8, 0},
+		{"OK", 'L', 1, 0},
+		{"SCORE", 'F', 10, 3},
+	}
+	rows := []string{
+		" Alpha         1    1.50202001311    12.125",
+		"*Beta          2   -3.2520191231F     0.000",
+		" Gamma      xyz         ????????? 1e999    ",
+	}
+	good := fuzzTable(fields, rows, 0, 0, true)
+	seeds := [][]byte{
+		good,
+		// truncated headers
+		good[:1],
+		good[:16],
+		good[:32],
+		good[:32+32+7],
+		good[:32+32*len(fields)],
+		// NumHeaderBytes inside the field descriptors
+		fuzzTable(fields, rows, 40, 0, true),
+		// NumHeaderBytes past the records
+		fuzzTable(fields, rows, 4000, 0, true),
+		// no 0x0d after the descriptors
+		fuzzTable(fields, rows, 0, 0, false),
+		// zero width fields
+		fuzzTable([]fuzzField{{"EMPTY", 'C', 0, 0}, {"N", 'N', 0, 0}, {"D", 'D', 0, 0}}, []string{" ", "*"}, 0, 0, true),
+		// NumRecordBytes shorter and longer than the fields
+		fuzzTable(fields, rows, 0, 12, true),
+		fuzzTable(fields, rows, 0, 200, true),
+		fuzzTable(fields, rows, 0, 1, true),
+		// character field wider than 255 by its Count byte
+		fuzzTable([]fuzzField{{"LONG", 'C', 4, 1}}, []string{" x"}, 0, 0, true),
+		// Visual FoxPro binary and memo types in a dBASE III table
+		fuzzTable([]fuzzField{{"I", 'I', 4, 0}, {"Y", 'Y', 8, 0}, {"B", 'B', 8, 0}, {"T", 'T', 8, 0}, {"M", 'M', 10, 0}}, []string{" 12345678901234567890123456789"}, 0, 0, true),
+	}
+	dbase4 := append([]byte(nil), good...)
+	dbase4[0] = 0x8b
+	seeds = append(seeds, dbase4)
+	fox := append([]byte(nil), good...)
+	fox[0] = 0x30
+	seeds = append(seeds, fox)
+	level7 := append([]byte(nil), good...)
+	level7[0] = 0x04
+	seeds = append(seeds, level7)
+	dbase2 := make([]byte, dBaseIIHeaderLength+20)
+	dbase2[0] = dBaseII
+	dbase2[1] = 2
+	dbase2[6] = 9
+	copy(dbase2[8:], "NAME\x00\x00\x00\x00\x00\x00\x00C\x08")
+	dbase2[8+16] = 0x0d
+	seeds = append(seeds, dbase2)
+	return seeds
+}
+
+// readEverything runs every field accessor over every record of d
+func readEverything(d *Dbf) {
+	for i := 0; ; i++ {
+		err := d.Next()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			if _, ok := err.(*ParseError); !ok {
+				return
+			}
+		}
+		d.RawRecord()
+		d.IsDeleted()
+		for j := range d.Fields {
+			f := &d.Fields[j]
+			f.StringValue()
+			f.BytesValue()
+			f.Int64()
+			f.Float64()
+			f.Bool()
+			f.DateValue()
+			f.Value()
+			f.IsNull()
+			f.MemoValue()
+		}
+		if err != nil && i > 1000 {
+			return
+		}
+	}
+}
+
+func FuzzNewDbf(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		variants := [][]Option{
+			nil,
+			{WithRecordWidthPolicy(TrustHeaderWidth)},
+			{WithResync()},
+		}
+		for _, opts := range variants {
+			opts = append(opts, WithLogger(nil))
+			d, err := NewDbf(bytes.NewReader(data), opts...)
+			if err != nil {
+				continue
+			}
+			d.Schema()
+			readEverything(d)
+		}
+		d, err := NewDbf(bytes.NewReader(data), WithLogger(nil))
+		if err != nil {
+			return
+		}
+		for {
+			records, err := d.NextN(2)
+			for _, r := range records {
+				for j := range d.Fields {
+					r.Get(d.Fields[j].Name)
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog estimates the number of distinct values added to it in
+// fixed memory, 2^precision bytes. The standard error is about
+// 1.04/sqrt(2^precision), 0.8% at the DefaultPrecision.
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// DefaultPrecision uses 16 KiB per estimator
+const DefaultPrecision = 14
+
+// NewHyperLogLog makes an estimator with precision 4..18
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 {
+		precision = 4
+	} else if precision > 18 {
+		precision = 18
+	}
+	return &HyperLogLog{precision: precision, registers: make([]uint8, 1<<precision)}
+}
+
+// hash64 is FNV-1a with a murmur3 finalizer to spread the bits
+func hash64(data []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, c := range data {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Add counts one value
+func (h *HyperLogLog) Add(value []byte) {
+	x := hash64(value)
+	index := x >> (64 - h.precision)
+	rest := x<<h.precision | 1<<(h.precision-1)
+	rank := uint8(bits.LeadingZeros64(rest)) + 1
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// AddString counts one value
+func (h *HyperLogLog) AddString(value string) {
+	h.Add([]byte(value))
+}
+
+// Estimate is the approximate number of distinct values added
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros != 0 {
+		// small range, linear counting is better
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// fieldsByName finds the named fields, all fields if names is empty
+func fieldsByName(d *Dbf, names []string) ([]*DbfField, error) {
+	if len(names) == 0 {
+		out := make([]*DbfField, len(d.Fields))
+		for i := range d.Fields {
+			out[i] = &d.Fields[i]
+		}
+		return out, nil
+	}
+	out := make([]*DbfField, len(names))
+	for i, name := range names {
+		out[i] = d.Field(name)
+		if out[i] == nil {
+			return nil, errors.New("dbf has no field " + name)
+		}
+	}
+	return out, nil
+}
+
+// DistinctEstimate scans the remaining live (not deleted) records of d
+// and estimates the number of distinct values of each named field, or
+// every field if none are named, using a HyperLogLog per field. Memory is
+// fixed however large the file. A count near the record count suggests a
+// key column.
+func DistinctEstimate(d *Dbf, fields ...string) (map[string]uint64, error) {
+	selected, err := fieldsByName(d, fields)
+	if err != nil {
+		return nil, err
+	}
+	hlls := make([]*HyperLogLog, len(selected))
+	for i := range hlls {
+		hlls[i] = NewHyperLogLog(DefaultPrecision)
+	}
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if d.IsDeleted() {
+			continue
+		}
+		for i, f := range selected {
+			hlls[i].AddString(f.StringValue())
+		}
+	}
+	out := make(map[string]uint64, len(selected))
+	for i, f := range selected {
+		out[f.Name] = hlls[i].Estimate()
+	}
+	return out, nil
+}
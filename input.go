@@ -0,0 +1,136 @@
+package dbf
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultReadBufferSize is the read buffer NewDbf puts in front of an input
+// that is not already buffered, see WithReadBuffer
+const DefaultReadBufferSize = 64 << 10
+
+// WithReadBuffer sets the size of the buffer records are read through, 0
+// to read straight from the input. An input that is not an io.ByteReader,
+// as *os.File and network connections are not, is buffered so that the
+// header and per-record reads are not a system call each; *bufio.Reader,
+// *bytes.Reader and the like are already in memory and read directly. Seeking, Mark, RecordAt and UpdateRecord still work through
+// the buffer. WithFollow reads directly, and WithBuffers turns the buffer
+// off unless WithReadBuffer comes after it.
+func WithReadBuffer(size int) Option {
+	return func(d *Dbf) {
+		d.readBufferSize = size
+	}
+}
+
+// startBuffering puts the read buffer in front of reader, if any
+func (d *Dbf) startBuffering() {
+	d.buffered = nil
+	if d.reader == nil || d.readBufferSize <= 0 || d.follow != nil {
+		return
+	}
+	if _, ok := d.reader.(io.ByteReader); ok {
+		return
+	}
+	d.buffered = bufio.NewReaderSize(d.reader, d.readBufferSize)
+}
+
+// input is where bytes are read from, the buffer if there is one
+func (d *Dbf) input() io.Reader {
+	if d.buffered != nil {
+		return d.buffered
+	}
+	return d.reader
+}
+
+// read is reader.Read after any pushed back bytes, tracking pos
+func (d *Dbf) read(buf []byte) (int, error) {
+	if len(d.unread) > 0 {
+		n := copy(buf, d.unread)
+		d.unread = d.unread[n:]
+		d.pos += int64(n)
+		return n, nil
+	}
+	n, err := d.input().Read(buf)
+	d.pos += int64(n)
+	return n, err
+}
+
+// readFull is io.ReadFull from read
+func (d *Dbf) readFull(buf []byte) (int, error) {
+	n := 0
+	if len(d.unread) > 0 {
+		n = copy(buf, d.unread)
+		d.unread = d.unread[n:]
+		d.pos += int64(n)
+		if n == len(buf) {
+			return n, nil
+		}
+	}
+	m, err := io.ReadFull(d.input(), buf[n:])
+	d.pos += int64(m)
+	if err == io.EOF && n != 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n + m, err
+}
+
+// unreadBytes pushes data back to be read again, it must be the bytes just read
+func (d *Dbf) unreadBytes(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	if len(d.unread) > 0 {
+		d.unread = append(append([]byte(nil), data...), d.unread...)
+	} else {
+		d.unread = data
+	}
+	d.pos -= int64(len(data))
+}
+
+// skip reads and discards n bytes
+func (d *Dbf) skip(n int64) error {
+	if len(d.unread) > 0 {
+		if int64(len(d.unread)) >= n {
+			d.unread = d.unread[n:]
+			d.pos += n
+			return nil
+		}
+		n -= int64(len(d.unread))
+		d.pos += int64(len(d.unread))
+		d.unread = nil
+	}
+	m, err := io.CopyN(ioutil.Discard, d.input(), n)
+	d.pos += m
+	return err
+}
+
+// seekTo moves an io.Seeker reader to an absolute file position
+func (d *Dbf) seekTo(pos int64) error {
+	seeker, ok := d.reader.(io.Seeker)
+	if !ok {
+		return ErrNotSeekable
+	}
+	_, err := seeker.Seek(pos, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	if d.buffered != nil {
+		d.buffered.Reset(d.reader)
+	}
+	d.unread = nil
+	d.pos = pos
+	return nil
+}
+
+// peek reads up to n bytes ahead and pushes them back, at end of input it returns what there is
+func (d *Dbf) peek(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	got, err := d.readFull(buf)
+	buf = buf[:got]
+	d.unreadBytes(buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf, err
+}
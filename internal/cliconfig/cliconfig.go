@@ -0,0 +1,216 @@
+// Package cliconfig is the flags and environment shared by the cmd/ tools,
+// so they treat encodings, deleted records, field selection, output
+// format and damaged input the same way, and write tables to files or
+// pipes the same way, see CreateDbf.
+//
+// Each flag defaults from an environment variable:
+//
+//	-encoding         DBF_ENCODING          text encoding of character fields, "auto" to guess, "language" from the header
+//	-include-deleted  DBF_INCLUDE_DELETED   also output records marked deleted
+//	-fields           DBF_FIELDS            comma separated fields to output, all if empty
+//	-format           DBF_FORMAT            output format
+//	-lenient          DBF_LENIENT           skip over corrupt spans instead of failing
+//	-strict           DBF_STRICT            fail on malformed headers and impossible dates instead of warning
+//	-where            DBF_WHERE             only records matching a condition, e.g. "COUNTYFP10 == '025' AND ALAND10 > 1000"
+package cliconfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	dbf "github.com/brianolson/go-dbf"
+)
+
+// Flag selects which of the shared flags a tool registers
+type Flag int
+
+const (
+	Encoding Flag = 1 << iota
+	Deleted
+	Fields
+	Format
+	Strictness
+	Where
+
+	All = Encoding | Deleted | Fields | Format | Strictness | Where
+)
+
+// Config is the shared settings after flag parsing
+type Config struct {
+	Encoding       string
+	IncludeDeleted bool
+	Fields         []string
+	Format         string
+	Lenient        bool
+	Strict         bool
+	Where          string
+
+	fields string
+}
+
+func envBool(name string) bool {
+	v, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && v
+}
+
+func envString(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// Register adds the selected flags to fs, defaulting from the environment.
+// defaultFormat is the -format default when DBF_FORMAT is not set; formats
+// lists the values the tool accepts, for the usage text. Call Parsed after
+// fs.Parse.
+func Register(fs *flag.FlagSet, which Flag, defaultFormat string, formats ...string) *Config {
+	c := &Config{}
+	if which&Encoding != 0 {
+		fs.StringVar(&c.Encoding, "encoding", envString("DBF_ENCODING", ""), "text encoding of character fields, e.g. windows-1252, auto to guess, language for the header language byte (env DBF_ENCODING)")
+	}
+	if which&Deleted != 0 {
+		fs.BoolVar(&c.IncludeDeleted, "include-deleted", envBool("DBF_INCLUDE_DELETED"), "also output deleted records, with an extra "+dbf.DeletedColumn+" column (env DBF_INCLUDE_DELETED)")
+	}
+	if which&Fields != 0 {
+		fs.StringVar(&c.fields, "fields", envString("DBF_FIELDS", ""), "comma separated fields to output, default all (env DBF_FIELDS)")
+	}
+	if which&Format != 0 {
+		fs.StringVar(&c.Format, "format", envString("DBF_FORMAT", defaultFormat), "output format: "+strings.Join(formats, ", ")+" (env DBF_FORMAT)")
+	}
+	if which&Strictness != 0 {
+		fs.BoolVar(&c.Lenient, "lenient", envBool("DBF_LENIENT"), "skip over corrupt spans of records instead of failing (env DBF_LENIENT)")
+		fs.BoolVar(&c.Strict, "strict", envBool("DBF_STRICT"), "fail on malformed headers, unknown field types and impossible dates instead of warning (env DBF_STRICT)")
+	}
+	if which&Where != 0 {
+		fs.StringVar(&c.Where, "where", envString("DBF_WHERE", ""), "only output records matching a condition, e.g. \"COUNTYFP10 == '025' AND ALAND10 > 1000\" (env DBF_WHERE)")
+	}
+	return c
+}
+
+// Parsed finishes the config after flags are parsed, checking Encoding is
+// known, Lenient and Strict are not both set, and Format is one of
+// formats if any are given.
+func (c *Config) Parsed(formats ...string) error {
+	if c.Lenient && c.Strict {
+		return errors.New("use one of -lenient and -strict")
+	}
+	switch c.Encoding {
+	case "", "auto", "language", dbf.EncodingASCII, dbf.EncodingUTF8:
+	default:
+		if dbf.NewDecoder(c.Encoding) == nil {
+			return errors.New("unknown encoding " + strconv.Quote(c.Encoding))
+		}
+	}
+	c.Fields = nil
+	for _, name := range strings.Split(c.fields, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			c.Fields = append(c.Fields, name)
+		}
+	}
+	if len(formats) == 0 || c.Format == "" {
+		return nil
+	}
+	for _, f := range formats {
+		if c.Format == f {
+			return nil
+		}
+	}
+	return errors.New("unknown format " + strconv.Quote(c.Format) + ", want one of " + strings.Join(formats, ", "))
+}
+
+// DbfOptions are the NewDbf options for the config
+func (c *Config) DbfOptions() []dbf.Option {
+	var opts []dbf.Option
+	if c.Lenient {
+		opts = append(opts, dbf.WithResync())
+	}
+	if c.Strict {
+		opts = append(opts, dbf.WithStrict())
+	}
+	if c.Where != "" {
+		opts = append(opts, dbf.WithWhere(c.Where))
+	}
+	if c.Encoding == "language" {
+		opts = append(opts, dbf.WithLanguageDecoding())
+	} else if dec := dbf.NewDecoder(c.Encoding); dec != nil {
+		opts = append(opts, dbf.WithDecoder(dec))
+	}
+	return opts
+}
+
+// ExportOptions are the export options for the config
+func (c *Config) ExportOptions() *dbf.ExportOptions {
+	return &dbf.ExportOptions{IncludeDeleted: c.IncludeDeleted, Fields: c.Fields}
+}
+
+// ResolveEncoding is the encoding to use for d: the -encoding flag, for
+// "language" the one the header names, or for "auto" the guess from the
+// data, which is then set as the decoder of d. It is "" if not set.
+func (c *Config) ResolveEncoding(d *dbf.Dbf) (string, error) {
+	switch c.Encoding {
+	case "language":
+		return dbf.LanguageEncoding(d.Language), nil
+	case "auto":
+		encoding, err := d.GuessEncoding()
+		if err == nil {
+			d.SetDecoder(dbf.NewDecoder(encoding))
+		}
+		return encoding, err
+	}
+	return c.Encoding, nil
+}
+
+// ParseSchema reads fields from a spec like "NAME:C:20,POP:N:9:0", each
+// name:type:length[:decimals], separated by commas or newlines. Blank
+// lines and lines starting with # are skipped, so a schema file can list
+// one field per line.
+func ParseSchema(spec string) ([]dbf.DbfField, error) {
+	var fields []dbf.DbfField
+	for _, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, part := range strings.Split(line, ",") {
+			field, err := parseSchemaField(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("empty schema")
+	}
+	return fields, nil
+}
+
+func parseSchemaField(part string) (dbf.DbfField, error) {
+	parts := strings.Split(part, ":")
+	if len(parts) < 3 || len(parts) > 4 || len(parts[1]) != 1 {
+		return dbf.DbfField{}, fmt.Errorf("bad schema field %#v, want name:type:length[:decimals]", part)
+	}
+	length, err := strconv.ParseUint(parts[2], 10, 16)
+	if err != nil {
+		return dbf.DbfField{}, fmt.Errorf("bad schema field %#v length: %v", part, err)
+	}
+	field := dbf.DbfField{
+		Name:  parts[0],
+		Type:  dbf.DbfFieldType(parts[1][0]),
+		Width: int(length),
+	}
+	if len(parts) == 4 {
+		decimals, err := strconv.ParseUint(parts[3], 10, 8)
+		if err != nil {
+			return dbf.DbfField{}, fmt.Errorf("bad schema field %#v decimals: %v", part, err)
+		}
+		field.Count = uint8(decimals)
+	}
+	return field, nil
+}
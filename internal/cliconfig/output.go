@@ -0,0 +1,62 @@
+package cliconfig
+
+import (
+	"bufio"
+	"os"
+
+	dbf "github.com/brianolson/go-dbf"
+)
+
+// DbfOutput is a table being written to a file through a buffer. On a
+// regular file the record count is patched into the header at Close; on
+// a pipe the header keeps NumRecords, 0 if it is not set, and readers
+// count the records from the size of the file.
+type DbfOutput struct {
+	*dbf.Writer
+	bw *bufio.Writer
+}
+
+// CreateDbf starts a table of fields on out
+func CreateDbf(out *os.File, fields []dbf.DbfField, opts ...dbf.WriterOption) (*DbfOutput, error) {
+	o := &DbfOutput{bw: bufio.NewWriter(out)}
+	var err error
+	if isRegular(out) {
+		o.Writer, err = dbf.NewWriter(bufferedFile{o.bw, out}, fields, opts...)
+	} else {
+		o.Writer, err = dbf.NewWriter(o.bw, fields, append(opts, dbf.WithUnknownCount())...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Close finishes the table and flushes it to the file, which stays open
+func (o *DbfOutput) Close() error {
+	err := o.Writer.Close()
+	if err != nil {
+		return err
+	}
+	return o.bw.Flush()
+}
+
+// bufferedFile buffers writes to a file and flushes them before a Seek, so
+// that dbf.Writer can patch the header record count at Close
+type bufferedFile struct {
+	*bufio.Writer
+	f *os.File
+}
+
+func (b bufferedFile) Seek(offset int64, whence int) (int64, error) {
+	err := b.Flush()
+	if err != nil {
+		return 0, err
+	}
+	return b.f.Seek(offset, whence)
+}
+
+// isRegular is true if f is a regular file, which can seek
+func isRegular(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode().IsRegular()
+}
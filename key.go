@@ -0,0 +1,52 @@
+package dbf
+
+import (
+	"errors"
+	"strings"
+)
+
+// KeyFunc returns a function giving the composite key of fields for the
+// current row, their values concatenated with no separator, like a census
+// GEOID from STATEFP, COUNTYFP, TRACTCE and BLOCKCE. Integer fields (N
+// without decimals, and I) are zero padded to their width so codes stored
+// as numbers keep their leading zeros; other values are StringValue. A
+// blank value adds nothing, so a key shorter than expected shows a
+// missing part.
+func KeyFunc(fields ...*DbfField) func() string {
+	var b strings.Builder
+	return func() string {
+		b.Reset()
+		for _, f := range fields {
+			v := f.StringValue()
+			if v != "" && f.isIntegerCode() && strings.Trim(v, "0123456789") == "" {
+				width := f.Width
+				if f.Type == DbfFieldInteger {
+					// 4 bytes hold 10 digits
+					width = 10
+				}
+				for i := len(v); i < width; i++ {
+					b.WriteByte('0')
+				}
+			}
+			b.WriteString(v)
+		}
+		return b.String()
+	}
+}
+
+// isIntegerCode is true for fields that may hold numeric codes
+func (h *DbfField) isIntegerCode() bool {
+	return (h.Type == DbfFieldNumeric && h.DecimalCount() == 0) || (h.Type == DbfFieldInteger && h.isBinary())
+}
+
+// CompositeKey is KeyFunc of the named fields
+func (d *Dbf) CompositeKey(names ...string) (func() string, error) {
+	if len(names) == 0 {
+		return nil, errors.New("dbf CompositeKey needs at least one field")
+	}
+	fields, err := fieldsByName(d, names)
+	if err != nil {
+		return nil, err
+	}
+	return KeyFunc(fields...), nil
+}
@@ -0,0 +1,9 @@
+//go:build !tinygo
+// +build !tinygo
+
+package dbf
+
+import "log"
+
+// defaultLogf is where warnings go unless WithLogger is given
+var defaultLogf = log.Printf
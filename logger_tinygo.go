@@ -0,0 +1,7 @@
+//go:build tinygo
+// +build tinygo
+
+package dbf
+
+// tinygo builds do not pull in the log package, warnings are dropped unless WithLogger is given
+var defaultLogf func(format string, v ...interface{})
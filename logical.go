@@ -0,0 +1,40 @@
+package dbf
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrUnsetLogical is the value of a logical field holding the '?'
+// uninitialized marker, or blank
+var ErrUnsetLogical error = errors.New("dbf logical value is not set")
+
+// LogicalFormatError is a value that is not a logical
+type LogicalFormatError struct {
+	Field string
+	Value string
+}
+
+func (e *LogicalFormatError) Error() string {
+	return "dbf field " + e.Field + " value is not a logical: " + strconv.Quote(e.Value)
+}
+
+// Bool is the field for the current row as a logical: T, t, Y or y are
+// true and F, f, N or n false. The '?' uninitialized marker and blank
+// return ErrUnsetLogical. Anything else fails with a *ParseError wrapping
+// a *LogicalFormatError.
+func (h *DbfField) Bool() (bool, error) {
+	if h.raw() == nil {
+		return false, h.parseError(ErrFieldBounds)
+	}
+	v := h.StringValue()
+	switch v {
+	case "T", "t", "Y", "y":
+		return true, nil
+	case "F", "f", "N", "n":
+		return false, nil
+	case "?", "":
+		return false, ErrUnsetLogical
+	}
+	return false, h.parseError(&LogicalFormatError{h.Name, v})
+}
@@ -0,0 +1,129 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrNotSeekable error = errors.New("dbf reader is not an io.Seeker")
+var ErrNoMark error = errors.New("dbf ResetToMark without Mark")
+var ErrClosed error = errors.New("dbf reader already closed at end of data, see WithRewind")
+
+type dbfMark struct {
+	pos   int64
+	recno int64
+}
+
+// Mark remembers the position after the current record so ResetToMark can
+// return to it, e.g. to retry a batch of records after a failed downstream
+// write. The reader must be an io.Seeker. While a mark is set the reader is
+// not closed at end of data; call Close when done.
+func (d *Dbf) Mark() error {
+	if _, ok := d.reader.(io.Seeker); !ok {
+		return ErrNotSeekable
+	}
+	d.mark = dbfMark{pos: d.pos, recno: d.recno}
+	d.marked = true
+	return nil
+}
+
+// ResetToMark seeks back to the last Mark. The next call to Next reads the
+// record after the one that was current at Mark. Field values are not
+// restored until then.
+func (d *Dbf) ResetToMark() error {
+	if !d.marked {
+		return ErrNoMark
+	}
+	err := d.seekTo(d.mark.pos)
+	if err != nil {
+		return err
+	}
+	d.recno = d.mark.recno
+	d.eof = false
+	return nil
+}
+
+// RecordAt reads record i (0 based) directly, by its position from the
+// header and record sizes, and leaves it current; Next then continues with
+// the record after it. The reader must be an io.Seeker. Past the last
+// record it returns io.EOF. Once RecordAt has been used the reader is not
+// closed at end of data; call Close when done. Spans skipped by
+// WithResync are not accounted for.
+func (d *Dbf) RecordAt(i int64) error {
+	if i < 0 {
+		return errors.New("dbf RecordAt negative record index")
+	}
+	if _, ok := d.reader.(io.Seeker); !ok {
+		return ErrNotSeekable
+	}
+	d.keepOpen = true
+	if d.SizeRecords >= 0 && i >= d.SizeRecords {
+		return io.EOF
+	}
+	err := d.seekTo(d.dataStart + i*int64(d.rowWidth+1))
+	if err != nil {
+		return err
+	}
+	d.recno = i - 1
+	d.eof = false
+	err = d.next()
+	if err == nil {
+		return d.checkRecord(d.recordBuffer, d.recno, d.recordPos)
+	}
+	return d.wrapNextError(err)
+}
+
+// WithRewind keeps a seekable reader open at the end of the data so Reset
+// can go back to the first record. Call Close when done.
+func WithRewind() Option {
+	return func(d *Dbf) {
+		d.keepOpen = true
+	}
+}
+
+// WithReopen gives Reset a way to scan input that cannot seek again: open
+// returns the whole file from the start, e.g. by opening it again. The
+// header is skipped, not read again.
+func WithReopen(open func() (io.Reader, error)) Option {
+	return func(d *Dbf) {
+		d.reopen = open
+	}
+}
+
+// Reset goes back before the first record so the table can be scanned
+// again. A seekable reader seeks, which needs it still open: use
+// WithRewind, or Mark or RecordAt, for it to stay open at the end of the
+// data. Otherwise the WithReopen function is used, the old reader closed
+// if it is an io.Closer.
+func (d *Dbf) Reset() error {
+	if _, ok := d.reader.(io.Seeker); ok {
+		err := d.seekTo(d.dataStart)
+		if err != nil {
+			return err
+		}
+	} else if d.reopen != nil {
+		err := d.Close()
+		if err != nil {
+			return err
+		}
+		r, err := d.reopen()
+		if err != nil {
+			return err
+		}
+		d.reader = r
+		d.startBuffering()
+		d.pos = 0
+		d.unread = nil
+		err = d.skip(d.dataStart)
+		if err != nil {
+			return err
+		}
+	} else if d.reader == nil {
+		return ErrClosed
+	} else {
+		return ErrNotSeekable
+	}
+	d.recno = -1
+	d.eof = false
+	return nil
+}
@@ -0,0 +1,338 @@
+// Package mdx reads dBASE IV .mdx multiple index files: the tag directory
+// and the B-tree of each tag, to list keys in index order. A table whose
+// Mdx header byte is set has a production index of the same base name.
+// https://www.clicketyclick.dk/databases/xbase/format/mdx.html
+package mdx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PageSize is the unit page numbers in an .mdx count in
+const PageSize = 512
+
+// tagTableOffset is where the tag directory starts
+const tagTableOffset = 544
+
+// maxTags is the most tags an .mdx holds
+const maxTags = 48
+
+// key format bits of a tag header
+const (
+	formatDescending = 0x08
+	formatUnique     = 0x40
+)
+
+var ErrBadHeader error = errors.New("mdx: bad file header")
+var ErrBadPage error = errors.New("mdx: bad index page")
+
+// ErrStop returned by a Walk callback ends the walk early without error
+var ErrStop error = errors.New("mdx: stop walk")
+
+// File is an open .mdx
+type File struct {
+	// Table is the name of the data file the index belongs to
+	Table string
+	// BlockSize is the size in bytes of a B-tree node
+	BlockSize int
+	// Production is set for the index opened with its table
+	Production bool
+	Tags       []*Tag
+
+	r io.ReaderAt
+}
+
+// Tag is one index of an .mdx
+type Tag struct {
+	Name string
+	// KeyType is 'C' for character keys, 'N' or 'D' for numeric and date
+	// keys stored as 12 byte decimal numbers, see Number
+	KeyType    byte
+	KeyLength  int
+	Expression string
+	Descending bool
+	Unique     bool
+
+	f          *File
+	root       uint32
+	itemLength int
+}
+
+// Open reads the header and tag directory of an .mdx
+func Open(r io.ReaderAt) (*File, error) {
+	header := make([]byte, tagTableOffset)
+	_, err := r.ReadAt(header, 0)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrBadHeader
+		}
+		return nil, err
+	}
+	f := &File{
+		Table:      strings.TrimRight(string(header[4:20]), "\x00 "),
+		BlockSize:  int(binary.LittleEndian.Uint16(header[22:24])),
+		Production: header[24] != 0,
+		r:          r,
+	}
+	if f.BlockSize == 0 {
+		f.BlockSize = int(binary.LittleEndian.Uint16(header[20:22])) * PageSize
+	}
+	entryLength := int(header[26])
+	numTags := int(binary.LittleEndian.Uint16(header[28:30]))
+	if f.BlockSize < PageSize || entryLength < 21 || numTags > maxTags {
+		return nil, ErrBadHeader
+	}
+	entries := make([]byte, entryLength*numTags)
+	_, err = r.ReadAt(entries, tagTableOffset)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrBadHeader
+		}
+		return nil, err
+	}
+	for i := 0; i < numTags; i++ {
+		entry := entries[i*entryLength : (i+1)*entryLength]
+		tag, err := f.readTag(entry)
+		if err != nil {
+			return nil, err
+		}
+		f.Tags = append(f.Tags, tag)
+	}
+	return f, nil
+}
+
+// readTag reads the tag header a tag directory entry points to
+func (f *File) readTag(entry []byte) (*Tag, error) {
+	headerPage := binary.LittleEndian.Uint32(entry[0:4])
+	header := make([]byte, 244)
+	_, err := f.r.ReadAt(header, int64(headerPage)*PageSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	t := &Tag{
+		Name:       strings.TrimRight(string(entry[4:15]), "\x00 "),
+		KeyType:    header[9],
+		KeyLength:  int(binary.LittleEndian.Uint16(header[12:14])),
+		Descending: header[8]&formatDescending != 0,
+		Unique:     header[8]&formatUnique != 0 || header[23] != 0,
+		f:          f,
+		root:       binary.LittleEndian.Uint32(header[0:4]),
+		itemLength: int(binary.LittleEndian.Uint16(header[18:20])),
+	}
+	expr := header[24:]
+	if end := strings.IndexByte(string(expr), 0); end >= 0 {
+		expr = expr[:end]
+	}
+	t.Expression = strings.TrimSpace(string(expr))
+	if t.KeyLength == 0 || t.itemLength < t.KeyLength+4 || 8+2*t.itemLength > f.BlockSize {
+		return nil, errors.New("mdx: bad header for tag " + t.Name)
+	}
+	return t, nil
+}
+
+// Tag finds a tag by name, nil if there is none
+func (f *File) Tag(name string) *Tag {
+	for _, t := range f.Tags {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// node is one B-tree block
+type node struct {
+	// entries is the number of keys, each after its pointer in data; an
+	// interior node also has the rightmost child pointer after the last
+	entries int
+	data    []byte
+	leaf    bool
+}
+
+func (t *Tag) readNode(page uint32) (*node, error) {
+	data := make([]byte, t.f.BlockSize)
+	_, err := t.f.r.ReadAt(data, int64(page)*PageSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	n := &node{entries: int(binary.LittleEndian.Uint32(data[0:4])), data: data}
+	if n.entries < 0 || 8+(n.entries+1)*t.itemLength > len(data) {
+		return nil, ErrBadPage
+	}
+	// a leaf has no pointer after its last key
+	n.leaf = n.pointer(t, n.entries) == 0
+	return n, nil
+}
+
+func (n *node) pointer(t *Tag, i int) uint32 {
+	start := 8 + i*t.itemLength
+	return binary.LittleEndian.Uint32(n.data[start : start+4])
+}
+
+func (n *node) key(t *Tag, i int) []byte {
+	start := 8 + i*t.itemLength + 4
+	return n.data[start : start+t.KeyLength]
+}
+
+// maxDepth bounds B-tree descent in a corrupt file
+const maxDepth = 64
+
+// Walk calls fn with each key and its record number (1 based, as in the
+// index) in index order. Returning ErrStop from fn ends the walk early.
+func (t *Tag) Walk(fn func(key []byte, record uint32) error) error {
+	err := t.walk(t.root, nil, fn, map[uint32]bool{}, 0)
+	if err == ErrStop {
+		return nil
+	}
+	return err
+}
+
+// Find calls fn with the record number (1 based) of each key matching
+// key, in index order, reading only the pages that can hold them.
+// Character keys match by prefix, as dBASE SEEK does with SET EXACT OFF.
+// Numeric keys are the number as text, date keys YYYYMMDD.
+func (t *Tag) Find(key string, fn func(record uint32) error) error {
+	want, err := t.searchKey(key)
+	if err != nil {
+		return err
+	}
+	err = t.walk(t.root, want, func(k []byte, record uint32) error {
+		c := t.compare(k, want)
+		if c > 0 {
+			return ErrStop
+		} else if c < 0 {
+			return nil
+		}
+		return fn(record)
+	}, map[uint32]bool{}, 0)
+	if err == ErrStop {
+		return nil
+	}
+	return err
+}
+
+// julianUnixEpoch is the Julian day number of 1970-01-01
+const julianUnixEpoch = 2440588
+
+// searchKey is key as text for character tags, or a number encoded as 8
+// float64 bytes to compare against Number
+func (t *Tag) searchKey(key string) ([]byte, error) {
+	if t.KeyType == 'C' {
+		if len(key) > t.KeyLength {
+			return nil, errors.New("mdx: key longer than " + strconv.Itoa(t.KeyLength))
+		}
+		return []byte(key), nil
+	}
+	key = strings.TrimSpace(key)
+	var v float64
+	if t.KeyType == 'D' {
+		day, err := time.Parse("20060102", key)
+		if err != nil {
+			return nil, err
+		}
+		v = float64(day.Unix()/86400 + julianUnixEpoch)
+	} else {
+		var err error
+		v, err = strconv.ParseFloat(key, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	return b[:], nil
+}
+
+// compare orders an index key against a search key in index order,
+// character keys by the prefix the search key covers
+func (t *Tag) compare(k, want []byte) int {
+	c := 0
+	if t.KeyType == 'C' {
+		c = bytes.Compare(k[:len(want)], want)
+	} else {
+		a := Number(k)
+		b := math.Float64frombits(binary.LittleEndian.Uint64(want))
+		if a < b {
+			c = -1
+		} else if a > b {
+			c = 1
+		}
+	}
+	if t.Descending {
+		return -c
+	}
+	return c
+}
+
+// walk visits the keys under page in order. With want set it skips child
+// pages whose keys, bounded by the interior key after them, all sort
+// before want. visited holds the blocks read so far; a corrupt file whose
+// child pointers lead back to one fails with ErrBadPage.
+func (t *Tag) walk(page uint32, want []byte, fn func(key []byte, record uint32) error, visited map[uint32]bool, depth int) error {
+	if depth > maxDepth || visited[page] {
+		return ErrBadPage
+	}
+	visited[page] = true
+	n, err := t.readNode(page)
+	if err != nil {
+		return err
+	}
+	if n.leaf {
+		for i := 0; i < n.entries; i++ {
+			err = fn(n.key(t, i), n.pointer(t, i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := 0; i <= n.entries; i++ {
+		if want != nil && i < n.entries && t.compare(n.key(t, i), want) < 0 {
+			continue
+		}
+		err = t.walk(n.pointer(t, i), want, fn, visited, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KeyString is a key as text: character keys without trailing spaces,
+// numeric and date keys as numbers
+func (t *Tag) KeyString(key []byte) string {
+	if t.KeyType == 'C' {
+		return strings.TrimRight(string(key), " \x00")
+	}
+	return strconv.FormatFloat(Number(key), 'f', -1, 64)
+}
+
+// Number decodes the 12 byte decimal form of numeric and date keys: the
+// count of integer digits plus 0x34, a sign bit 0x80 and digit count,
+// then 20 digits packed two per byte. Dates are Julian day numbers.
+func Number(key []byte) float64 {
+	if len(key) < 12 {
+		return 0
+	}
+	var digits [20]byte
+	for i := range digits {
+		digit := key[2+i/2]
+		if i%2 == 0 {
+			digit >>= 4
+		}
+		digits[i] = '0' + digit&0x0f
+	}
+	// the digits are 0.d1d2...d20 times 10 to the exponent
+	v, _ := strconv.ParseFloat("0."+string(digits[:])+"e"+strconv.Itoa(int(key[0])-0x34), 64)
+	if key[1]&0x80 != 0 {
+		v = -v
+	}
+	return v
+}
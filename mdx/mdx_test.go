@@ -0,0 +1,234 @@
+package mdx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// item is one B-tree entry, pointer a record in a leaf and a child block
+// in an interior node; an item without a key is the child pointer after
+// the last key of an interior node
+type item struct {
+	pointer uint32
+	key     []byte
+}
+
+type tagSpec struct {
+	name       string
+	keyType    byte
+	keyLength  int
+	format     byte
+	header     uint32
+	root       uint32
+	expression string
+}
+
+// mdxFile builds an index with 512 byte blocks, pages holding the nodes by
+// page number
+func mdxFile(tags []tagSpec, pages map[uint32][]item) []byte {
+	last := uint32(1)
+	for _, tag := range tags {
+		if tag.header > last {
+			last = tag.header
+		}
+	}
+	for page := range pages {
+		if page > last {
+			last = page
+		}
+	}
+	data := make([]byte, PageSize*(last+1))
+	copy(data[4:20], "PEOPLE")
+	binary.LittleEndian.PutUint16(data[22:24], PageSize)
+	data[24] = 1
+	data[26] = 32
+	binary.LittleEndian.PutUint16(data[28:30], uint16(len(tags)))
+	itemLength := map[uint32]int{}
+	for i, tag := range tags {
+		entry := data[tagTableOffset+32*i:]
+		binary.LittleEndian.PutUint32(entry[0:4], tag.header)
+		copy(entry[4:15], tag.name)
+		header := data[tag.header*PageSize:]
+		binary.LittleEndian.PutUint32(header[0:4], tag.root)
+		header[8] = tag.format
+		header[9] = tag.keyType
+		binary.LittleEndian.PutUint16(header[12:14], uint16(tag.keyLength))
+		length := (tag.keyLength + 4 + 3) &^ 3
+		binary.LittleEndian.PutUint16(header[18:20], uint16(length))
+		copy(header[24:], tag.expression)
+		// the nodes of a tag follow its header
+		for page := tag.header + 1; pages[page] != nil; page++ {
+			itemLength[page] = length
+		}
+	}
+	for page, items := range pages {
+		node := data[page*PageSize : (page+1)*PageSize]
+		entries := 0
+		for j, it := range items {
+			start := 8 + j*itemLength[page]
+			binary.LittleEndian.PutUint32(node[start:], it.pointer)
+			if it.key != nil {
+				entries++
+				copy(node[start+4:], it.key)
+			}
+		}
+		binary.LittleEndian.PutUint32(node[0:4], uint32(entries))
+	}
+	return data
+}
+
+// numKey is the 12 byte decimal form of a number written as text
+func numKey(s string) []byte {
+	key := make([]byte, 12)
+	if strings.HasPrefix(s, "-") {
+		key[1] = 0x80
+		s = s[1:]
+	}
+	whole, frac := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		whole, frac = s[:dot], s[dot+1:]
+	}
+	whole = strings.TrimLeft(whole, "0")
+	digits, exponent := whole+frac, len(whole)
+	for strings.HasPrefix(digits, "0") {
+		digits = digits[1:]
+		exponent--
+	}
+	key[0] = byte(0x34 + exponent)
+	key[1] |= byte(len(digits))
+	for i := 0; i < len(digits); i++ {
+		d := digits[i] - '0'
+		if i%2 == 0 {
+			d <<= 4
+		}
+		key[2+i/2] |= d
+	}
+	return key
+}
+
+// people has a two level NAME tag, POP and BORN, and NAME descending in
+// NAMED
+func people() []byte {
+	return mdxFile([]tagSpec{
+		{name: "NAME", keyType: 'C', keyLength: 6, header: 2, root: 3, expression: "NAME"},
+		{name: "POP", keyType: 'N', keyLength: 12, header: 6, root: 7, expression: "POP"},
+		{name: "BORN", keyType: 'D', keyLength: 12, header: 8, root: 9, expression: "BORN"},
+		{name: "NAMED", keyType: 'C', keyLength: 6, format: formatDescending, header: 10, root: 11, expression: "NAME"},
+	}, map[uint32][]item{
+		3:  {{4, []byte("CHARLI")}, {5, nil}},
+		4:  {{3, []byte("ALPHA ")}, {1, []byte("BRAVO ")}, {5, []byte("CHARLI")}},
+		5:  {{2, []byte("DELTA ")}, {4, []byte("ECHO  ")}},
+		7:  {{2, numKey("-1")}, {1, numKey("2.5")}, {4, numKey("2.5")}, {3, numKey("120")}},
+		9:  {{2, numKey("2451545")}, {1, numKey("2451546")}},
+		11: {{4, []byte("ECHO  ")}, {2, []byte("DELTA ")}, {1, []byte("BRAVO ")}},
+	})
+}
+
+func open(t *testing.T, data []byte) *File {
+	t.Helper()
+	f, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func find(t *testing.T, tag *Tag, key string) []uint32 {
+	t.Helper()
+	var got []uint32
+	err := tag.Find(key, func(record uint32) error {
+		got = append(got, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestOpen(t *testing.T) {
+	f := open(t, people())
+	if f.Table != "PEOPLE" || f.BlockSize != PageSize || !f.Production || len(f.Tags) != 4 {
+		t.Fatalf("header %+v", f)
+	}
+	tag := f.Tag("named")
+	if tag == nil || tag.KeyType != 'C' || tag.KeyLength != 6 || !tag.Descending || tag.Expression != "NAME" {
+		t.Errorf("NAMED tag %+v", tag)
+	}
+	if f.Tag("NOPE") != nil {
+		t.Error("found a tag NOPE")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tag := open(t, people()).Tag("NAME")
+	var keys []string
+	var records []uint32
+	err := tag.Walk(func(key []byte, record uint32) error {
+		keys = append(keys, tag.KeyString(key))
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"ALPHA", "BRAVO", "CHARLI", "DELTA", "ECHO"}) || !reflect.DeepEqual(records, []uint32{3, 1, 5, 2, 4}) {
+		t.Errorf("Walk %v %v", keys, records)
+	}
+}
+
+func TestFind(t *testing.T) {
+	f := open(t, people())
+	for _, c := range []struct {
+		tag, key string
+		want     []uint32
+	}{
+		{"NAME", "BRAVO", []uint32{1}},
+		{"NAME", "CH", []uint32{5}},
+		{"NAME", "E", []uint32{4}},
+		{"NAME", "", []uint32{3, 1, 5, 2, 4}},
+		{"NAME", "FOX", nil},
+		{"NAMED", "D", []uint32{2}},
+		{"NAMED", "", []uint32{4, 2, 1}},
+		{"NAMED", "A", nil},
+		{"POP", "2.5", []uint32{1, 4}},
+		{"POP", " -1 ", []uint32{2}},
+		{"POP", "3", nil},
+		{"BORN", "20000102", []uint32{1}},
+		{"BORN", "19991231", nil},
+	} {
+		if got := find(t, f.Tag(c.tag), c.key); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s Find %q = %v, want %v", c.tag, c.key, got, c.want)
+		}
+	}
+	for _, c := range []struct{ tag, key string }{{"NAME", "CHARLIE"}, {"POP", "many"}, {"BORN", "2000-01-01"}} {
+		if err := f.Tag(c.tag).Find(c.key, func(uint32) error { return nil }); err == nil {
+			t.Errorf("%s Find %q did not fail", c.tag, c.key)
+		}
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	// the last child of the root points back at the root
+	f := open(t, mdxFile([]tagSpec{{name: "NAME", keyType: 'C', keyLength: 6, header: 2, root: 3}}, map[uint32][]item{
+		3: {{4, []byte("B")}, {3, nil}},
+		4: {{1, []byte("A")}},
+	}))
+	if err := f.Tags[0].Walk(func([]byte, uint32) error { return nil }); err != ErrBadPage {
+		t.Errorf("Walk = %v, want ErrBadPage", err)
+	}
+
+	// each block has both children the next, 2^30 paths to the leaf
+	pages := map[uint32][]item{}
+	for page := uint32(3); page < 33; page++ {
+		pages[page] = []item{{page + 1, []byte("B")}, {page + 1, nil}}
+	}
+	pages[33] = []item{{1, []byte("A")}}
+	f = open(t, mdxFile([]tagSpec{{name: "NAME", keyType: 'C', keyLength: 6, header: 2, root: 3}}, pages))
+	if err := f.Tags[0].Walk(func([]byte, uint32) error { return nil }); err != ErrBadPage {
+		t.Errorf("Walk shared blocks = %v, want ErrBadPage", err)
+	}
+}
@@ -0,0 +1,279 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+var ErrNoMemo error = errors.New("dbf memo field read without AttachMemo")
+var ErrBadMemo error = errors.New("dbf bad memo block")
+
+// memoFormat is the layout of memo blocks
+type memoFormat int
+
+const (
+	// memoTerminated memos run to a 0x1a byte, dBASE III
+	memoTerminated memoFormat = iota
+	// memoDBase memos start with ff ff 08 00 and a length, dBASE IV and 7
+	memoDBase
+	// memoFoxPro memos start with a big endian type and length
+	memoFoxPro
+)
+
+// memoFile is an attached memo file
+type memoFile struct {
+	r         io.ReaderAt
+	blockSize int64
+	format    memoFormat
+	// size is the memo file size, -1 if r does not say
+	size int64
+}
+
+// memoHeaderLength is the 8 byte header of a dBASE IV and later memo
+// block: ff ff 08 00, then the little endian length including the header
+const memoHeaderLength = 8
+
+// maxMemoLength bounds the length a memo block header may claim when the
+// memo file size is not known
+const maxMemoLength = 64 << 20
+
+// defaultMemoBlockSize is used when the memo file header has none
+const defaultMemoBlockSize = 512
+
+// foxProMemoHeaderLength is the 8 byte header of a FoxPro memo block: the
+// big endian block type (0 picture, 1 text, 2 object), then the big endian
+// length of the data after the header
+const foxProMemoHeaderLength = 8
+
+// defaultFoxProMemoBlockSize is used when a .fpt header has none
+const defaultFoxProMemoBlockSize = 64
+
+// isMemoType is true for fields that point into a memo file
+func isMemoType(t DbfFieldType) bool {
+	return t == 'M' || t == 'G' || t == 'B' || t == 'P'
+}
+
+// WithMemoBlockSize makes AttachMemo use size byte blocks whatever the
+// memo file header says, for memo files written with a block size their
+// header does not give, which reads garbled text. The size of dBASE III
+// memo files is 512 otherwise. WithMemo sets the size of a memo file a
+// Writer writes.
+func WithMemoBlockSize(size int) Option {
+	return func(d *Dbf) {
+		d.memoBlockSize = int64(size)
+	}
+}
+
+// MemoBlockSize is the block size of the attached memo file, 0 before
+// AttachMemo
+func (d *Dbf) MemoBlockSize() int {
+	if d.memo == nil {
+		return 0
+	}
+	return int(d.memo.blockSize)
+}
+
+// AttachMemo reads memo values for M (and B, G, P) fields from r, the
+// memo file next to the table: .dbt for dBASE III and IV, .smt for dBASE
+// 7, .fpt for FoxPro and Visual FoxPro. dBASE III memos are 512 byte
+// blocks of text ended by 0x1a; the other formats read the block size from
+// the memo file header. WithMemoBlockSize overrides either.
+func (d *Dbf) AttachMemo(r io.ReaderAt) error {
+	m, err := openMemo(d.Version, r)
+	if err != nil {
+		return err
+	}
+	if d.memoBlockSize > 0 {
+		m.blockSize = d.memoBlockSize
+	}
+	m.size = memoSize(r)
+	d.memo = m
+	return nil
+}
+
+// memoSize is the size of r from os.File Stat or a Size() method
+// (bytes.Reader, io.SectionReader), -1 if it has neither
+func memoSize(r io.ReaderAt) int64 {
+	switch v := r.(type) {
+	case interface{ Stat() (os.FileInfo, error) }:
+		fi, err := v.Stat()
+		if err == nil && fi.Mode().IsRegular() {
+			return fi.Size()
+		}
+	case interface{ Size() int64 }:
+		return v.Size()
+	}
+	return -1
+}
+
+// fits is true if length bytes at offset can be in the memo file, so that
+// a corrupt length is not allocated
+func (m *memoFile) fits(offset, length int64) bool {
+	if m.size >= 0 {
+		return length <= m.size-offset
+	}
+	return length <= maxMemoLength
+}
+
+// openMemo reads the memo file header for a table of version
+func openMemo(version byte, r io.ReaderAt) (*memoFile, error) {
+	switch {
+	case isFoxPro(version):
+		var header [8]byte
+		_, err := r.ReadAt(header[:], 0)
+		if err != nil {
+			return nil, err
+		}
+		m := &memoFile{r: r, blockSize: int64(binary.BigEndian.Uint16(header[6:8])), format: memoFoxPro}
+		if m.blockSize == 0 {
+			m.blockSize = defaultFoxProMemoBlockSize
+		}
+		return m, nil
+	case version&0x07 == 3 && version&0x08 == 0:
+		return &memoFile{r: r, blockSize: defaultMemoBlockSize, format: memoTerminated}, nil
+	case version&0x07 == 3, version&0x07 == 4:
+		// dBASE IV has the memo bit 0x08 set, dBASE 7 is level 4
+	default:
+		return nil, errors.New("dbf memo files for version " + strconv.FormatUint(uint64(version), 16) + " are not supported")
+	}
+	var header [22]byte
+	_, err := r.ReadAt(header[:], 0)
+	if err != nil {
+		return nil, err
+	}
+	m := &memoFile{r: r, blockSize: int64(binary.LittleEndian.Uint16(header[20:22])), format: memoDBase}
+	if m.blockSize == 0 {
+		m.blockSize = defaultMemoBlockSize
+	}
+	return m, nil
+}
+
+// memoBlock is the block number a memo field points to, 0 for none. Level
+// 7 and Visual FoxPro tables may store it as 4 binary bytes, others as
+// decimal text.
+func (h *DbfField) memoBlock() (int64, error) {
+	raw := h.raw()
+	if raw == nil {
+		return 0, ErrFieldBounds
+	}
+	if h.Width == 4 {
+		return int64(binary.LittleEndian.Uint32(raw)), nil
+	}
+	v := string(trimPadding(raw, false))
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// MemoValue reads the memo file contents a memo field of the current row
+// points to, nil for an empty memo. AttachMemo must be called first.
+func (h *DbfField) MemoValue() ([]byte, error) {
+	if h.d == nil || h.d.memo == nil {
+		return nil, ErrNoMemo
+	}
+	m := h.d.memo
+	block, err := h.memoBlock()
+	if err != nil {
+		return nil, h.parseError(err)
+	}
+	if block == 0 {
+		return nil, nil
+	}
+	value, err := m.read(block)
+	if err != nil {
+		return nil, h.parseError(err)
+	}
+	return value, nil
+}
+
+// read returns the memo starting at block
+func (m *memoFile) read(block int64) ([]byte, error) {
+	switch m.format {
+	case memoTerminated:
+		return m.readTerminated(block * m.blockSize)
+	case memoFoxPro:
+		return m.readFoxPro(block * m.blockSize)
+	}
+	var header [memoHeaderLength]byte
+	offset := block * m.blockSize
+	_, err := m.r.ReadAt(header[:], offset)
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != 0xff || header[1] != 0xff || header[2] != 0x08 || header[3] != 0x00 {
+		return nil, ErrBadMemo
+	}
+	length := int64(binary.LittleEndian.Uint32(header[4:8])) - memoHeaderLength
+	if length < 0 || !m.fits(offset+memoHeaderLength, length) {
+		return nil, ErrBadMemo
+	}
+	value := make([]byte, length)
+	_, err = m.r.ReadAt(value, offset+memoHeaderLength)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return value, err
+}
+
+// span is the bytes of the memo file the memo at block takes, in whole
+// blocks
+func (m *memoFile) span(block int64) (int64, error) {
+	value, err := m.read(block)
+	if err != nil {
+		return 0, err
+	}
+	n := int64(len(value))
+	switch m.format {
+	case memoTerminated:
+		// the 0x1a terminator
+		n++
+	case memoFoxPro:
+		n += foxProMemoHeaderLength
+	default:
+		n += memoHeaderLength
+	}
+	return (n + m.blockSize - 1) / m.blockSize * m.blockSize, nil
+}
+
+// readTerminated reads from offset up to a 0x1a byte or the end of the file
+func (m *memoFile) readTerminated(offset int64) ([]byte, error) {
+	var value []byte
+	buf := make([]byte, m.blockSize)
+	for {
+		n, err := m.r.ReadAt(buf, offset)
+		if end := bytes.IndexByte(buf[:n], 0x1a); end >= 0 {
+			return append(value, buf[:end]...), nil
+		}
+		value = append(value, buf[:n]...)
+		if err == io.EOF {
+			return value, nil
+		} else if err != nil {
+			return nil, err
+		}
+		offset += int64(n)
+	}
+}
+
+// readFoxPro reads a memo with a FoxPro block header at offset
+func (m *memoFile) readFoxPro(offset int64) ([]byte, error) {
+	var header [foxProMemoHeaderLength]byte
+	_, err := m.r.ReadAt(header[:], offset)
+	if err != nil {
+		return nil, err
+	}
+	length := int64(binary.BigEndian.Uint32(header[4:8]))
+	if binary.BigEndian.Uint32(header[0:4]) > 2 || !m.fits(offset+foxProMemoHeaderLength, length) {
+		return nil, ErrBadMemo
+	}
+	value := make([]byte, length)
+	_, err = m.r.ReadAt(value, offset+foxProMemoHeaderLength)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return value, err
+}
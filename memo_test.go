@@ -0,0 +1,139 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// memoTable is a table of version with one M field pointing at block 1
+func memoTable(t *testing.T, version byte) *Dbf {
+	table := fuzzTable([]fuzzField{{"NOTE", 'M', 10, 0}}, []string{"          1"}, 0, 0, true)
+	table[0] = version
+	d, err := NewDbf(bytes.NewReader(table), WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// wantBadMemo fails unless the memo of d's first field is ErrBadMemo
+func wantBadMemo(t *testing.T, d *Dbf) {
+	t.Helper()
+	v, err := d.Fields[0].MemoValue()
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Err != ErrBadMemo {
+		t.Errorf("MemoValue = %d bytes, %v; want ErrBadMemo", len(v), err)
+	}
+}
+
+func TestMemoLengthPastEnd(t *testing.T) {
+	d := memoTable(t, 0x8b)
+	memo := make([]byte, 1024)
+	binary.LittleEndian.PutUint16(memo[20:22], 512)
+	copy(memo[512:], []byte{0xff, 0xff, 0x08, 0x00, 0xff, 0xff, 0xff, 0xff})
+	if err := d.AttachMemo(bytes.NewReader(memo)); err != nil {
+		t.Fatal(err)
+	}
+	wantBadMemo(t, d)
+	// without a size the length is still bounded
+	if err := d.AttachMemo(struct{ io.ReaderAt }{bytes.NewReader(memo)}); err != nil {
+		t.Fatal(err)
+	}
+	wantBadMemo(t, d)
+}
+
+func TestFoxProMemoLengthPastEnd(t *testing.T) {
+	d := memoTable(t, 0x30)
+	memo := make([]byte, 128)
+	binary.BigEndian.PutUint16(memo[6:8], 64)
+	copy(memo[64:], []byte{0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff})
+	if err := d.AttachMemo(bytes.NewReader(memo)); err != nil {
+		t.Fatal(err)
+	}
+	wantBadMemo(t, d)
+}
+
+func TestWriterMemo(t *testing.T) {
+	notes := []string{"first memo", "", strings.Repeat("a longer memo over blocks ", 80)}
+	for _, c := range []struct {
+		version   byte
+		blockSize int
+		opts      []Option
+		wantSize  int
+		wantByte0 byte
+	}{
+		{0x03, 0, nil, 512, 0x83},
+		{0x03, 64, []Option{WithMemoBlockSize(64)}, 64, 0x83},
+		{0x04, 1024, nil, 1024, 0x8c},
+		{0x30, 0, nil, 64, 0x30},
+		{0x30, 32, nil, 32, 0x30},
+	} {
+		memo, err := ioutil.TempFile("", "memo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(memo.Name())
+		defer memo.Close()
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, []DbfField{{Name: "NAME", Type: DbfFieldChar, Width: 5}, {Name: "NOTE", Type: 'M'}},
+			WithTableVersion(c.version), WithMemo(memo, c.blockSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.NumRecords = uint32(len(notes))
+		for _, note := range notes {
+			if err = w.WriteRecord("x", note); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		table := buf.Bytes()
+		if table[0] != c.wantByte0 || c.version == 0x30 && table[28]&0x02 == 0 {
+			t.Errorf("version %#x: header %#x flags %#x", c.version, table[0], table[28])
+		}
+		d, err := NewDbf(bytes.NewReader(table), append(c.opts, WithStrict())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = d.AttachMemo(memo); err != nil {
+			t.Fatal(err)
+		}
+		if d.MemoBlockSize() != c.wantSize {
+			t.Errorf("version %#x: block size %d, want %d", c.version, d.MemoBlockSize(), c.wantSize)
+		}
+		// the header has the next free block, the end of the file
+		var header [4]byte
+		memo.ReadAt(header[:], 0)
+		next := binary.LittleEndian.Uint32(header[:])
+		if c.version == 0x30 {
+			next = binary.BigEndian.Uint32(header[:])
+		}
+		fi, err := memo.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() != int64(next)*int64(c.wantSize) {
+			t.Errorf("version %#x: next free block %d for %v bytes", c.version, next, fi.Size())
+		}
+		for i, note := range notes {
+			if err = d.Next(); err != nil {
+				t.Fatal(err)
+			}
+			v, err := d.Field("NOTE").MemoValue()
+			if err != nil || string(v) != note {
+				t.Errorf("version %#x record %d: memo %q, %v, want %q", c.version, i, v, err, note)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// memoHeaderBytes is the size of the memo file header, rounded up to
+// whole blocks
+const memoHeaderBytes = 512
+
+// memoWriter writes the memo file of a Writer
+type memoWriter struct {
+	w         io.WriteSeeker
+	format    memoFormat
+	blockSize int64
+	// start is where the memo file starts in w, next the block the next
+	// memo goes in
+	start int64
+	next  int64
+}
+
+// WithMemo writes the values of M fields to memo, the memo file next to
+// the table, in blockSize byte blocks, or 0 for the usual size of the
+// version: dBASE III .dbt memos ended by 0x1a in 512 byte blocks, dBASE 7
+// .dbt memos with a length header in 512 byte blocks, or Visual FoxPro
+// .fpt text memos in 64 byte blocks. The fields hold the block numbers,
+// empty values none, and the table header gets the memo flag. Text is
+// transcoded like character fields. dBASE III memo files do not record
+// their block size, so any other than 512 must be read back with
+// WithMemoBlockSize. Close writes the next free block into the memo
+// header; it does not close memo. WriteRaw records keep the block
+// numbers they have.
+func WithMemo(memo io.WriteSeeker, blockSize int) WriterOption {
+	return func(w *Writer) {
+		w.memoOut = memo
+		w.memoBlockSize = blockSize
+	}
+}
+
+// newMemoWriter writes the header of a memo file for a table of version
+// at the current position of w
+func newMemoWriter(version byte, w io.WriteSeeker, blockSize int) (*memoWriter, error) {
+	m := &memoWriter{w: w, format: memoTerminated, blockSize: defaultMemoBlockSize}
+	if isVisualFoxPro(version) {
+		m.format = memoFoxPro
+		m.blockSize = defaultFoxProMemoBlockSize
+	} else if version == 0x04 {
+		m.format = memoDBase
+	}
+	if blockSize != 0 {
+		m.blockSize = int64(blockSize)
+	}
+	if m.blockSize <= 0 || m.blockSize > 0xffff {
+		return nil, errors.New("dbf memo block size must be 1..65535: " + strconv.Itoa(blockSize))
+	}
+	var err error
+	m.start, err = w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	m.next = (memoHeaderBytes + m.blockSize - 1) / m.blockSize
+	header := make([]byte, m.next*m.blockSize)
+	switch m.format {
+	case memoFoxPro:
+		binary.BigEndian.PutUint16(header[6:8], uint16(m.blockSize))
+	case memoDBase:
+		binary.LittleEndian.PutUint16(header[20:22], uint16(m.blockSize))
+	}
+	m.putNext(header)
+	_, err = w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// putNext sets the next free block in the first bytes of header
+func (m *memoWriter) putNext(header []byte) {
+	if m.format == memoFoxPro {
+		binary.BigEndian.PutUint32(header[0:4], uint32(m.next))
+	} else {
+		binary.LittleEndian.PutUint32(header[0:4], uint32(m.next))
+	}
+}
+
+// write adds a memo in whole blocks and returns the block it starts at
+func (m *memoWriter) write(value []byte) (int64, error) {
+	var data []byte
+	switch m.format {
+	case memoTerminated:
+		if bytes.IndexByte(value, 0x1a) >= 0 {
+			return 0, errors.New("dBASE III memo text cannot hold 0x1a")
+		}
+		data = append(append(data, value...), 0x1a, 0x1a)
+	case memoFoxPro:
+		data = make([]byte, foxProMemoHeaderLength, foxProMemoHeaderLength+len(value))
+		// type 1 is text
+		binary.BigEndian.PutUint32(data[0:4], 1)
+		binary.BigEndian.PutUint32(data[4:8], uint32(len(value)))
+		data = append(data, value...)
+	default:
+		data = make([]byte, memoHeaderLength, memoHeaderLength+len(value))
+		data[0], data[1], data[2], data[3] = 0xff, 0xff, 0x08, 0x00
+		binary.LittleEndian.PutUint32(data[4:8], uint32(memoHeaderLength+len(value)))
+		data = append(data, value...)
+	}
+	blocks := (int64(len(data)) + m.blockSize - 1) / m.blockSize
+	if m.next+blocks > 0xffffffff {
+		return 0, errors.New("memo file full")
+	}
+	data = append(data, make([]byte, blocks*m.blockSize-int64(len(data)))...)
+	_, err := m.w.Write(data)
+	if err != nil {
+		return 0, err
+	}
+	block := m.next
+	m.next += blocks
+	return block, nil
+}
+
+// close writes the next free block into the header, leaving w at its end
+func (m *memoWriter) close() error {
+	end, err := m.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = m.w.Seek(m.start, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	next := make([]byte, 4)
+	m.putNext(next)
+	_, err = m.w.Write(next)
+	if err != nil {
+		return err
+	}
+	_, err = m.w.Seek(end, io.SeekStart)
+	return err
+}
+
+// writeMemo writes the memo of a field value, transcoded by the encoder,
+// and returns its block, 0 for an empty value
+func (w *Writer) writeMemo(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	value := []byte(v)
+	if w.encoder != nil {
+		var err error
+		value, err = w.encoder.Bytes(value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return w.memo.write(value)
+}
@@ -0,0 +1,187 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// mmapReader reads a file mapped into memory, see OpenMmap
+type mmapReader struct {
+	data []byte
+	off  int64
+	// unmap releases data, nil once done
+	unmap func() error
+}
+
+// OpenMmap opens the table at path by mapping the file into memory, where
+// there is mmap (elsewhere the file is read in whole). Records are not
+// copied: field values, RawRecord and the records of NextN are read from
+// the mapping, and RecordAt is a slice away. It is seekable and stays open
+// at the end of the data, as with WithRewind; Close unmaps the file, after
+// which RawRecord and NextN records from before must not be used. Changes
+// to the file by others while it is mapped show through.
+func OpenMmap(path string, opts ...Option) (*Dbf, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		f.Close()
+		return nil, errors.New("dbf OpenMmap " + path + " is not a regular file")
+	}
+	// the mapping outlives the file descriptor
+	m, err := mapFile(f, fi.Size())
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		if m != nil {
+			m.Close()
+		}
+		return nil, err
+	}
+	d, err := NewDbf(m, append(opts[:len(opts):len(opts)], WithRewind())...)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	d.mapped = m
+	d.ownBuffer = d.recordBuffer
+	return d, nil
+}
+
+func (m *mmapReader) Read(p []byte) (int, error) {
+	if m.off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.off:])
+	m.off += int64(n)
+	return n, nil
+}
+
+func (m *mmapReader) ReadByte() (byte, error) {
+	if m.off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	b := m.data[m.off]
+	m.off++
+	return b, nil
+}
+
+func (m *mmapReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("dbf mmap ReadAt negative offset")
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m *mmapReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += m.off
+	case io.SeekEnd:
+		offset += int64(len(m.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("dbf mmap Seek before start")
+	}
+	m.off = offset
+	return offset, nil
+}
+
+func (m *mmapReader) Size() int64 {
+	return int64(len(m.data))
+}
+
+// span is the next n bytes of the mapping, fewer at the end, and moves past them
+func (m *mmapReader) span(n int) []byte {
+	if m.off >= int64(len(m.data)) {
+		return nil
+	}
+	end := m.off + int64(n)
+	if end > int64(len(m.data)) {
+		end = int64(len(m.data))
+	}
+	b := m.data[m.off:end:end]
+	m.off = end
+	return b
+}
+
+func (m *mmapReader) Close() error {
+	m.data = nil
+	if m.unmap == nil {
+		return nil
+	}
+	unmap := m.unmap
+	m.unmap = nil
+	return unmap()
+}
+
+// mappedSpan is the next n bytes from the mapping, false if the Dbf is not
+// mapped or bytes were pushed back ahead of it
+func (d *Dbf) mappedSpan(n int) ([]byte, bool) {
+	if d.mapped == nil || d.reader == nil || len(d.unread) != 0 {
+		return nil, false
+	}
+	b := d.mapped.span(n)
+	d.pos += int64(len(b))
+	return b, true
+}
+
+// setRow makes row the bytes of the current record after the flag,
+// pointing at it rather than copying when the Dbf is mapped
+func (d *Dbf) setRow(row []byte) {
+	if d.mapped != nil {
+		if len(row) >= len(d.ownBuffer) {
+			d.recordBuffer = row[:len(d.ownBuffer):len(d.ownBuffer)]
+			return
+		}
+		d.recordBuffer = d.ownBuffer
+	}
+	copy(d.recordBuffer, row)
+}
+
+// readRow reads the bytes of the record after the flag into the current
+// row, returning how many there were as io.ReadFull
+func (d *Dbf) readRow() (int, error) {
+	if d.mapped != nil && d.rowWidth == len(d.ownBuffer) {
+		if row, ok := d.mappedSpan(d.rowWidth); ok {
+			if len(row) < d.rowWidth {
+				d.recordBuffer = d.ownBuffer
+				copy(d.recordBuffer, row)
+				if len(row) == 0 {
+					return 0, io.EOF
+				}
+				return len(row), io.ErrUnexpectedEOF
+			}
+			d.recordBuffer = row
+			return len(row), nil
+		}
+	}
+	if d.mapped != nil {
+		d.recordBuffer = d.ownBuffer
+	}
+	return d.readFull(d.recordBuffer[:d.rowWidth])
+}
+
+// unmapRow copies the current row out of the mapping before it goes away
+func (d *Dbf) unmapRow() {
+	if d.mapped != nil && len(d.ownBuffer) != 0 && &d.recordBuffer[0] != &d.ownBuffer[0] {
+		copy(d.ownBuffer, d.recordBuffer)
+		d.recordBuffer = d.ownBuffer
+	}
+}
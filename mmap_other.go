@@ -0,0 +1,19 @@
+//go:build tinygo || !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+// +build tinygo !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package dbf
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the file into memory where there is no mmap
+func mapFile(f *os.File, size int64) (*mmapReader, error) {
+	data := make([]byte, size)
+	_, err := io.ReadFull(f, data)
+	if err != nil {
+		return nil, err
+	}
+	return &mmapReader{data: data}, nil
+}
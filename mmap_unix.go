@@ -0,0 +1,26 @@
+//go:build !tinygo && (linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+// +build !tinygo
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package dbf
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of f read only
+func mapFile(f *os.File, size int64) (*mmapReader, error) {
+	if size == 0 {
+		// mmap of nothing fails
+		return &mmapReader{}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, syscall.EFBIG
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, &os.PathError{Op: "mmap", Path: f.Name(), Err: err}
+	}
+	return &mmapReader{data: data, unmap: func() error { return syscall.Munmap(data) }}, nil
+}
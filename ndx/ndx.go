@@ -0,0 +1,219 @@
+// Package ndx reads dBASE III and Clipper style .ndx single index files, to
+// find records by key without scanning the table.
+// https://www.clicketyclick.dk/databases/xbase/format/ndx.html
+package ndx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PageSize is the size of the header and of each B-tree node
+const PageSize = 512
+
+var ErrBadHeader error = errors.New("ndx: bad file header")
+var ErrBadPage error = errors.New("ndx: bad index page")
+
+// ErrStop returned by a Walk or Find callback ends it early without error
+var ErrStop error = errors.New("ndx: stop walk")
+
+// Index is an open .ndx
+type Index struct {
+	// Numeric is set for numeric and date keys, stored as float64; dates
+	// are Julian day numbers. Otherwise keys are text.
+	Numeric    bool
+	KeyLength  int
+	Unique     bool
+	Expression string
+
+	r          io.ReaderAt
+	root       uint32
+	itemLength int
+}
+
+// Open reads the header of an .ndx
+func Open(r io.ReaderAt) (*Index, error) {
+	header := make([]byte, PageSize)
+	_, err := r.ReadAt(header, 0)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrBadHeader
+		}
+		return nil, err
+	}
+	x := &Index{
+		root:       binary.LittleEndian.Uint32(header[0:4]),
+		KeyLength:  int(binary.LittleEndian.Uint16(header[12:14])),
+		Numeric:    binary.LittleEndian.Uint16(header[16:18]) != 0,
+		itemLength: int(binary.LittleEndian.Uint16(header[18:20])),
+		Unique:     header[22] != 0,
+		r:          r,
+	}
+	expr := header[24:]
+	if end := bytes.IndexByte(expr, 0); end >= 0 {
+		expr = expr[:end]
+	}
+	x.Expression = strings.TrimSpace(string(expr))
+	if x.KeyLength == 0 || x.itemLength < x.KeyLength+8 || 4+2*x.itemLength > PageSize || (x.Numeric && x.KeyLength != 8) {
+		return nil, ErrBadHeader
+	}
+	return x, nil
+}
+
+// node is one B-tree page
+type node struct {
+	// entries is the number of keys, each after a child page pointer and
+	// a record number; an interior node has one more child pointer after
+	// the last
+	entries int
+	data    []byte
+	leaf    bool
+}
+
+func (x *Index) readNode(page uint32) (*node, error) {
+	data := make([]byte, PageSize)
+	_, err := x.r.ReadAt(data, int64(page)*PageSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	n := &node{entries: int(binary.LittleEndian.Uint32(data[0:4])), data: data}
+	if n.entries < 0 || 4+n.entries*x.itemLength+4 > len(data) {
+		return nil, ErrBadPage
+	}
+	// leaf items point at records, not child pages
+	n.leaf = n.child(x, 0) == 0
+	return n, nil
+}
+
+func (n *node) child(x *Index, i int) uint32 {
+	start := 4 + i*x.itemLength
+	return binary.LittleEndian.Uint32(n.data[start : start+4])
+}
+
+func (n *node) record(x *Index, i int) uint32 {
+	start := 4 + i*x.itemLength + 4
+	return binary.LittleEndian.Uint32(n.data[start : start+4])
+}
+
+func (n *node) key(x *Index, i int) []byte {
+	start := 4 + i*x.itemLength + 8
+	return n.data[start : start+x.KeyLength]
+}
+
+// maxDepth bounds B-tree descent in a corrupt file
+const maxDepth = 64
+
+// Walk calls fn with each key and its record number (1 based, as in the
+// index) in index order. Returning ErrStop from fn ends the walk early.
+func (x *Index) Walk(fn func(key []byte, record uint32) error) error {
+	err := x.walk(x.root, nil, fn, map[uint32]bool{}, 0)
+	if err == ErrStop {
+		return nil
+	}
+	return err
+}
+
+// Find calls fn with the record number (1 based) of each key matching
+// key, in index order, reading only the pages that can hold them. Text
+// keys match by prefix, as dBASE SEEK does with SET EXACT OFF. Numeric
+// keys are the number as text.
+func (x *Index) Find(key string, fn func(record uint32) error) error {
+	want, err := x.searchKey(key)
+	if err != nil {
+		return err
+	}
+	err = x.walk(x.root, want, func(k []byte, record uint32) error {
+		c := x.compare(k, want)
+		if c > 0 {
+			return ErrStop
+		} else if c < 0 {
+			return nil
+		}
+		return fn(record)
+	}, map[uint32]bool{}, 0)
+	if err == ErrStop {
+		return nil
+	}
+	return err
+}
+
+func (x *Index) searchKey(key string) ([]byte, error) {
+	if !x.Numeric {
+		if len(key) > x.KeyLength {
+			return nil, errors.New("ndx: key longer than " + strconv.Itoa(x.KeyLength))
+		}
+		return []byte(key), nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(key), 64)
+	if err != nil {
+		return nil, err
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	return b[:], nil
+}
+
+// compare orders an index key against a search key, text by the prefix
+// the search key covers
+func (x *Index) compare(k, want []byte) int {
+	if !x.Numeric {
+		return bytes.Compare(k[:len(want)], want)
+	}
+	a := math.Float64frombits(binary.LittleEndian.Uint64(k))
+	b := math.Float64frombits(binary.LittleEndian.Uint64(want))
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// walk visits the keys under page in order. With want set it skips child
+// pages whose keys, bounded by the interior key after them, all sort
+// before want. visited holds the pages read so far; a corrupt file whose
+// child pointers lead back to one fails with ErrBadPage.
+func (x *Index) walk(page uint32, want []byte, fn func(key []byte, record uint32) error, visited map[uint32]bool, depth int) error {
+	if depth > maxDepth || visited[page] {
+		return ErrBadPage
+	}
+	visited[page] = true
+	n, err := x.readNode(page)
+	if err != nil {
+		return err
+	}
+	if n.leaf {
+		for i := 0; i < n.entries; i++ {
+			err = fn(n.key(x, i), n.record(x, i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := 0; i <= n.entries; i++ {
+		if want != nil && i < n.entries && x.compare(n.key(x, i), want) < 0 {
+			continue
+		}
+		err = x.walk(n.child(x, i), want, fn, visited, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KeyString is a key as text: text keys without trailing spaces, numeric
+// keys as numbers
+func (x *Index) KeyString(key []byte) string {
+	if !x.Numeric {
+		return strings.TrimRight(string(key), " \x00")
+	}
+	return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(key)), 'f', -1, 64)
+}
@@ -0,0 +1,162 @@
+package ndx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// item is one B-tree entry; an item without a key is the child pointer
+// after the last key of an interior node
+type item struct {
+	child, record uint32
+	key           []byte
+}
+
+// ndxFile builds an index with pages numbered from 1, the root
+func ndxFile(keyLength int, numeric bool, pages ...[]item) []byte {
+	itemLength := (keyLength + 8 + 3) &^ 3
+	data := make([]byte, PageSize*(1+len(pages)))
+	binary.LittleEndian.PutUint32(data[0:4], 1)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(1+len(pages)))
+	binary.LittleEndian.PutUint16(data[12:14], uint16(keyLength))
+	binary.LittleEndian.PutUint16(data[14:16], uint16((PageSize-4)/itemLength))
+	if numeric {
+		binary.LittleEndian.PutUint16(data[16:18], 1)
+	}
+	binary.LittleEndian.PutUint16(data[18:20], uint16(itemLength))
+	copy(data[24:], "NAME")
+	for i, items := range pages {
+		page := data[(i+1)*PageSize : (i+2)*PageSize]
+		entries := 0
+		for j, it := range items {
+			start := 4 + j*itemLength
+			binary.LittleEndian.PutUint32(page[start:], it.child)
+			binary.LittleEndian.PutUint32(page[start+4:], it.record)
+			if it.key != nil {
+				entries++
+				copy(page[start+8:start+8+keyLength], bytes.Repeat([]byte{' '}, keyLength))
+				copy(page[start+8:], it.key)
+			}
+		}
+		binary.LittleEndian.PutUint32(page[0:4], uint32(entries))
+	}
+	return data
+}
+
+func numKey(v float64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	return b[:]
+}
+
+// names is a root over two leaves, records not in key order
+func names() []byte {
+	return ndxFile(6, false,
+		[]item{{child: 2, key: []byte("CHARLI")}, {child: 3}},
+		[]item{{record: 3, key: []byte("ALPHA")}, {record: 1, key: []byte("BRAVO")}, {record: 5, key: []byte("CHARLI")}},
+		[]item{{record: 2, key: []byte("DELTA")}, {record: 4, key: []byte("ECHO")}},
+	)
+}
+
+func open(t *testing.T, data []byte) *Index {
+	t.Helper()
+	x, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+func find(t *testing.T, x *Index, key string) []uint32 {
+	t.Helper()
+	var got []uint32
+	err := x.Find(key, func(record uint32) error {
+		got = append(got, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestWalk(t *testing.T) {
+	x := open(t, names())
+	if x.Numeric || x.KeyLength != 6 || x.Expression != "NAME" {
+		t.Errorf("header %+v", x)
+	}
+	var keys []string
+	var records []uint32
+	err := x.Walk(func(key []byte, record uint32) error {
+		keys = append(keys, x.KeyString(key))
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"ALPHA", "BRAVO", "CHARLI", "DELTA", "ECHO"}) || !reflect.DeepEqual(records, []uint32{3, 1, 5, 2, 4}) {
+		t.Errorf("Walk %v %v", keys, records)
+	}
+}
+
+func TestFind(t *testing.T) {
+	x := open(t, names())
+	for _, c := range []struct {
+		key  string
+		want []uint32
+	}{
+		{"BRAVO", []uint32{1}},
+		{"CH", []uint32{5}},
+		{"DELTA", []uint32{2}},
+		{"E", []uint32{4}},
+		{"", []uint32{3, 1, 5, 2, 4}},
+		{"FOX", nil},
+		{"AB", nil},
+	} {
+		if got := find(t, x, c.key); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Find %q = %v, want %v", c.key, got, c.want)
+		}
+	}
+	if err := x.Find("CHARLIE", func(uint32) error { return nil }); err == nil {
+		t.Error("Find accepted a key longer than the index key")
+	}
+}
+
+func TestFindNumeric(t *testing.T) {
+	x := open(t, ndxFile(8, true,
+		[]item{{record: 2, key: numKey(-1)}, {record: 1, key: numKey(2.5)}, {record: 4, key: numKey(2.5)}, {record: 3, key: numKey(10)}},
+	))
+	if got := find(t, x, " 2.5"); !reflect.DeepEqual(got, []uint32{1, 4}) {
+		t.Errorf("Find 2.5 = %v", got)
+	}
+	if got := find(t, x, "3"); got != nil {
+		t.Errorf("Find 3 = %v", got)
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	// the last child of the root points back at the root
+	data := ndxFile(6, false,
+		[]item{{child: 2, key: []byte("B")}, {child: 1}},
+		[]item{{record: 1, key: []byte("A")}},
+	)
+	x := open(t, data)
+	if err := x.Walk(func([]byte, uint32) error { return nil }); err != ErrBadPage {
+		t.Errorf("Walk = %v, want ErrBadPage", err)
+	}
+
+	// each page has both children the next, 2^30 paths to the leaf
+	var pages [][]item
+	for page := uint32(1); page <= 30; page++ {
+		pages = append(pages, []item{{child: page + 1, key: []byte("B")}, {child: page + 1}})
+	}
+	pages = append(pages, []item{{record: 1, key: []byte("A")}})
+	x = open(t, ndxFile(6, false, pages...))
+	if err := x.Walk(func([]byte, uint32) error { return nil }); err != ErrBadPage {
+		t.Errorf("Walk shared pages = %v, want ErrBadPage", err)
+	}
+}
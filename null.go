@@ -0,0 +1,74 @@
+package dbf
+
+// Visual FoxPro field Flags
+const (
+	// FieldFlagSystem marks a hidden system column such as _NullFlags
+	FieldFlagSystem = 0x01
+	// FieldFlagNullable marks a field that can be null
+	FieldFlagNullable = 0x02
+	// FieldFlagBinary marks character and memo fields not translated
+	// between code pages
+	FieldFlagBinary = 0x04
+	// FieldFlagAutoIncrement marks an autoincrementing integer field
+	FieldFlagAutoIncrement = 0x0c
+)
+
+// nullFlagsField is the hidden Visual FoxPro column of null bits
+const nullFlagsField = "_NullFlags"
+
+// isVarLength is true for the Visual FoxPro varchar and varbinary types,
+// which also take a bit of _NullFlags
+func isVarLength(t DbfFieldType) bool {
+	return t == 'V' || t == 'Q'
+}
+
+// assignNullBits finds the _NullFlags column and numbers the bits of the
+// nullable fields in it, after the header is read
+func (d *Dbf) assignNullBits() {
+	d.nullFlags = nil
+	bit := 0
+	for i := range d.Fields {
+		f := &d.Fields[i]
+		f.nullBit = -1
+		if f.Type == '0' && f.Name == nullFlagsField {
+			d.nullFlags = f
+			continue
+		}
+		if !isVisualFoxPro(d.Version) {
+			continue
+		}
+		if isVarLength(f.Type) {
+			// the full length bit comes first
+			bit++
+		}
+		if f.Flags&FieldFlagNullable != 0 {
+			f.nullBit = bit
+			bit++
+		}
+	}
+}
+
+// IsNull is true if the field has no value in the current row: its bit is
+// set in the _NullFlags column of a Visual FoxPro table, or it is a
+// numeric, date or logical field that is blank (a logical may also hold
+// '?'). Blank character fields are empty strings, not null, unless
+// _NullFlags says so.
+func (h *DbfField) IsNull() bool {
+	d := h.d
+	if d != nil && d.nullFlags != nil && h.nullBit >= 0 {
+		flags := d.nullFlags.raw()
+		byteIndex := h.nullBit / 8
+		if byteIndex < len(flags) {
+			return flags[byteIndex]&(1<<uint(h.nullBit%8)) != 0
+		}
+		return false
+	}
+	switch h.Type {
+	case DbfFieldNumeric, DbfFieldFloat, DbfFieldDate, DbfFieldDateTime:
+		return h.StringValue() == ""
+	case DbfFieldLogical:
+		v := h.StringValue()
+		return v == "" || v == "?"
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package dbf
+
+import "errors"
+
+// Project limits the fields RecordMap, Record.Map, ReadAll, ReadAllValues
+// and the exporters convert to the named ones, in that order, so the
+// others stay untouched bytes in the record:
+//
+//	err := d.Project("STATEFP10", "TRACTCE10")
+//
+// Field values are only decoded when read, so scans that read fields
+// themselves need no projection. Computed columns are still included; an
+// ExportOptions.Fields list overrides the projection. No names removes
+// it. An unknown name fails, leaving the projection as it was.
+func (d *Dbf) Project(names ...string) error {
+	if len(names) == 0 {
+		d.projected = nil
+		return nil
+	}
+	projected := make([]*DbfField, 0, len(names))
+	for _, name := range names {
+		f := d.Field(name)
+		if f == nil {
+			return errors.New("dbf Project of unknown field " + name)
+		}
+		projected = append(projected, f)
+	}
+	d.projected = projected
+	return nil
+}
+
+// Projection is the fields set by Project, nil for all
+func (d *Dbf) Projection() []*DbfField {
+	return d.projected
+}
+
+// readFields are the fields ReadAll reads: the projection, or all fields
+func (d *Dbf) readFields() []*DbfField {
+	if d.projected != nil {
+		return d.projected
+	}
+	fields := make([]*DbfField, len(d.Fields))
+	for i := range d.Fields {
+		fields[i] = &d.Fields[i]
+	}
+	return fields
+}
@@ -0,0 +1,65 @@
+package dbf
+
+import (
+	"io"
+)
+
+// readAllMaxPrealloc bounds the rows ReadAll allocates up front, a header
+// can claim any number of records
+const readAllMaxPrealloc = 1 << 16
+
+// ReadAll reads the remaining records of d into memory, one row of
+// StringValue per record with values in field order, or in the order of a
+// Project. Like Next it returns deleted records too unless d was opened
+// WithSkipDeleted; IsDeleted is not kept, so use WithSkipDeleted, or Next,
+// to tell them apart.
+func (d *Dbf) ReadAll() ([][]string, error) {
+	fields := d.readFields()
+	rows := make([][]string, 0, d.readAllCap())
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return rows, err
+		}
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = f.StringValue()
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ReadAllValues is ReadAll with typed values, see DbfField.Value.
+func (d *Dbf) ReadAllValues() ([][]interface{}, error) {
+	fields := d.readFields()
+	rows := make([][]interface{}, 0, d.readAllCap())
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return rows, err
+		}
+		row := make([]interface{}, len(fields))
+		for i, f := range fields {
+			row[i], err = f.Value()
+			if err != nil {
+				return rows, err
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func (d *Dbf) readAllCap() int64 {
+	n := d.EffectiveRecords() - d.recno - 1
+	if n < 0 {
+		return 0
+	}
+	if n > readAllMaxPrealloc {
+		return readAllMaxPrealloc
+	}
+	return n
+}
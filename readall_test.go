@@ -0,0 +1,34 @@
+package dbf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadAllDeleted(t *testing.T) {
+	table := deletedTable(t, 10)
+	for _, c := range []struct {
+		opts []Option
+		want int
+	}{
+		{nil, 10},
+		{[]Option{WithSkipDeleted()}, 6},
+	} {
+		d, err := NewDbf(bytes.NewReader(table), c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := d.ReadAll()
+		if err != nil || len(rows) != c.want {
+			t.Errorf("ReadAll %d rows, %v, want %d", len(rows), err, c.want)
+		}
+		d, err = NewDbf(bytes.NewReader(table), c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, err := d.ReadAllValues()
+		if err != nil || len(values) != c.want {
+			t.Errorf("ReadAllValues %d rows, %v, want %d", len(values), err, c.want)
+		}
+	}
+}
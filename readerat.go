@@ -0,0 +1,139 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrNoCursor error = errors.New("dbf Cursor needs a Dbf from OpenReaderAt")
+
+// readerAtSource is what OpenReaderAt opened, for Cursor to open again
+type readerAtSource struct {
+	ra   io.ReaderAt
+	size int64
+	opts []Option
+}
+
+// OpenReaderAt reads the table in the first size bytes of ra. The Dbf
+// reads like one from NewDbf, and Cursor opens more over the same ra, so
+// several goroutines can each scan their own range of records:
+//
+//	d, err := dbf.OpenReaderAt(f, size)
+//	...
+//	for i := int64(0); i < n; i++ {
+//		c, err := d.Cursor(i*per, (i+1)*per)
+//		...
+//		go scan(c)
+//	}
+func OpenReaderAt(ra io.ReaderAt, size int64, opts ...Option) (*Dbf, error) {
+	d, err := NewDbf(io.NewSectionReader(ra, 0, size), opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := &readerAtSource{ra: ra, size: size}
+	for _, opt := range opts {
+		// the memory of WithBuffers is d's, each cursor has its own
+		var probe Dbf
+		opt(&probe)
+		if !probe.fixed {
+			s.opts = append(s.opts, opt)
+		}
+	}
+	d.source = s
+	return d, nil
+}
+
+// Cursor is an independent Dbf over records start up to end (0 based, end
+// not included) of a table from OpenReaderAt, to be read by one goroutine
+// while others read theirs; ra must allow concurrent ReadAt calls, as
+// *os.File does. end < 0 reads to the end of the data. The header is not
+// read again: the cursor gets a copy of the fields, aliases and
+// projection of d, shares its attached memo file, and has its own record
+// and read buffers. The other options of OpenReaderAt apply, so computed
+// columns and filters of WithComputed and WithWhere are compiled for the
+// cursor, but ones added to d afterwards are not; header warnings are not
+// logged again.
+func (d *Dbf) Cursor(start, end int64) (*Dbf, error) {
+	if d.source == nil {
+		return nil, ErrNoCursor
+	}
+	if start < 0 {
+		return nil, errors.New("dbf Cursor negative record index")
+	}
+	s := d.source
+	c := &Dbf{reader: io.NewSectionReader(s.ra, 0, s.size), recno: -1, readBufferSize: DefaultReadBufferSize}
+	for _, opt := range s.opts {
+		opt(c)
+	}
+	c.logf = nil
+	c.source = s
+	d.copyHeader(c)
+	c.startBuffering()
+	err := c.seekTo(c.dataStart + start*int64(c.rowWidth+1))
+	if err != nil {
+		return nil, err
+	}
+	c.recno = start - 1
+	err = c.compileDefs()
+	if err != nil {
+		return nil, err
+	}
+	if end >= 0 {
+		c.limited = true
+		c.limit = end
+	}
+	return c, nil
+}
+
+// copyHeader gives c what NewDbf read from the header of d, with fields
+// and a record buffer of its own
+func (d *Dbf) copyHeader(c *Dbf) {
+	c.Version = d.Version
+	c.Year, c.Month, c.Day = d.Year, d.Month, d.Day
+	c.NumRecords = d.NumRecords
+	c.NumHeaderBytes = d.NumHeaderBytes
+	c.NumRecordBytes = d.NumRecordBytes
+	c.Incomplete = d.Incomplete
+	c.Encrypted = d.Encrypted
+	c.Mdx = d.Mdx
+	c.Language = d.Language
+	c.DriverName = d.DriverName
+	c.SizeRecords = d.SizeRecords
+	c.recordLength = d.recordLength
+	c.rowWidth = d.rowWidth
+	c.dataStart = d.dataStart
+	c.anomalies = append([]Anomaly(nil), d.anomalies...)
+	c.decoder = d.decoder
+	c.memo = d.memo
+	c.memoBlockSize = d.memoBlockSize
+
+	c.Fields = append([]DbfField(nil), d.Fields...)
+	for i := range c.Fields {
+		c.Fields[i].d = c
+	}
+	c.assignNullBits()
+	c.recordBuffer = make([]byte, len(d.recordBuffer))
+	for i := c.rowWidth; i < len(c.recordBuffer); i++ {
+		// fields past a short header width read as blank
+		c.recordBuffer[i] = ' '
+	}
+	// aliases and the projection point at the fields
+	moved := make(map[*DbfField]*DbfField, len(d.Fields))
+	for i := range d.Fields {
+		moved[&d.Fields[i]] = &c.Fields[i]
+	}
+	if d.aliases != nil {
+		c.aliases = make(map[string]*DbfField, len(d.aliases))
+		for name, f := range d.aliases {
+			c.aliases[name] = moved[f]
+		}
+	}
+	for _, f := range d.projected {
+		c.projected = append(c.projected, moved[f])
+	}
+}
+
+// atLimit is true once a Cursor has read up to its end
+func (d *Dbf) atLimit() bool {
+	return d.limited && d.recno+1 >= d.limit
+}
@@ -0,0 +1,59 @@
+package dbf
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestCursorsConcurrent(t *testing.T) {
+	table := wideTable(t, 1000)
+	record := make([]byte, 0, 8192)
+	fields := make([]DbfField, 0, 64)
+	d, err := OpenReaderAt(bytes.NewReader(table), int64(len(table)), WithBuffers(record, fields), WithWhere("COUNT >= 70"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.Alias(map[string]string{"COUNT": "N"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const cursors = 4
+	sums := make([]int64, cursors)
+	var wg sync.WaitGroup
+	for i := 0; i < cursors; i++ {
+		c, err := d.Cursor(int64(i*250), int64((i+1)*250))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if &c.Fields[0] == &d.Fields[0] || &c.recordBuffer[0] == &d.recordBuffer[0] {
+			t.Fatal("cursor shares the WithBuffers memory")
+		}
+		wg.Add(1)
+		go func(i int, c *Dbf) {
+			defer wg.Done()
+			n := c.Field("N")
+			for c.Next() == nil {
+				v, err := n.Int64()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				sums[i] += v
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	var total int64
+	for _, s := range sums {
+		total += s
+	}
+	// COUNT is 7 times the record index, the first 10 are filtered out
+	var want int64
+	for r := int64(10); r < 1000; r++ {
+		want += r * 7
+	}
+	if total != want {
+		t.Errorf("cursors sum to %d, want %d", total, want)
+	}
+}
@@ -0,0 +1,80 @@
+package dbf
+
+// RecordMap is the current row keyed by field name, StringValue of each
+// field and the value of each computed column. The hidden Visual FoxPro
+// _NullFlags column is left out, as are fields outside a Project.
+func (d *Dbf) RecordMap() map[string]string {
+	m := make(map[string]string, len(d.Fields)+len(d.computed))
+	if d.projected != nil {
+		for _, f := range d.projected {
+			m[f.Name] = f.StringValue()
+		}
+	} else {
+		for i := range d.Fields {
+			if &d.Fields[i] != d.nullFlags {
+				m[d.Fields[i].Name] = d.Fields[i].StringValue()
+			}
+		}
+	}
+	for _, c := range d.computed {
+		m[c.Name] = c.StringValue()
+	}
+	return m
+}
+
+// Record is the current row of a Records iteration, valid until the
+// iteration moves on, or a row read by NextN, which keeps its own bytes
+type Record struct {
+	d *Dbf
+	// row is the record after the flag from NextN, nil for the current row
+	row   []byte
+	flag  byte
+	recno int64
+}
+
+// load makes a NextN record the current row of the Dbf so field values
+// read it. The record index of the Dbf is left alone.
+func (r Record) load() {
+	if r.row != nil {
+		r.d.setRow(r.row)
+		r.d.flag[0] = r.flag
+	}
+}
+
+// Index is the 0 based index of the record in the table
+func (r Record) Index() int64 {
+	if r.row != nil {
+		return r.recno
+	}
+	return r.d.recno
+}
+
+// IsDeleted is true if the record is marked deleted
+func (r Record) IsDeleted() bool {
+	if r.row != nil {
+		return r.flag == '*'
+	}
+	return r.d.IsDeleted()
+}
+
+// Get is the value of the field or computed column name, "" if there is
+// none. Get, Map and Scan of a record from NextN load it into the current
+// row of the Dbf, so DbfField values then read it too.
+func (r Record) Get(name string) string {
+	r.load()
+	if f := r.d.Field(name); f != nil {
+		return f.StringValue()
+	}
+	for _, c := range r.d.computed {
+		if c.Name == name {
+			return c.StringValue()
+		}
+	}
+	return ""
+}
+
+// Map is the record keyed by field name, see Dbf.RecordMap
+func (r Record) Map() map[string]string {
+	r.load()
+	return r.d.RecordMap()
+}
@@ -0,0 +1,36 @@
+//go:build go1.23
+
+package dbf
+
+import (
+	"io"
+	"iter"
+)
+
+// Records iterates over the remaining records of d:
+//
+//	for rec, err := range d.Records() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(rec.Get("NAME"))
+//	}
+//
+// The iteration ends at the end of the data, without an error, or after
+// yielding the first error Next returns.
+func (d *Dbf) Records() iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for {
+			err := d.Next()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				yield(Record{d: d}, err)
+				return
+			}
+			if !yield(Record{d: d}, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package dbf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// RedactAction is what a Redaction does to a column
+type RedactAction int
+
+const (
+	// RedactDrop leaves the column out of the export
+	RedactDrop RedactAction = iota
+
+	// RedactHash replaces values with a hex HMAC-SHA256 under Redaction.Key,
+	// so equal values still match but can't be recovered without the key
+	RedactHash
+
+	// RedactMask replaces all but the last Redaction.Keep characters with '*'
+	RedactMask
+)
+
+func (a RedactAction) String() string {
+	switch a {
+	case RedactDrop:
+		return "drop"
+	case RedactHash:
+		return "hash"
+	case RedactMask:
+		return "mask"
+	default:
+		return "RedactAction(" + strconv.Itoa(int(a)) + ")"
+	}
+}
+
+// Redaction transforms one named column on export, see ExportOptions.Redact
+type Redaction struct {
+	Field  string
+	Action RedactAction
+
+	// Key is the HMAC key for RedactHash
+	Key []byte
+
+	// Keep is how many trailing characters RedactMask leaves visible
+	Keep int
+}
+
+// KeyID identifies the key in audit descriptions without revealing it
+func (r Redaction) KeyID() string {
+	sum := sha256.Sum256(r.Key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// String describes the transform, e.g. "OWNER: hash hmac-sha256 key 1a2b3c4d"
+func (r Redaction) String() string {
+	switch r.Action {
+	case RedactHash:
+		return r.Field + ": hash hmac-sha256 key " + r.KeyID()
+	case RedactMask:
+		return r.Field + ": mask keep last " + strconv.Itoa(r.Keep)
+	default:
+		return r.Field + ": " + r.Action.String()
+	}
+}
+
+// DescribeRedactions is an auditable record of the transforms applied by
+// an export, one line per redacted column, to publish alongside the data.
+func DescribeRedactions(redactions []Redaction) string {
+	var sb strings.Builder
+	for _, r := range redactions {
+		sb.WriteString(r.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (r *Redaction) apply(v string) string {
+	switch r.Action {
+	case RedactHash:
+		mac := hmac.New(sha256.New, r.Key)
+		mac.Write([]byte(v))
+		return hex.EncodeToString(mac.Sum(nil))
+	case RedactMask:
+		runes := []rune(v)
+		for i := 0; i < len(runes)-r.Keep; i++ {
+			runes[i] = '*'
+		}
+		return string(runes)
+	}
+	return v
+}
+
+// exportColumn is one output column of an export. For a computed column
+// field only carries its name and type.
+type exportColumn struct {
+	field    *DbfField
+	redact   *Redaction
+	computed *ComputedColumn
+}
+
+func (c *exportColumn) value() string {
+	var v string
+	if c.computed != nil {
+		v = c.computed.StringValue()
+	} else {
+		v = c.field.StringValue()
+	}
+	if c.redact != nil {
+		v = c.redact.apply(v)
+	}
+	return v
+}
+
+// isNull is true if the column is a field whose Value is nil
+func (c *exportColumn) isNull() bool {
+	if c.computed != nil || c.redact != nil {
+		return false
+	}
+	v, err := c.field.Value()
+	return err == nil && v == nil
+}
+
+// exportColumns lists the fields and computed columns of d to export with
+// any redactions applied, only the projected fields after Project
+func exportColumns(d *Dbf, opts *ExportOptions) ([]exportColumn, error) {
+	var redactions []Redaction
+	if opts != nil {
+		redactions = opts.Redact
+	}
+	byField := make(map[string]*Redaction, len(redactions))
+	for i := range redactions {
+		r := &redactions[i]
+		if r.Action < RedactDrop || r.Action > RedactMask {
+			return nil, errors.New("dbf unknown redaction for field " + r.Field + ": " + r.Action.String())
+		}
+		if r.Action == RedactHash && len(r.Key) == 0 {
+			return nil, errors.New("dbf redaction hash needs a Key for field " + r.Field)
+		}
+		byField[r.Field] = r
+	}
+	cols := make([]exportColumn, 0, len(d.Fields))
+	for i := range d.Fields {
+		f := &d.Fields[i]
+		if f == d.nullFlags {
+			continue
+		}
+		r := byField[f.Name]
+		delete(byField, f.Name)
+		if r != nil && r.Action == RedactDrop {
+			continue
+		}
+		cols = append(cols, exportColumn{f, r, nil})
+	}
+	for _, c := range d.computed {
+		r := byField[c.Name]
+		delete(byField, c.Name)
+		if r != nil && r.Action == RedactDrop {
+			continue
+		}
+		cols = append(cols, exportColumn{&DbfField{Name: c.Name, Type: c.Type}, r, c})
+	}
+	for name := range byField {
+		return nil, errors.New("dbf redaction for unknown field " + name)
+	}
+	if opts != nil && len(opts.Fields) != 0 {
+		return selectColumns(d, cols, opts.Fields)
+	}
+	if d.projected != nil {
+		names := make([]string, 0, len(d.projected)+len(d.computed))
+		for _, f := range d.projected {
+			names = append(names, f.Name)
+		}
+		for _, c := range d.computed {
+			names = append(names, c.Name)
+		}
+		return selectColumns(d, cols, names)
+	}
+	return cols, nil
+}
+
+// selectColumns picks the named columns out of cols in the order named.
+// A column dropped by a redaction is left out; a name d does not have is
+// an error.
+func selectColumns(d *Dbf, cols []exportColumn, names []string) ([]exportColumn, error) {
+	byName := make(map[string]int, len(cols))
+	for i, c := range cols {
+		byName[c.field.Name] = i
+	}
+	selected := make([]exportColumn, 0, len(names))
+	for _, name := range names {
+		i, ok := byName[name]
+		if !ok {
+			if f := d.aliases[name]; f != nil {
+				i, ok = byName[f.Name]
+			}
+		}
+		if ok {
+			selected = append(selected, cols[i])
+		} else if !d.hasColumn(name) {
+			return nil, errors.New("dbf export of unknown field " + name)
+		}
+	}
+	return selected, nil
+}
+
+// hasColumn is true if d has a field or computed column named name
+func (d *Dbf) hasColumn(name string) bool {
+	if d.Field(name) != nil {
+		return true
+	}
+	for _, c := range d.computed {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package dbf
+
+import (
+	"io"
+	"strings"
+)
+
+// Gap is a span of corrupt data skipped by WithResync.
+type Gap struct {
+	// Offset is the file position of the first bad byte
+	Offset int64
+	// Length is the number of bytes skipped
+	Length int64
+	// Record is the index the next good record gets
+	Record int64
+}
+
+// resyncConfirm is how many following record flags must look right to accept a record start
+const resyncConfirm = 3
+
+// WithResync recovers from a corrupt record: when a record does not start
+// with a valid deletion flag, the reader scans forward for the next
+// position where flag bytes line up at record-length strides and
+// continues from there. The skipped spans are available from Gaps.
+func WithResync() Option {
+	return func(d *Dbf) {
+		d.resync = true
+	}
+}
+
+// Gaps lists the corrupt spans skipped so far with WithResync
+func (d *Dbf) Gaps() []Gap {
+	return d.gaps
+}
+
+// plausibleRecord checks that numeric, date and logical fields hold
+// characters they could hold. Space padding makes flag bytes alone
+// ambiguous, this rejects most starts in the middle of a record.
+func (d *Dbf) plausibleRecord(rec []byte) bool {
+	for _, f := range d.Fields {
+		end := f.StartPos + f.Width
+		if end > len(rec) {
+			end = len(rec)
+		}
+		if end <= f.StartPos {
+			// past a short header width
+			continue
+		}
+		var ok string
+		switch f.Type {
+		case DbfFieldNumeric, DbfFieldFloat:
+			ok = "0123456789 +-.eE*"
+		case DbfFieldDate:
+			ok = "0123456789 "
+		case DbfFieldLogical:
+			ok = "TtFfYyNn? "
+		default:
+			continue
+		}
+		// blank padding before and/or after, but not within the value
+		state := 0
+		for _, c := range rec[f.StartPos:end] {
+			if strings.IndexByte(ok, c) < 0 {
+				return false
+			}
+			if c == ' ' {
+				if state == 1 {
+					state = 2
+				}
+			} else if state == 2 {
+				return false
+			} else {
+				state = 1
+			}
+		}
+	}
+	return true
+}
+
+// byteClass buckets bytes for the resync column profile
+func byteClass(c byte) byte {
+	switch {
+	case c == ' ':
+		return 1
+	case '0' <= c && c <= '9':
+		return 2
+	case ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z'):
+		return 4
+	}
+	return 8
+}
+
+// resyncProfileRecords is how many good records are learned from
+const resyncProfileRecords = 1000
+
+// learnProfile notes which byte classes appear in each column of good records
+func (d *Dbf) learnProfile(rec []byte) {
+	if d.profiled >= resyncProfileRecords {
+		return
+	}
+	if d.profile == nil {
+		d.profile = make([]byte, len(rec))
+	}
+	for i, c := range rec {
+		d.profile[i] |= byteClass(c)
+	}
+	d.profiled++
+}
+
+// profileMisses counts the bytes of rec of a class not seen in that column before
+func (d *Dbf) profileMisses(rec []byte) int {
+	if d.profiled == 0 {
+		return 0
+	}
+	misses := 0
+	for i, c := range rec {
+		if d.profile[i]&byteClass(c) == 0 {
+			misses++
+		}
+	}
+	return misses
+}
+
+// plausibleStart checks that a record could start at buf[o], with flag
+// bytes (or the 0x1a end marker) at the following strides and sensible
+// field contents in between. misses scores how unlike the good records
+// before they are.
+func (d *Dbf) plausibleStart(buf []byte, o, stride int, eof bool) (ok bool, misses int) {
+	if buf[o] == 0x1a {
+		return eof && o == len(buf)-1, 0
+	}
+	if !isRecordFlag(buf[o]) {
+		return false, 0
+	}
+	if eof && o+stride > len(buf) {
+		// not enough left for a whole record
+		return false, 0
+	}
+	for k := 1; k <= resyncConfirm; k++ {
+		q := o + k*stride
+		if q > len(buf) {
+			return eof, misses
+		}
+		rec := buf[q-stride+1 : q]
+		if !d.plausibleRecord(rec) {
+			return false, 0
+		}
+		misses += d.profileMisses(rec)
+		if q == len(buf) || buf[q] == 0x1a {
+			return eof, misses
+		}
+		if !isRecordFlag(buf[q]) {
+			return false, 0
+		}
+	}
+	return true, misses
+}
+
+// resyncAfter scans forward from a bad flag byte just read and leaves the
+// input positioned at the next plausible record start.
+func (d *Dbf) resyncAfter(bad byte) error {
+	stride := d.rowWidth + 1
+	gap := Gap{Offset: d.pos - 1, Record: d.recno + 1}
+	buf := []byte{bad}
+	chunk := make([]byte, stride*8)
+	from := 1
+	for {
+		n, err := d.readFull(chunk)
+		buf = append(buf, chunk[:n]...)
+		eof := false
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			eof = true
+		} else if err != nil {
+			return err
+		}
+		// take the start whose records look most like the good ones before
+		start := -1
+		bestMisses := 0
+		for o := from; o < len(buf); o++ {
+			ok, misses := d.plausibleStart(buf, o, stride, eof)
+			if ok && (start < 0 || misses < bestMisses) {
+				start = o
+				bestMisses = misses
+			}
+		}
+		if start >= 0 {
+			gap.Length += int64(start)
+			d.unreadBytes(buf[start:])
+			d.addGap(gap)
+			return nil
+		}
+		if eof {
+			// nothing good left
+			gap.Length += int64(len(buf))
+			d.addGap(gap)
+			d.atEnd()
+			return io.EOF
+		}
+		// keep the tail that could still start a record once more is read
+		keep := resyncConfirm * stride
+		if len(buf) > keep {
+			gap.Length += int64(len(buf) - keep)
+			buf = append(buf[:0], buf[len(buf)-keep:]...)
+		}
+		from = 0
+	}
+}
+
+func (d *Dbf) addGap(gap Gap) {
+	d.gaps = append(d.gaps, gap)
+	if d.logf != nil {
+		d.logf("skipped %d corrupt bytes at offset %d before record %d", gap.Length, gap.Offset, gap.Record)
+	}
+}
@@ -0,0 +1,219 @@
+//go:build !tinygo
+// +build !tinygo
+
+package dbf
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scanTarget is a struct field Scan fills and where its value comes from
+type scanTarget struct {
+	index    []int
+	field    *DbfField
+	computed *ComputedColumn
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// scanCache has the scanTargets of each struct type Scan has filled. It is
+// kept out of the Dbf so that tinygo builds, which leave out Scan, have
+// no reflection in the core type.
+type scanCache struct {
+	plans map[reflect.Type][]scanTarget
+}
+
+// Scan copies the current row into the struct dest points to. A struct
+// field tagged `dbf:"NAME"` gets the field or computed column NAME, an
+// untagged one the field matching its name case insensitively, and one
+// tagged `dbf:"-"` is left alone. Values are converted to the struct
+// field type: string, integers, floats, bool, time.Time or a pointer to
+// one of those, which is set to nil for a blank value. A tag naming a
+// field the table does not have is an error; a value that does not
+// convert fails with a *ParseError. Scan uses reflection and is left out
+// of tinygo builds.
+func (d *Dbf) Scan(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("dbf Scan needs a pointer to a struct")
+	}
+	v = v.Elem()
+	targets, err := d.scanTargets(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		err = d.scanValue(&t, v.FieldByIndex(t.index))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan copies the record into a struct, see Dbf.Scan
+func (r Record) Scan(dest interface{}) error {
+	r.load()
+	return r.d.Scan(dest)
+}
+
+// scanTargets maps the fields of struct type t to the table, cached
+func (d *Dbf) scanTargets(t reflect.Type) ([]scanTarget, error) {
+	if d.scan != nil {
+		if targets, ok := d.scan.plans[t]; ok {
+			return targets, nil
+		}
+	}
+	var targets []scanTarget
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name, tagged := sf.Tag.Lookup("dbf")
+		if name == "-" {
+			continue
+		}
+		target := scanTarget{index: sf.Index}
+		if tagged {
+			target.field = d.Field(name)
+		} else {
+			for fi := range d.Fields {
+				if strings.EqualFold(d.Fields[fi].Name, sf.Name) {
+					target.field = &d.Fields[fi]
+					break
+				}
+			}
+		}
+		if target.field == nil {
+			for _, c := range d.computed {
+				if c.Name == name || (!tagged && strings.EqualFold(c.Name, sf.Name)) {
+					target.computed = c
+					break
+				}
+			}
+		}
+		if target.field == nil && target.computed == nil {
+			if tagged {
+				return nil, errors.New("dbf Scan no field " + name + " for " + t.Name() + "." + sf.Name)
+			}
+			continue
+		}
+		targets = append(targets, target)
+	}
+	if d.scan == nil {
+		d.scan = &scanCache{plans: make(map[reflect.Type][]scanTarget)}
+	}
+	d.scan.plans[t] = targets
+	return targets, nil
+}
+
+// scanValue converts the target's value into dest
+func (d *Dbf) scanValue(t *scanTarget, dest reflect.Value) error {
+	var s string
+	if t.field != nil {
+		s = t.field.StringValue()
+	} else {
+		s = t.computed.StringValue()
+	}
+	fail := func(err error) error {
+		if t.field != nil {
+			return t.field.parseError(err)
+		}
+		return &ParseError{Offset: d.recordPos, Record: d.recno, Field: t.computed.Name, Err: err}
+	}
+	if dest.Kind() == reflect.Ptr {
+		if s == "" || (s == "?" && t.field != nil && t.field.Type == DbfFieldLogical) {
+			dest.Set(reflect.Zero(dest.Type()))
+			return nil
+		}
+		if dest.IsNil() {
+			dest.Set(reflect.New(dest.Type().Elem()))
+		}
+		dest = dest.Elem()
+	}
+	if dest.Type() == timeType {
+		var tv time.Time
+		var err error
+		if t.field != nil {
+			tv, err = t.field.DateValue()
+		} else if s != "" {
+			err = &DateFormatError{t.computed.Name, s}
+			for _, layout := range DefaultDateLayouts {
+				if parsed, perr := time.Parse(layout, s); perr == nil {
+					tv, err = parsed, nil
+					break
+				}
+			}
+		}
+		if err != nil {
+			if t.computed != nil {
+				return fail(err)
+			}
+			// DateValue errors are already a *ParseError
+			return err
+		}
+		dest.Set(reflect.ValueOf(tv))
+		return nil
+	}
+	switch dest.Kind() {
+	case reflect.String:
+		dest.SetString(s)
+	case reflect.Bool:
+		if t.field != nil && t.field.Type == DbfFieldLogical {
+			b, err := t.field.Bool()
+			if err != nil && err != ErrUnsetLogical {
+				return err
+			}
+			dest.SetBool(b)
+			return nil
+		}
+		if s == "" {
+			dest.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fail(err)
+		}
+		dest.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			dest.SetInt(0)
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, dest.Type().Bits())
+		if err != nil {
+			return fail(err)
+		}
+		dest.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			dest.SetUint(0)
+			return nil
+		}
+		u, err := strconv.ParseUint(s, 10, dest.Type().Bits())
+		if err != nil {
+			return fail(err)
+		}
+		dest.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			dest.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(s, dest.Type().Bits())
+		if err != nil {
+			return fail(err)
+		}
+		dest.SetFloat(f)
+	default:
+		return errors.New("dbf Scan unsupported type " + dest.Type().String())
+	}
+	return nil
+}
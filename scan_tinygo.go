@@ -0,0 +1,8 @@
+//go:build tinygo
+// +build tinygo
+
+package dbf
+
+// scanCache is empty in tinygo builds, which leave out Scan and its
+// reflection
+type scanCache struct{}
@@ -0,0 +1,286 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// fieldText is a field value for copying to another table: character
+// fields keep leading spaces, other types are trimmed.
+func fieldText(f *DbfField) string {
+	if f.Type == DbfFieldChar {
+		return strings.TrimRight(string(f.raw()), " \x00")
+	}
+	return f.StringValue()
+}
+
+var (
+	goString = reflect.TypeOf("")
+	goInt64  = reflect.TypeOf(int64(0))
+	goFloat  = reflect.TypeOf(float64(0))
+	goBool   = reflect.TypeOf(false)
+	goTime   = reflect.TypeOf(time.Time{})
+	goBytes  = reflect.TypeOf([]byte(nil))
+)
+
+// GoType is the type of the values Value returns for the field: string
+// for C, int64 for N without decimals and I, float64 for other N, F, B
+// and Y, bool for L, time.Time for D and T, []byte for memo fields once
+// AttachMemo is called, and string for anything else.
+func (h *DbfField) GoType() reflect.Type {
+	if h.isMemo() {
+		if h.d != nil && h.d.memo != nil {
+			return goBytes
+		}
+		return goString
+	}
+	switch h.Type {
+	case DbfFieldNumeric:
+		if h.DecimalCount() == 0 {
+			return goInt64
+		}
+		return goFloat
+	case DbfFieldInteger:
+		return goInt64
+	case DbfFieldFloat, DbfFieldDouble, DbfFieldCurrency:
+		return goFloat
+	case DbfFieldLogical:
+		return goBool
+	case DbfFieldDate, DbfFieldDateTime:
+		return goTime
+	}
+	return goString
+}
+
+// GoKind is the reflect.Kind of GoType, reflect.Struct for dates
+func (h *DbfField) GoKind() reflect.Kind {
+	return h.GoType().Kind()
+}
+
+// Nullable is true if Value can be nil for the field: N, F, D, T and L
+// fields when blank, and any field with a bit in _NullFlags
+func (h *DbfField) Nullable() bool {
+	if h.nullBit >= 0 && h.d != nil && h.d.nullFlags != nil {
+		return true
+	}
+	switch h.Type {
+	case DbfFieldNumeric, DbfFieldFloat, DbfFieldDate, DbfFieldDateTime, DbfFieldLogical:
+		return !h.isMemo()
+	}
+	return false
+}
+
+// ColumnSchema describes one column for exporters and code generators
+type ColumnSchema struct {
+	Name string `json:"name"`
+	// Type is the dbf field type letter
+	Type     string `json:"type"`
+	Width    int    `json:"width"`
+	Decimals int    `json:"decimals"`
+	// GoType is the type Value returns, as GoType().String(), e.g. "int64"
+	// or "time.Time"
+	GoType   string `json:"go_type"`
+	Nullable bool   `json:"nullable"`
+	// Computed is set for computed columns, which have no width
+	Computed bool `json:"computed,omitempty"`
+}
+
+// Schema describes the columns of d as Value and the exporters see them:
+// the fields, without the hidden _NullFlags, and then the computed
+// columns, whose values are float64 if numeric, else string.
+func (d *Dbf) Schema() []ColumnSchema {
+	schema := make([]ColumnSchema, 0, len(d.Fields)+len(d.computed))
+	for i := range d.Fields {
+		f := &d.Fields[i]
+		if f == d.nullFlags {
+			continue
+		}
+		schema = append(schema, ColumnSchema{
+			Name:     f.Name,
+			Type:     string(rune(f.Type)),
+			Width:    f.Width,
+			Decimals: f.DecimalCount(),
+			GoType:   f.GoType().String(),
+			Nullable: f.Nullable(),
+		})
+	}
+	for _, c := range d.computed {
+		goType := goString
+		if c.Type == DbfFieldNumeric {
+			goType = goFloat
+		}
+		schema = append(schema, ColumnSchema{
+			Name:     c.Name,
+			Type:     string(rune(c.Type)),
+			GoType:   goType.String(),
+			Computed: true,
+		})
+	}
+	return schema
+}
+
+// fieldExtent is what SuggestSchema has seen of one field
+type fieldExtent struct {
+	length   int
+	intPart  int
+	decimals int
+	numeric  bool
+}
+
+func (e *fieldExtent) observe(f *DbfField) {
+	v := fieldText(f)
+	if len(v) > e.length {
+		e.length = len(v)
+	}
+	if !isNumericType(f.Type) || !e.numeric || v == "" {
+		return
+	}
+	intPart, decimals := v, ""
+	if dot := strings.IndexByte(v, '.'); dot >= 0 {
+		intPart, decimals = v[:dot], v[dot+1:]
+	}
+	digits := strings.TrimPrefix(intPart, "-")
+	if strings.Trim(digits, "0123456789") != "" || strings.Trim(decimals, "0123456789") != "" {
+		// not a plain number, leave the field alone
+		e.numeric = false
+		return
+	}
+	if len(intPart) > e.intPart {
+		e.intPart = len(intPart)
+	}
+	if len(decimals) > e.decimals {
+		e.decimals = len(decimals)
+	}
+}
+
+// SuggestSchema scans the remaining records of d, deleted ones included,
+// and returns its fields narrowed to the widths the data needs: character
+// fields to the longest value, N and F fields without exponents to the
+// most integer digits and decimals seen. Other field types are returned
+// unchanged. The result is ready for NewWriter or Rewrite.
+func SuggestSchema(d *Dbf) ([]DbfField, error) {
+	extents := newFieldExtents(len(d.Fields))
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		for i := range d.Fields {
+			extents[i].observe(&d.Fields[i])
+		}
+	}
+	return tightFields(d.Fields, extents), nil
+}
+
+func newFieldExtents(n int) []fieldExtent {
+	extents := make([]fieldExtent, n)
+	for i := range extents {
+		extents[i].numeric = true
+	}
+	return extents
+}
+
+// tightFields narrows fields to what their extents need
+func tightFields(fields []DbfField, extents []fieldExtent) []DbfField {
+	out := make([]DbfField, len(fields))
+	startPos := 0
+	for i, f := range fields {
+		e := &extents[i]
+		f.d = nil
+		switch {
+		case f.Type == DbfFieldChar:
+			f.Width = e.length
+		case isNumericType(f.Type) && e.numeric:
+			if e.decimals > int(f.Count) {
+				e.decimals = int(f.Count)
+			}
+			f.Width = e.intPart
+			if e.decimals > 0 {
+				f.Width += 1 + e.decimals
+			}
+			f.Count = uint8(e.decimals)
+		}
+		if f.Width < 1 {
+			f.Width = 1
+		}
+		f.Length = uint8(f.Width)
+		if f.Type == DbfFieldChar {
+			f.Count = uint8(f.Width >> 8)
+		}
+		f.StartPos = startPos
+		startPos += f.Width
+		out[i] = f
+	}
+	return out
+}
+
+// Rewrite copies the remaining records of d that Next returns, deleted
+// ones included unless WithSkipDeleted, to a new table on w with fields of the same names and types but possibly
+// different widths, as from SuggestSchema. A value that does not fit
+// fails with a *ParseError wrapping a *ValueTooLongError. The record
+// count in the new header is the number of records copied: patched in at
+// Close on an io.WriteSeeker, otherwise known up front unless
+// WithSkipDeleted or a filter passes over records, when the new table is
+// held in memory until it is complete. The new table keeps the version,
+// as WriterVersion, and the Language byte of d.
+func Rewrite(d *Dbf, w io.Writer, fields []DbfField) error {
+	if len(fields) != len(d.Fields) {
+		return errors.New("dbf Rewrite needs one field per source field")
+	}
+	for i := range fields {
+		if fields[i].Name != d.Fields[i].Name || fields[i].Type != d.Fields[i].Type {
+			return errors.New("dbf Rewrite field " + fields[i].Name + " does not match source field " + d.Fields[i].Name)
+		}
+	}
+	return copyTable(d, w, fields, nil)
+}
+
+// copyTable writes the remaining records of d to w with new fields, one
+// per source field, in the version (see WriterVersion) and code page of
+// d. convert, if not nil, may change each value.
+func copyTable(d *Dbf, w io.Writer, fields []DbfField, convert func(i int, v string) (string, error)) error {
+	out, err := NewWriter(w, fields, WithTableVersion(WriterVersion(d.Version)))
+	if err != nil {
+		return err
+	}
+	out.Language = d.Language
+	if n := d.EffectiveRecords() - (d.recno + 1); n >= 0 {
+		out.NumRecords = uint32(n)
+	}
+	out.countAtClose = !d.readsAll()
+	values := make([]string, len(fields))
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		for i := range d.Fields {
+			values[i] = fieldText(&d.Fields[i])
+			if convert != nil {
+				values[i], err = convert(i, values[i])
+				if err != nil {
+					return d.Fields[i].parseError(err)
+				}
+			}
+		}
+		err = out.writeValues(values, d.IsDeleted())
+		if tooLong, ok := err.(*ValueTooLongError); ok {
+			for i := range fields {
+				if fields[i].Name == tooLong.Field {
+					return d.Fields[i].parseError(err)
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
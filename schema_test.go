@@ -0,0 +1,46 @@
+package dbf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRewriteSkipDeleted(t *testing.T) {
+	d, err := NewDbf(bytes.NewReader(deletedTable(t, 10)), WithSkipDeleted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = Rewrite(d, &buf, d.Fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCount(t, buf.Bytes(), 6)
+}
+
+func TestRewriteVisualFoxPro(t *testing.T) {
+	rows := []string{" ab   \x07\x00\x00\x00", " cdef \xf9\xff\xff\xff"}
+	table := vfpTable([]fuzzField{{"NAME", 'C', 5, 0}, {"N", 'I', 4, 0}}, rows)
+	table[29] = 0x03
+	d, err := NewDbf(bytes.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := SuggestSchema(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = NewDbf(bytes.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = Rewrite(d, &buf, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIntegers(t, buf.Bytes(), 7, -7)
+	if language := buf.Bytes()[29]; language != 0x03 {
+		t.Errorf("language %#x, want 0x03", language)
+	}
+}
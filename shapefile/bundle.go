@@ -0,0 +1,115 @@
+// Package shapefile works on whole shapefile bundles: the .shp geometry,
+// .shx index and .dbf attributes that share a base name, plus the
+// optional .prj and .cpg sidecars.
+package shapefile
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dbf "github.com/brianolson/go-dbf"
+)
+
+// Bundle has the paths of the files of one shapefile, "" for a missing optional file.
+type Bundle struct {
+	Shp string
+	Shx string
+	Dbf string
+	Prj string
+	Cpg string
+}
+
+// sidecars are copied unchanged to derived bundles
+var sidecars = []string{".prj", ".cpg"}
+
+// Find locates the files of a shapefile from a base path, with or without
+// a .shp/.dbf extension. Extensions match in any case. .shp, .shx and
+// .dbf are required.
+func Find(base string) (*Bundle, error) {
+	ext := filepath.Ext(base)
+	switch strings.ToLower(ext) {
+	case ".shp", ".shx", ".dbf", ".prj", ".cpg":
+		base = base[:len(base)-len(ext)]
+	}
+	dir := filepath.Dir(base)
+	name := filepath.Base(base)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{}
+	for _, entry := range entries {
+		fname := entry.Name()
+		fext := filepath.Ext(fname)
+		if fname[:len(fname)-len(fext)] != name {
+			continue
+		}
+		path := filepath.Join(dir, fname)
+		switch strings.ToLower(fext) {
+		case ".shp":
+			b.Shp = path
+		case ".shx":
+			b.Shx = path
+		case ".dbf":
+			b.Dbf = path
+		case ".prj":
+			b.Prj = path
+		case ".cpg":
+			b.Cpg = path
+		}
+	}
+	if b.Shp == "" || b.Shx == "" || b.Dbf == "" {
+		return nil, errors.New("shapefile: " + base + " needs .shp, .shx and .dbf")
+	}
+	return b, nil
+}
+
+// OpenDbf opens the attribute table
+func (b *Bundle) OpenDbf() (*dbf.Dbf, error) {
+	f, err := os.Open(b.Dbf)
+	if err != nil {
+		return nil, err
+	}
+	d, err := dbf.NewDbf(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// copySidecars copies .prj and .cpg next to outBase
+func (b *Bundle) copySidecars(outBase string) error {
+	for i, path := range []string{b.Prj, b.Cpg} {
+		if path == "" {
+			continue
+		}
+		err := copyFile(path, outBase+sidecars[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
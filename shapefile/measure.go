@@ -0,0 +1,156 @@
+package shapefile
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/shp"
+)
+
+// GeometryColumns names the numeric columns AddGeometryAttributes appends, "" to leave one out.
+type GeometryColumns struct {
+	// Area is planar polygon area in the units of the coordinates squared
+	Area string
+	// Length is planar length, the perimeter for polygons
+	Length string
+	// Vertices is the point count
+	Vertices string
+}
+
+// DefaultGeometryColumns are used by AddGeometryAttributes when columns is nil
+var DefaultGeometryColumns = GeometryColumns{Area: "SHP_AREA", Length: "SHP_LEN", Vertices: "SHP_NVERT"}
+
+const measureWidth = 19
+const measureDecimals = 8
+
+// formatMeasure fits v into a numeric field, with fewer decimals if it must
+func formatMeasure(v float64, width int) string {
+	for decimals := measureDecimals; decimals >= 0; decimals-- {
+		s := strconv.FormatFloat(v, 'f', decimals, 64)
+		if len(s) <= width {
+			return s
+		}
+	}
+	return "*"
+}
+
+// AddGeometryAttributes writes a new bundle at outBase with the geometry
+// of in unchanged and the attribute table extended by per-feature area,
+// length and vertex count computed from the .shp, e.g. to compare ALAND
+// against the polygon area. columns may be nil for DefaultGeometryColumns.
+// The table keeps the version of the input, see dbf.WriterVersion.
+func AddGeometryAttributes(in *Bundle, outBase string, columns *GeometryColumns) (int, error) {
+	if columns == nil {
+		columns = &DefaultGeometryColumns
+	}
+	d, err := in.OpenDbf()
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+	shpFile, err := os.Open(in.Shp)
+	if err != nil {
+		return 0, err
+	}
+	defer shpFile.Close()
+	sr, err := shp.NewReader(bufio.NewReader(shpFile))
+	if err != nil {
+		return 0, err
+	}
+
+	fields := append([]dbf.DbfField(nil), d.Fields...)
+	var added []string
+	for _, name := range []string{columns.Area, columns.Length, columns.Vertices} {
+		if name == "" {
+			continue
+		}
+		field := dbf.DbfField{Name: name, Type: dbf.DbfFieldNumeric, Length: measureWidth, Count: measureDecimals}
+		if name == columns.Vertices {
+			field.Length = 10
+			field.Count = 0
+		}
+		fields = append(fields, field)
+		added = append(added, name)
+	}
+
+	dbfOut, err := os.Create(outBase + ".dbf")
+	if err != nil {
+		return 0, err
+	}
+	defer dbfOut.Close()
+	bw := bufio.NewWriter(dbfOut)
+	w, err := dbf.NewWriter(bw, fields, dbf.WithTableVersion(dbf.WriterVersion(d.Version)))
+	if err != nil {
+		return 0, err
+	}
+	w.NumRecords = uint32(d.EffectiveRecords())
+	w.Language = d.Language
+	var record []byte
+	count := 0
+	for {
+		rec, err := sr.Next()
+		derr := d.Next()
+		if err == io.EOF && derr == io.EOF {
+			break
+		} else if err == io.EOF || derr == io.EOF {
+			return count, errors.New("shapefile: " + in.Shp + " and " + in.Dbf + " have different record counts")
+		} else if err != nil {
+			return count, err
+		} else if derr != nil {
+			return count, derr
+		}
+		parts, err := rec.Parts()
+		if err != nil {
+			return count, err
+		}
+		// original fields unchanged, then the new ones right aligned
+		record = append(record[:0], d.RawRecord()...)
+		for i, name := range added {
+			f := &fields[len(d.Fields)+i]
+			var value string
+			switch name {
+			case columns.Area:
+				area := 0.0
+				if rec.ShapeType() == shp.Polygon || rec.ShapeType() == shp.PolygonZ || rec.ShapeType() == shp.PolygonM {
+					area = shp.Area(parts)
+				}
+				value = formatMeasure(area, int(f.Length))
+			case columns.Length:
+				value = formatMeasure(shp.Length(parts), int(f.Length))
+			case columns.Vertices:
+				value = strconv.Itoa(rec.NumPoints())
+			}
+			for j := len(value); j < int(f.Length); j++ {
+				record = append(record, ' ')
+			}
+			record = append(record, value...)
+		}
+		err = w.WriteRaw(record, d.IsDeleted())
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	err = w.Close()
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		return count, err
+	}
+	for _, ext := range []string{".shp", ".shx"} {
+		from := in.Shp
+		if ext == ".shx" {
+			from = in.Shx
+		}
+		err = copyFile(from, outBase+ext)
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, in.copySidecars(outBase)
+}
@@ -0,0 +1,120 @@
+package shapefile
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/shp"
+)
+
+// sameFields is true if two tables have the same columns
+func sameFields(a, b []dbf.DbfField) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Type != b[i].Type || a[i].Width != b[i].Width || a[i].Count != b[i].Count {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge appends bundles with the same geometry type and attribute fields
+// into one new bundle at outBase, e.g. state files into a national layer.
+// The tables must also be of the same version, which the output keeps,
+// see dbf.WriterVersion. Records are renumbered, the bounding box and all counts are recomputed.
+// The .prj and .cpg of the first input are copied. Returns the number of
+// features written.
+func Merge(inputs []*Bundle, outBase string) (int, error) {
+	if len(inputs) == 0 {
+		return 0, errors.New("shapefile: nothing to merge")
+	}
+	var shapeType shp.ShapeType
+	var fields []dbf.DbfField
+	var version, language byte
+	total := 0
+	for i, in := range inputs {
+		header, index, err := in.readIndex()
+		if err != nil {
+			return 0, err
+		}
+		d, err := in.OpenDbf()
+		if err != nil {
+			return 0, err
+		}
+		d.Close()
+		if i == 0 {
+			shapeType = header.ShapeType
+			fields = d.Fields
+			version = d.Version
+			language = d.Language
+		} else if header.ShapeType != shapeType {
+			return 0, errors.New("shapefile: " + in.Shp + " geometry type differs from " + inputs[0].Shp)
+		} else if !sameFields(fields, d.Fields) {
+			return 0, errors.New("shapefile: " + in.Dbf + " fields differ from " + inputs[0].Dbf)
+		} else if d.Version != version {
+			return 0, errors.New("shapefile: " + in.Dbf + " table version differs from " + inputs[0].Dbf)
+		}
+		total += len(index)
+	}
+
+	out, err := createOutput(outBase, shapeType, fields, uint32(total), version, language)
+	if err != nil {
+		return 0, err
+	}
+	for _, in := range inputs {
+		err = appendBundle(out, in)
+		if err != nil {
+			out.abort()
+			return 0, err
+		}
+	}
+	err = out.close()
+	if err != nil {
+		return 0, err
+	}
+	return total, inputs[0].copySidecars(outBase)
+}
+
+// appendBundle copies all features of in to out, checking that shapes and rows pair up
+func appendBundle(out *output, in *Bundle) error {
+	shpFile, err := os.Open(in.Shp)
+	if err != nil {
+		return err
+	}
+	defer shpFile.Close()
+	sr, err := shp.NewReader(bufio.NewReader(shpFile))
+	if err != nil {
+		return err
+	}
+	d, err := in.OpenDbf()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	for {
+		rec, err := sr.Next()
+		derr := d.Next()
+		if err == io.EOF && derr == io.EOF {
+			return nil
+		} else if err == io.EOF || derr == io.EOF {
+			return errors.New("shapefile: " + in.Shp + " and " + in.Dbf + " have different record counts")
+		} else if err != nil {
+			return err
+		} else if derr != nil {
+			return derr
+		}
+		err = out.dbf.WriteRaw(d.RawRecord(), d.IsDeleted())
+		if err != nil {
+			return err
+		}
+		err = out.shp.Write(rec)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package shapefile
+
+import (
+	"bufio"
+	"os"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/shp"
+)
+
+// output is a bundle being written at base.shp, base.shx, base.dbf
+type output struct {
+	files []*os.File
+	shp   *shp.Writer
+	dbf   *dbf.Writer
+	dbfbw *bufio.Writer
+}
+
+// createOutput starts a bundle of shapeType with a table of fields, in
+// the layout of a table of version
+func createOutput(base string, shapeType shp.ShapeType, fields []dbf.DbfField, numRecords uint32, version, language byte) (o *output, err error) {
+	o = &output{}
+	defer func() {
+		if err != nil {
+			o.abort()
+		}
+	}()
+	var created [3]*os.File
+	for i, ext := range []string{".shp", ".shx", ".dbf"} {
+		created[i], err = os.Create(base + ext)
+		if err != nil {
+			return nil, err
+		}
+		o.files = append(o.files, created[i])
+	}
+	o.shp, err = shp.NewWriter(created[0], created[1], shapeType)
+	if err != nil {
+		return nil, err
+	}
+	o.dbfbw = bufio.NewWriter(created[2])
+	o.dbf, err = dbf.NewWriter(o.dbfbw, fields, dbf.WithTableVersion(dbf.WriterVersion(version)))
+	if err != nil {
+		return nil, err
+	}
+	o.dbf.NumRecords = numRecords
+	o.dbf.Language = language
+	return o, nil
+}
+
+// abort closes whatever was opened, after an error
+func (o *output) abort() {
+	for _, f := range o.files {
+		f.Close()
+	}
+}
+
+func (o *output) close() error {
+	err := o.shp.Close()
+	if err == nil {
+		err = o.dbf.Close()
+	}
+	if err == nil {
+		err = o.dbfbw.Flush()
+	}
+	for _, f := range o.files {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
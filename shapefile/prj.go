@@ -0,0 +1,259 @@
+package shapefile
+
+import (
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// WKTNode is one KEYWORD[...] of well known text. Values are its quoted
+// strings and numbers in order, without quotes; Children are its nested
+// nodes.
+type WKTNode struct {
+	Keyword  string
+	Values   []string
+	Children []*WKTNode
+}
+
+// Child is the first child with keyword, nil if there is none
+func (n *WKTNode) Child(keyword string) *WKTNode {
+	for _, c := range n.Children {
+		if strings.EqualFold(c.Keyword, keyword) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Find is the first node with keyword at any depth, n itself included,
+// nil if there is none
+func (n *WKTNode) Find(keyword string) *WKTNode {
+	if strings.EqualFold(n.Keyword, keyword) {
+		return n
+	}
+	for _, c := range n.Children {
+		if found := c.Find(keyword); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// value is Values[i], "" if there are not that many
+func (n *WKTNode) value(i int) string {
+	if n == nil || i >= len(n.Values) {
+		return ""
+	}
+	return n.Values[i]
+}
+
+// WKTError is a syntax error in well known text
+type WKTError struct {
+	Pos int
+	Msg string
+}
+
+func (e *WKTError) Error() string {
+	return "shapefile: bad WKT at " + strconv.Itoa(e.Pos) + ": " + e.Msg
+}
+
+// wktParser reads WKT1 as in .prj files
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *wktParser) fail(msg string) error {
+	return &WKTError{p.pos, msg}
+}
+
+// node reads KEYWORD[value, ...], ( ) also bracketing
+func (p *wktParser) node() (*WKTNode, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] == '_' || isAlnum(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.fail("want a keyword")
+	}
+	n := &WKTNode{Keyword: strings.ToUpper(p.s[start:p.pos])}
+	p.skipSpace()
+	if p.pos >= len(p.s) || (p.s[p.pos] != '[' && p.s[p.pos] != '(') {
+		// a bare keyword, as the axis direction NORTH
+		return n, nil
+	}
+	closing := byte(']')
+	if p.s[p.pos] == '(' {
+		closing = ')'
+	}
+	p.pos++
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, p.fail("unterminated " + n.Keyword)
+		}
+		switch c := p.s[p.pos]; {
+		case c == '"':
+			v, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			n.Values = append(n.Values, v)
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			start := p.pos
+			for p.pos < len(p.s) && strings.IndexByte("+-.eE0123456789", p.s[p.pos]) >= 0 {
+				p.pos++
+			}
+			if _, err := strconv.ParseFloat(p.s[start:p.pos], 64); err != nil {
+				p.pos = start
+				return nil, p.fail("bad number")
+			}
+			n.Values = append(n.Values, p.s[start:p.pos])
+		default:
+			child, err := p.node()
+			if err != nil {
+				return nil, err
+			}
+			if len(child.Children) == 0 && len(child.Values) == 0 {
+				// bare keywords are values
+				n.Values = append(n.Values, child.Keyword)
+			} else {
+				n.Children = append(n.Children, child)
+			}
+		}
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, p.fail("unterminated " + n.Keyword)
+		}
+		if p.s[p.pos] == closing {
+			p.pos++
+			return n, nil
+		}
+		if p.s[p.pos] != ',' {
+			return nil, p.fail("want , or " + string(closing))
+		}
+		p.pos++
+	}
+}
+
+// quoted reads a "string", "" standing for a quote
+func (p *wktParser) quoted() (string, error) {
+	var sb strings.Builder
+	p.pos++
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		if c != '"' {
+			sb.WriteByte(c)
+		} else if p.pos < len(p.s) && p.s[p.pos] == '"' {
+			sb.WriteByte('"')
+			p.pos++
+		} else {
+			return sb.String(), nil
+		}
+	}
+	return "", p.fail("unterminated string")
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// ParseWKT parses WKT1 well known text, as in a .prj
+func ParseWKT(wkt string) (*WKTNode, error) {
+	p := &wktParser{s: wkt}
+	n, err := p.node()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, p.fail("text after the end")
+	}
+	return n, nil
+}
+
+// Projection is the coordinate reference system of a .prj
+type Projection struct {
+	WKT  string
+	Root *WKTNode
+
+	// Name of the coordinate system, e.g. "GCS_North_American_1983"
+	Name string
+	// Projected is set for PROJCS, projected x,y; otherwise coordinates
+	// are GEOGCS longitude and latitude
+	Projected bool
+	// Projection is the method of a projected system, e.g.
+	// "Transverse_Mercator"
+	Projection string
+	Datum      string
+	Spheroid   string
+	// Unit is the linear unit of projected coordinates or the angular
+	// unit of geographic ones, e.g. "Degree", "Meter", "Foot_US"
+	Unit string
+	// EPSG is the code from an AUTHORITY["EPSG",...], or for the common
+	// geographic systems of Census and GPS data, NAD83 (4269), WGS 84
+	// (4326) and NAD27 (4267), known by datum. 0 if not known.
+	EPSG int
+}
+
+// geographicEPSG are the EPSG codes of the usual unprojected systems by
+// datum name, ESRI and EPSG spellings
+var geographicEPSG = map[string]int{
+	"D_NORTH_AMERICAN_1983":     4269,
+	"NORTH_AMERICAN_DATUM_1983": 4269,
+	"D_WGS_1984":                4326,
+	"WGS_1984":                  4326,
+	"D_NORTH_AMERICAN_1927":     4267,
+	"NORTH_AMERICAN_DATUM_1927": 4267,
+}
+
+// ParsePrj reads the coordinate system from the well known text of a .prj
+func ParsePrj(wkt string) (*Projection, error) {
+	wkt = strings.TrimSpace(strings.TrimPrefix(wkt, "\ufeff"))
+	root, err := ParseWKT(wkt)
+	if err != nil {
+		return nil, err
+	}
+	switch root.Keyword {
+	case "GEOGCS", "PROJCS":
+	default:
+		return nil, errors.New("shapefile: .prj is " + root.Keyword + ", not GEOGCS or PROJCS")
+	}
+	p := &Projection{
+		WKT:        wkt,
+		Root:       root,
+		Name:       root.value(0),
+		Projected:  root.Keyword == "PROJCS",
+		Projection: root.Child("PROJECTION").value(0),
+		Datum:      root.Find("DATUM").value(0),
+		Spheroid:   root.Find("SPHEROID").value(0),
+		Unit:       root.Child("UNIT").value(0),
+	}
+	if auth := root.Child("AUTHORITY"); strings.EqualFold(auth.value(0), "EPSG") {
+		p.EPSG, _ = strconv.Atoi(auth.value(1))
+	} else if !p.Projected {
+		p.EPSG = geographicEPSG[strings.ToUpper(p.Datum)]
+	}
+	return p, nil
+}
+
+// Projection reads and parses the .prj of the bundle, nil if it has none
+func (b *Bundle) Projection() (*Projection, error) {
+	if b.Prj == "" {
+		return nil, nil
+	}
+	wkt, err := ioutil.ReadFile(b.Prj)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrj(string(wkt))
+}
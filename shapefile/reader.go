@@ -0,0 +1,106 @@
+package shapefile
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/shp"
+)
+
+// Reader reads the features of a shapefile in order, each .shp record
+// together with its .dbf row
+type Reader struct {
+	Bundle *Bundle
+	// Header is the .shp header
+	Header shp.Header
+	// Dbf is on the attribute row of the current feature after Next. Use
+	// it to read fields, not to move.
+	Dbf *dbf.Dbf
+	// NumFeatures is the record count of the .shx, which the .dbf matches
+	NumFeatures int
+
+	shpFile *os.File
+	shp     *shp.Reader
+	shape   *shp.Record
+	count   int
+}
+
+// Open opens the shapefile at a base path, as Find, for reading features
+// with Next. The .dbf record count must match the .shx.
+func Open(base string) (*Reader, error) {
+	b, err := Find(base)
+	if err != nil {
+		return nil, err
+	}
+	_, index, err := b.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	d, err := b.OpenDbf()
+	if err != nil {
+		return nil, err
+	}
+	if n := d.EffectiveRecords(); n != int64(len(index)) {
+		d.Close()
+		return nil, errors.New("shapefile: " + b.Dbf + " has " + strconv.FormatInt(n, 10) + " rows but " + b.Shx + " has " + strconv.Itoa(len(index)) + " shapes")
+	}
+	f, err := os.Open(b.Shp)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	sr, err := shp.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		d.Close()
+		return nil, err
+	}
+	return &Reader{Bundle: b, Header: sr.Header, Dbf: d, NumFeatures: len(index), shpFile: f, shp: sr}, nil
+}
+
+// Next moves to the next feature, returning io.EOF after the last. It is
+// an error for the .shp and .dbf to end at different records.
+func (r *Reader) Next() error {
+	shape, shpErr := r.shp.Next()
+	dbfErr := r.Dbf.Next()
+	if shpErr == io.EOF && dbfErr == io.EOF {
+		r.shape = nil
+		return io.EOF
+	}
+	if shpErr == nil && dbfErr == nil {
+		r.count++
+		if int(shape.Number) != r.count {
+			return errors.New("shapefile: " + r.Bundle.Shp + " record " + strconv.Itoa(r.count) + " is numbered " + strconv.Itoa(int(shape.Number)))
+		}
+		r.shape = shape
+		return nil
+	}
+	r.shape = nil
+	if shpErr == io.EOF {
+		return errors.New("shapefile: " + r.Bundle.Shp + " ends after " + strconv.Itoa(r.count) + " records but " + r.Bundle.Dbf + " has more")
+	} else if dbfErr == io.EOF {
+		return errors.New("shapefile: " + r.Bundle.Dbf + " ends after " + strconv.Itoa(r.count) + " rows but " + r.Bundle.Shp + " has more")
+	} else if shpErr != nil {
+		return shpErr
+	}
+	return dbfErr
+}
+
+// Shape is the geometry of the current feature
+func (r *Reader) Shape() *shp.Record {
+	return r.shape
+}
+
+// Close closes the .shp and .dbf
+func (r *Reader) Close() error {
+	err := r.shpFile.Close()
+	derr := r.Dbf.Close()
+	if err == nil {
+		err = derr
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package shapefile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/shp"
+)
+
+// writeBundle writes points at base with a table of version whose I
+// field N holds values, one row per point
+func writeBundle(t *testing.T, base string, version byte, values ...int) *Bundle {
+	t.Helper()
+	var files [3]*os.File
+	for i, ext := range []string{".shp", ".shx", ".dbf"} {
+		f, err := os.Create(base + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		files[i] = f
+	}
+	sw, err := shp.NewWriter(files[0], files[1], shp.Point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bw := bufio.NewWriter(files[2])
+	dw, err := dbf.NewWriter(bw, []dbf.DbfField{{Name: "N", Type: dbf.DbfFieldInteger}}, dbf.WithTableVersion(version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw.NumRecords = uint32(len(values))
+	dw.Language = 0x03
+	for _, v := range values {
+		content := make([]byte, 20)
+		binary.LittleEndian.PutUint32(content[0:4], uint32(shp.Point))
+		binary.LittleEndian.PutUint64(content[4:12], math.Float64bits(float64(v)))
+		binary.LittleEndian.PutUint64(content[12:20], math.Float64bits(float64(-v)))
+		err = sw.Write(&shp.Record{Content: content})
+		if err == nil {
+			err = dw.WriteValues(v)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = sw.Close()
+	if err == nil {
+		err = dw.Close()
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Find(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// checkTable fails unless the table of b is of version and language 0x03
+// with N holding want
+func checkTable(t *testing.T, b *Bundle, version byte, want ...int64) {
+	t.Helper()
+	d, err := b.OpenDbf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.Version != version || d.Language != 0x03 {
+		t.Errorf("%s version %#x language %#x, want %#x and 0x03", b.Dbf, d.Version, d.Language, version)
+	}
+	n := d.Field("N")
+	for i, v := range want {
+		err = d.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := n.Int64()
+		if err != nil || got != v {
+			t.Errorf("%s record %d N = %d, %v, want %d", b.Dbf, i, got, err, v)
+		}
+	}
+	err = b.Validate()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubsetVisualFoxPro(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shapefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	in := writeBundle(t, filepath.Join(dir, "in"), 0x30, 1, 2, 3, 4)
+	n, err := Subset(in, filepath.Join(dir, "even"), func(d *dbf.Dbf) bool {
+		v, _ := d.Field("N").Int64()
+		return v%2 == 0
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("Subset kept %d, %v", n, err)
+	}
+	out, err := Find(filepath.Join(dir, "even"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTable(t, out, 0x30, 2, 4)
+}
+
+func TestMergeVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shapefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a := writeBundle(t, filepath.Join(dir, "a"), 0x30, 1, 2)
+	b := writeBundle(t, filepath.Join(dir, "b"), 0x30, 3)
+	n, err := Merge([]*Bundle{a, b}, filepath.Join(dir, "ab"))
+	if err != nil || n != 3 {
+		t.Fatalf("Merge wrote %d, %v", n, err)
+	}
+	out, err := Find(filepath.Join(dir, "ab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTable(t, out, 0x30, 1, 2, 3)
+
+	c := writeBundle(t, filepath.Join(dir, "c"), 0x31, 4)
+	_, err = Merge([]*Bundle{a, c}, filepath.Join(dir, "ac"))
+	if err == nil {
+		t.Error("Merge took tables of different versions")
+	}
+}
+
+func TestAddGeometryAttributesVisualFoxPro(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shapefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	in := writeBundle(t, filepath.Join(dir, "in"), 0x30, 5, 6)
+	n, err := AddGeometryAttributes(in, filepath.Join(dir, "out"), nil)
+	if err != nil || n != 2 {
+		t.Fatalf("AddGeometryAttributes wrote %d, %v", n, err)
+	}
+	out, err := Find(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTable(t, out, 0x30, 5, 6)
+}
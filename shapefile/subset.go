@@ -0,0 +1,102 @@
+package shapefile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+
+	dbf "github.com/brianolson/go-dbf"
+	"github.com/brianolson/go-dbf/shp"
+)
+
+// readIndex reads the .shx of the bundle
+func (b *Bundle) readIndex() (shp.Header, []shp.IndexEntry, error) {
+	f, err := os.Open(b.Shx)
+	if err != nil {
+		return shp.Header{}, nil, err
+	}
+	defer f.Close()
+	return shp.ReadIndex(f)
+}
+
+// Subset writes a new bundle at outBase (.shp, .shx, .dbf and any .prj,
+// .cpg) with only the features whose attribute row passes keep. keep is
+// called with d positioned on each row. The table keeps the version of
+// the input, see dbf.WriterVersion. Returns the number of features kept.
+func Subset(in *Bundle, outBase string, keep func(d *dbf.Dbf) bool) (int, error) {
+	header, index, err := in.readIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	// first pass picks rows
+	d, err := in.OpenDbf()
+	if err != nil {
+		return 0, err
+	}
+	var kept []bool
+	count := 0
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			d.Close()
+			return 0, err
+		}
+		k := keep(d)
+		kept = append(kept, k)
+		if k {
+			count++
+		}
+	}
+	d.Close()
+	if len(kept) != len(index) {
+		return 0, errors.New("shapefile: " + in.Dbf + " has " + strconv.Itoa(len(kept)) + " rows but " + in.Shx + " has " + strconv.Itoa(len(index)) + " shapes")
+	}
+
+	// second pass copies them
+	shpIn, err := os.Open(in.Shp)
+	if err != nil {
+		return 0, err
+	}
+	defer shpIn.Close()
+	d, err = in.OpenDbf()
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+	out, err := createOutput(outBase, header.ShapeType, d.Fields, uint32(count), d.Version, d.Language)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(kept); i++ {
+		err = d.Next()
+		if err != nil {
+			out.abort()
+			return 0, err
+		}
+		if !kept[i] {
+			continue
+		}
+		err = out.dbf.WriteRaw(d.RawRecord(), d.IsDeleted())
+		if err != nil {
+			out.abort()
+			return 0, err
+		}
+		rec, err := shp.ReadRecordAt(shpIn, index[i])
+		if err == nil {
+			err = out.shp.Write(rec)
+		}
+		if err != nil {
+			out.abort()
+			return 0, err
+		}
+	}
+	err = out.close()
+	if err != nil {
+		return 0, err
+	}
+	return count, in.copySidecars(outBase)
+}
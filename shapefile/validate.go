@@ -0,0 +1,157 @@
+package shapefile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/brianolson/go-dbf/shp"
+)
+
+// ValidationError lists everything Validate found wrong
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "shapefile: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that the .shp, .shx and .dbf agree: header lengths
+// match file sizes, every .shx offset points at the matching .shp record,
+// the .shp holds as many records as the .shx, and the .dbf has exactly
+// that many rows, no trailing rows beyond the geometry. Problems are
+// returned in a *ValidationError, other errors are from reading.
+func (b *Bundle) Validate() error {
+	var problems []string
+	problemf := func(parts ...string) {
+		problems = append(problems, strings.Join(parts, ""))
+	}
+	itoa := func(i int64) string { return strconv.FormatInt(i, 10) }
+
+	shpFile, err := os.Open(b.Shp)
+	if err != nil {
+		return err
+	}
+	defer shpFile.Close()
+	shpInfo, err := shpFile.Stat()
+	if err != nil {
+		return err
+	}
+	shpSize := shpInfo.Size()
+	shpHeader, err := shp.ReadHeader(shpFile)
+	if err != nil {
+		return err
+	}
+	if shpHeader.FileLength != shpSize {
+		problemf(b.Shp, " header length ", itoa(shpHeader.FileLength), " but file is ", itoa(shpSize))
+	}
+
+	// the .shx header is checked against the file before it sizes anything
+	shxFile, err := os.Open(b.Shx)
+	if err != nil {
+		return err
+	}
+	defer shxFile.Close()
+	shxInfo, err := shxFile.Stat()
+	if err != nil {
+		return err
+	}
+	shxSize := shxInfo.Size()
+	shxHeader, err := shp.ReadHeader(shxFile)
+	if err != nil {
+		return err
+	}
+	if shxHeader.FileLength != shxSize {
+		problemf(b.Shx, " header length ", itoa(shxHeader.FileLength), " but file is ", itoa(shxSize))
+	}
+	if (shxSize-shp.HeaderLength)%8 != 0 {
+		problemf(b.Shx, " ends in a partial entry")
+	}
+	_, err = shxFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, index, err := shp.ReadIndex(shxFile)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if shxHeader.ShapeType != shpHeader.ShapeType {
+		problemf(b.Shx, " shape type differs from ", b.Shp)
+	}
+	var rh [8]byte
+	for i, e := range index {
+		if e.Offset < shp.HeaderLength || e.Offset+8+e.ContentLength > shpSize {
+			problemf(b.Shx, " record ", itoa(int64(i+1)), " offset ", itoa(e.Offset), " out of range")
+			continue
+		}
+		_, err = shpFile.ReadAt(rh[:], e.Offset)
+		if err != nil {
+			return err
+		}
+		number := int64(binary.BigEndian.Uint32(rh[0:4]))
+		length := int64(binary.BigEndian.Uint32(rh[4:8])) * 2
+		if number != int64(i+1) || length != e.ContentLength {
+			problemf(b.Shx, " record ", itoa(int64(i+1)), " points at .shp record ", itoa(number), " length ", itoa(length))
+		}
+	}
+
+	_, err = shpFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	sr, err := shp.NewReader(bufio.NewReader(shpFile))
+	if err != nil {
+		return err
+	}
+	shapes := int64(0)
+	for {
+		rec, err := sr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			problemf(b.Shp, " after record ", itoa(shapes), ": ", err.Error())
+			break
+		}
+		shapes++
+		if t := rec.ShapeType(); t != shp.NullShape && t != shpHeader.ShapeType {
+			problemf(b.Shp, " record ", itoa(shapes), " shape type ", itoa(int64(t)), " in a file of type ", itoa(int64(shpHeader.ShapeType)))
+		}
+	}
+	if shapes != int64(len(index)) {
+		problemf(b.Shp, " has ", itoa(shapes), " records but ", b.Shx, " has ", itoa(int64(len(index))))
+	}
+
+	d, err := b.OpenDbf()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	rows := int64(0)
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			problemf(b.Dbf, " after row ", itoa(rows), ": ", err.Error())
+			break
+		}
+		rows++
+	}
+	if int64(d.NumRecords) != rows {
+		problemf(b.Dbf, " header says ", itoa(int64(d.NumRecords)), " rows but has ", itoa(rows))
+	}
+	if rows > int64(len(index)) {
+		problemf(b.Dbf, " has ", itoa(rows-int64(len(index))), " trailing rows beyond the ", itoa(int64(len(index))), " shapes")
+	} else if rows < int64(len(index)) {
+		problemf(b.Dbf, " has ", itoa(rows), " rows but there are ", itoa(int64(len(index))), " shapes")
+	}
+
+	if len(problems) != 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
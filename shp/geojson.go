@@ -0,0 +1,81 @@
+package shp
+
+import "encoding/json"
+
+// geoJSONGeometry is a GeoJSON geometry object
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func position(c Coord) [2]float64 {
+	return [2]float64{c.X, c.Y}
+}
+
+func positions(cs []Coord) [][2]float64 {
+	out := make([][2]float64, len(cs))
+	for i, c := range cs {
+		out[i] = position(c)
+	}
+	return out
+}
+
+// signedArea is negative for clockwise rings
+func signedArea(ring []Coord) float64 {
+	sum := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		sum += ring[i].X*ring[i+1].Y - ring[i+1].X*ring[i].Y
+	}
+	return sum / 2
+}
+
+// GeoJSON is the x,y geometry of the record as a GeoJSON geometry object,
+// or null for null shapes and MultiPatch. Z and M values are dropped.
+// Polygon rings are grouped by orientation: each clockwise ring starts a
+// polygon and the counterclockwise rings after it are its holes, giving a
+// MultiPolygon.
+func (r *Record) GeoJSON() ([]byte, error) {
+	var g geoJSONGeometry
+	switch t := r.ShapeType(); t {
+	case Point, PointZ, PointM:
+		points, err := r.Points()
+		if err != nil {
+			return nil, err
+		}
+		g = geoJSONGeometry{"Point", position(points[0])}
+	case MultiPoint, MultiPointZ, MultiPointM:
+		points, err := r.Points()
+		if err != nil {
+			return nil, err
+		}
+		g = geoJSONGeometry{"MultiPoint", positions(points)}
+	case PolyLine, PolyLineZ, PolyLineM:
+		parts, err := r.Parts()
+		if err != nil {
+			return nil, err
+		}
+		lines := make([][][2]float64, len(parts))
+		for i, part := range parts {
+			lines[i] = positions(part)
+		}
+		g = geoJSONGeometry{"MultiLineString", lines}
+	case Polygon, PolygonZ, PolygonM:
+		rings, err := r.Parts()
+		if err != nil {
+			return nil, err
+		}
+		var polygons [][][][2]float64
+		for _, ring := range rings {
+			if signedArea(ring) <= 0 || len(polygons) == 0 {
+				polygons = append(polygons, [][][2]float64{positions(ring)})
+			} else {
+				last := len(polygons) - 1
+				polygons[last] = append(polygons[last], positions(ring))
+			}
+		}
+		g = geoJSONGeometry{"MultiPolygon", polygons}
+	default:
+		return []byte("null"), nil
+	}
+	return json.Marshal(g)
+}
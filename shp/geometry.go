@@ -0,0 +1,137 @@
+package shp
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Coord is an x,y coordinate
+type Coord struct {
+	X float64
+	Y float64
+}
+
+// hasParts is true for the shape types laid out as bbox, parts, points
+func hasParts(t ShapeType) bool {
+	switch t {
+	case PolyLine, Polygon, PolyLineZ, PolygonZ, PolyLineM, PolygonM, MultiPatch:
+		return true
+	}
+	return false
+}
+
+// Parts splits the x,y points of a polyline or polygon record (including
+// Z and M variants) into its parts or rings. Other shapes have no parts.
+func (r *Record) Parts() ([][]Coord, error) {
+	if !hasParts(r.ShapeType()) {
+		return nil, nil
+	}
+	c := r.Content
+	if len(c) < 44 {
+		return nil, ErrBadRecord
+	}
+	numParts := int(binary.LittleEndian.Uint32(c[36:40]))
+	numPoints := int(binary.LittleEndian.Uint32(c[40:44]))
+	pointsStart := 44 + 4*numParts
+	if numParts < 0 || numPoints < 0 || pointsStart+16*numPoints > len(c) {
+		return nil, ErrBadRecord
+	}
+	points := make([]Coord, numPoints)
+	for i := range points {
+		points[i].X = getFloat(c[pointsStart+16*i:])
+		points[i].Y = getFloat(c[pointsStart+16*i+8:])
+	}
+	parts := make([][]Coord, numParts)
+	for i := range parts {
+		start := int(binary.LittleEndian.Uint32(c[44+4*i:]))
+		end := numPoints
+		if i+1 < numParts {
+			end = int(binary.LittleEndian.Uint32(c[44+4*(i+1):]))
+		}
+		if start < 0 || start > end || end > numPoints {
+			return nil, ErrBadRecord
+		}
+		parts[i] = points[start:end]
+	}
+	return parts, nil
+}
+
+// Points is every x,y vertex of the record in order: one for a point, the
+// points of a multipoint, and all parts of a polyline or polygon run
+// together. Null shapes have none.
+func (r *Record) Points() ([]Coord, error) {
+	c := r.Content
+	switch t := r.ShapeType(); {
+	case t == Point || t == PointZ || t == PointM:
+		if len(c) < 20 {
+			return nil, ErrBadRecord
+		}
+		return []Coord{{getFloat(c[4:]), getFloat(c[12:])}}, nil
+	case t == MultiPoint || t == MultiPointZ || t == MultiPointM:
+		if len(c) < 40 {
+			return nil, ErrBadRecord
+		}
+		n := int(binary.LittleEndian.Uint32(c[36:40]))
+		if n < 0 || 40+16*n > len(c) {
+			return nil, ErrBadRecord
+		}
+		points := make([]Coord, n)
+		for i := range points {
+			points[i] = Coord{getFloat(c[40+16*i:]), getFloat(c[48+16*i:])}
+		}
+		return points, nil
+	case hasParts(t):
+		parts, err := r.Parts()
+		if err != nil {
+			return nil, err
+		}
+		var points []Coord
+		for _, part := range parts {
+			points = append(points, part...)
+		}
+		return points, nil
+	}
+	return nil, nil
+}
+
+// NumPoints is the vertex count of the record
+func (r *Record) NumPoints() int {
+	c := r.Content
+	switch t := r.ShapeType(); {
+	case t == Point || t == PointZ || t == PointM:
+		return 1
+	case hasParts(t):
+		if len(c) >= 44 {
+			return int(binary.LittleEndian.Uint32(c[40:44]))
+		}
+	case t == MultiPoint || t == MultiPointZ || t == MultiPointM:
+		if len(c) >= 40 {
+			return int(binary.LittleEndian.Uint32(c[36:40]))
+		}
+	}
+	return 0
+}
+
+// Area is the planar area enclosed by polygon rings. Shapefile outer
+// rings are clockwise and holes counterclockwise, so holes subtract.
+func Area(rings [][]Coord) float64 {
+	sum := 0.0
+	for _, ring := range rings {
+		for i := 0; i+1 < len(ring); i++ {
+			sum += ring[i].X*ring[i+1].Y - ring[i+1].X*ring[i].Y
+		}
+	}
+	// clockwise is negative by the shoelace formula
+	return math.Abs(sum / 2)
+}
+
+// Length is the planar length of all parts, the perimeter for polygons
+func Length(parts [][]Coord) float64 {
+	sum := 0.0
+	for _, part := range parts {
+		for i := 0; i+1 < len(part); i++ {
+			sum += math.Hypot(part[i+1].X-part[i].X, part[i+1].Y-part[i].Y)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,313 @@
+// Package shp reads and writes ESRI shapefile .shp geometry and .shx index files.
+// https://www.esri.com/content/dam/esrisites/sitecore-archive/Files/Pdfs/library/whitepapers/pdfs/shapefile.pdf
+package shp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// ShapeType is the geometry type of a shapefile or record
+type ShapeType int32
+
+const (
+	NullShape   ShapeType = 0
+	Point       ShapeType = 1
+	PolyLine    ShapeType = 3
+	Polygon     ShapeType = 5
+	MultiPoint  ShapeType = 8
+	PointZ      ShapeType = 11
+	PolyLineZ   ShapeType = 13
+	PolygonZ    ShapeType = 15
+	MultiPointZ ShapeType = 18
+	PointM      ShapeType = 21
+	PolyLineM   ShapeType = 23
+	PolygonM    ShapeType = 25
+	MultiPointM ShapeType = 28
+	MultiPatch  ShapeType = 31
+)
+
+// HeaderLength is the size of the .shp and .shx file header
+const HeaderLength = 100
+
+const fileCode = 9994
+
+var ErrBadHeader error = errors.New("shp: bad file header")
+var ErrBadRecord error = errors.New("shp: bad record")
+
+// maxRecordLength bounds the record content length read from a header
+const maxRecordLength = 1 << 30
+
+// maxIndexPrealloc bounds the index entries allocated up front when the
+// .shx size is not known, a header can claim any length
+const maxIndexPrealloc = 1 << 16
+
+// BBox is a bounding box, Xmin Ymin Xmax Ymax
+type BBox [4]float64
+
+// EmptyBBox is the starting point for Extend
+var EmptyBBox = BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+
+// Extend grows b to include o
+func (b *BBox) Extend(o BBox) {
+	b[0] = math.Min(b[0], o[0])
+	b[1] = math.Min(b[1], o[1])
+	b[2] = math.Max(b[2], o[2])
+	b[3] = math.Max(b[3], o[3])
+}
+
+// IsEmpty is true for EmptyBBox or anything else enclosing nothing
+func (b *BBox) IsEmpty() bool {
+	return b[0] > b[2] || b[1] > b[3]
+}
+
+// Header is the 100 byte header shared by .shp and .shx files
+type Header struct {
+	// FileLength is in bytes (stored in the file as 16-bit words)
+	FileLength int64
+	Version    int32
+	ShapeType  ShapeType
+	BBox       BBox
+	ZRange     [2]float64
+	MRange     [2]float64
+}
+
+// ParseHeader reads a Header from the first 100 bytes of a .shp or .shx
+func ParseHeader(data []byte) (h Header, err error) {
+	if len(data) < HeaderLength || binary.BigEndian.Uint32(data[0:4]) != fileCode {
+		return h, ErrBadHeader
+	}
+	h.FileLength = int64(binary.BigEndian.Uint32(data[24:28])) * 2
+	h.Version = int32(binary.LittleEndian.Uint32(data[28:32]))
+	h.ShapeType = ShapeType(binary.LittleEndian.Uint32(data[32:36]))
+	for i := range h.BBox {
+		h.BBox[i] = getFloat(data[36+(8*i):])
+	}
+	h.ZRange[0] = getFloat(data[68:])
+	h.ZRange[1] = getFloat(data[76:])
+	h.MRange[0] = getFloat(data[84:])
+	h.MRange[1] = getFloat(data[92:])
+	return h, nil
+}
+
+// ReadHeader reads a Header from the start of r
+func ReadHeader(r io.Reader) (Header, error) {
+	var data [HeaderLength]byte
+	_, err := io.ReadFull(r, data[:])
+	if err != nil {
+		return Header{}, err
+	}
+	return ParseHeader(data[:])
+}
+
+// Bytes is the 100 byte file header
+func (h *Header) Bytes() []byte {
+	data := make([]byte, HeaderLength)
+	binary.BigEndian.PutUint32(data[0:4], fileCode)
+	binary.BigEndian.PutUint32(data[24:28], uint32(h.FileLength/2))
+	version := h.Version
+	if version == 0 {
+		version = 1000
+	}
+	binary.LittleEndian.PutUint32(data[28:32], uint32(version))
+	binary.LittleEndian.PutUint32(data[32:36], uint32(h.ShapeType))
+	bbox := h.BBox
+	if bbox.IsEmpty() {
+		bbox = BBox{}
+	}
+	for i := range bbox {
+		putFloat(data[36+(8*i):], bbox[i])
+	}
+	putFloat(data[68:], h.ZRange[0])
+	putFloat(data[76:], h.ZRange[1])
+	putFloat(data[84:], h.MRange[0])
+	putFloat(data[92:], h.MRange[1])
+	return data
+}
+
+func getFloat(b []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func putFloat(b []byte, v float64) {
+	binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+}
+
+// Record is one raw .shp record
+type Record struct {
+	// Number is 1 based
+	Number int32
+	// Content starts with the little endian shape type
+	Content []byte
+}
+
+// ShapeType of the record content
+func (r *Record) ShapeType() ShapeType {
+	if len(r.Content) < 4 {
+		return NullShape
+	}
+	return ShapeType(binary.LittleEndian.Uint32(r.Content[0:4]))
+}
+
+// BBox of the record content, ok false for null shapes
+func (r *Record) BBox() (bbox BBox, ok bool) {
+	c := r.Content
+	switch r.ShapeType() {
+	case Point, PointZ, PointM:
+		if len(c) < 20 {
+			return bbox, false
+		}
+		x := getFloat(c[4:])
+		y := getFloat(c[12:])
+		return BBox{x, y, x, y}, true
+	case PolyLine, Polygon, MultiPoint, PolyLineZ, PolygonZ, MultiPointZ, PolyLineM, PolygonM, MultiPointM, MultiPatch:
+		if len(c) < 36 {
+			return bbox, false
+		}
+		for i := range bbox {
+			bbox[i] = getFloat(c[4+(8*i):])
+		}
+		return bbox, true
+	}
+	return bbox, false
+}
+
+// IndexEntry is one .shx record, the position of a .shp record
+type IndexEntry struct {
+	// Offset of the record header in the .shp in bytes
+	Offset int64
+	// ContentLength in bytes, not counting the 8 byte record header
+	ContentLength int64
+}
+
+// inputSize is the size of r from os.File Stat or a Size() method
+// (bytes.Reader, io.SectionReader), ok false if it has neither
+func inputSize(r interface{}) (size int64, ok bool) {
+	switch v := r.(type) {
+	case interface{ Stat() (os.FileInfo, error) }:
+		fi, err := v.Stat()
+		if err == nil && fi.Mode().IsRegular() {
+			return fi.Size(), true
+		}
+	case interface{ Size() int64 }:
+		return v.Size(), true
+	}
+	return 0, false
+}
+
+// checkLength is an error for a record content length at pos that no
+// record can have
+func checkLength(length, pos int64) error {
+	if length < 4 || length > maxRecordLength {
+		return errors.New("shp: bad record length " + strconv.FormatInt(length, 10) + " at " + strconv.FormatInt(pos, 10))
+	}
+	return nil
+}
+
+// ReadIndex reads a whole .shx file, as many entries as there are
+// whatever the header FileLength says
+func ReadIndex(r io.Reader) (Header, []IndexEntry, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return h, nil, err
+	}
+	count := (h.FileLength - HeaderLength) / 8
+	if count < 0 {
+		return h, nil, ErrBadHeader
+	}
+	// the header only sizes the slice up front, and may be corrupt
+	if size, ok := inputSize(r); ok {
+		if n := (size - HeaderLength) / 8; count > n {
+			count = n
+		}
+	} else if count > maxIndexPrealloc {
+		count = maxIndexPrealloc
+	}
+	if count < 0 {
+		count = 0
+	}
+	out := make([]IndexEntry, 0, count)
+	var rec [8]byte
+	for {
+		_, err = io.ReadFull(r, rec[:])
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return h, out, err
+		}
+		out = append(out, IndexEntry{
+			Offset:        int64(binary.BigEndian.Uint32(rec[0:4])) * 2,
+			ContentLength: int64(binary.BigEndian.Uint32(rec[4:8])) * 2,
+		})
+	}
+	return h, out, nil
+}
+
+// ReadRecordAt reads the .shp record for an index entry. An entry past the
+// end of shp, when its size is known, fails with ErrBadRecord.
+func ReadRecordAt(shp io.ReaderAt, e IndexEntry) (*Record, error) {
+	err := checkLength(e.ContentLength, e.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if size, ok := inputSize(shp); ok && e.Offset+8+e.ContentLength > size {
+		return nil, ErrBadRecord
+	}
+	buf := make([]byte, 8+e.ContentLength)
+	_, err = shp.ReadAt(buf, e.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if int64(binary.BigEndian.Uint32(buf[4:8]))*2 != e.ContentLength {
+		return nil, ErrBadRecord
+	}
+	return &Record{Number: int32(binary.BigEndian.Uint32(buf[0:4])), Content: buf[8:]}, nil
+}
+
+// Reader reads .shp records in order
+type Reader struct {
+	Header Header
+
+	r   io.Reader
+	pos int64
+}
+
+// NewReader reads the .shp header
+func NewReader(r io.Reader) (*Reader, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{Header: h, r: r, pos: HeaderLength}, nil
+}
+
+// Next returns the next record, or io.EOF
+func (sr *Reader) Next() (*Record, error) {
+	if sr.Header.FileLength > 0 && sr.pos >= sr.Header.FileLength {
+		return nil, io.EOF
+	}
+	var rh [8]byte
+	_, err := io.ReadFull(sr.r, rh[:])
+	if err != nil {
+		return nil, err
+	}
+	length := int64(binary.BigEndian.Uint32(rh[4:8])) * 2
+	err = checkLength(length, sr.pos)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Record{Number: int32(binary.BigEndian.Uint32(rh[0:4])), Content: make([]byte, length)}
+	_, err = io.ReadFull(sr.r, rec.Content)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	sr.pos += 8 + length
+	return rec, nil
+}
@@ -0,0 +1,48 @@
+package shp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// corruptIndex is a .shx with one entry whose header claims the largest
+// FileLength
+func corruptIndex() []byte {
+	h := Header{FileLength: HeaderLength + 8, ShapeType: Point}
+	shx := append(h.Bytes(), 0, 0, 0, 50, 0x7f, 0xff, 0xff, 0xff)
+	binary.BigEndian.PutUint32(shx[24:28], 0xffffffff)
+	return shx
+}
+
+func TestReadIndexCorruptLength(t *testing.T) {
+	_, index, err := ReadIndex(bytes.NewReader(corruptIndex()))
+	if err != nil || len(index) != 1 {
+		t.Fatalf("ReadIndex = %d entries, %v", len(index), err)
+	}
+	if cap(index) != 1 {
+		t.Errorf("ReadIndex allocated %d entries for a one entry file", cap(index))
+	}
+	// without a size, a bounded number up front
+	_, index, err = ReadIndex(struct{ io.Reader }{bytes.NewReader(corruptIndex())})
+	if err != nil || len(index) != 1 || cap(index) > maxIndexPrealloc {
+		t.Errorf("ReadIndex of a stream = %d entries of %d, %v", len(index), cap(index), err)
+	}
+}
+
+func TestReadRecordAtCorruptLength(t *testing.T) {
+	_, index, err := ReadIndex(bytes.NewReader(corruptIndex()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shp := make([]byte, HeaderLength+28)
+	_, err = ReadRecordAt(bytes.NewReader(shp), index[0])
+	if err == nil {
+		t.Fatal("ReadRecordAt read a record longer than the file")
+	}
+	_, err = ReadRecordAt(bytes.NewReader(shp), IndexEntry{Offset: HeaderLength, ContentLength: 1 << 20})
+	if err != ErrBadRecord {
+		t.Errorf("ReadRecordAt past the end = %v, want ErrBadRecord", err)
+	}
+}
@@ -0,0 +1,90 @@
+package shp
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Writer writes a .shp and its .shx together. Records are renumbered from
+// 1 and the headers (file length, bounding box) are rewritten on Close, so
+// both outputs must be seekable.
+type Writer struct {
+	Header Header
+
+	shp   io.WriteSeeker
+	shx   io.WriteSeeker
+	pos   int64
+	count int32
+}
+
+// NewWriter starts a .shp/.shx pair of shapeType
+func NewWriter(shp, shx io.WriteSeeker, shapeType ShapeType) (*Writer, error) {
+	w := &Writer{shp: shp, shx: shx, pos: HeaderLength}
+	w.Header.ShapeType = shapeType
+	w.Header.BBox = EmptyBBox
+	// placeholder headers until Close
+	blank := make([]byte, HeaderLength)
+	_, err := shp.Write(blank)
+	if err != nil {
+		return nil, err
+	}
+	_, err = shx.Write(blank)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends the content of rec as the next record
+func (w *Writer) Write(rec *Record) error {
+	w.count++
+	var rh [8]byte
+	binary.BigEndian.PutUint32(rh[0:4], uint32(w.count))
+	binary.BigEndian.PutUint32(rh[4:8], uint32(len(rec.Content)/2))
+	_, err := w.shp.Write(rh[:])
+	if err != nil {
+		return err
+	}
+	_, err = w.shp.Write(rec.Content)
+	if err != nil {
+		return err
+	}
+	var ix [8]byte
+	binary.BigEndian.PutUint32(ix[0:4], uint32(w.pos/2))
+	binary.BigEndian.PutUint32(ix[4:8], uint32(len(rec.Content)/2))
+	_, err = w.shx.Write(ix[:])
+	if err != nil {
+		return err
+	}
+	w.pos += 8 + int64(len(rec.Content))
+	if bbox, ok := rec.BBox(); ok {
+		w.Header.BBox.Extend(bbox)
+	}
+	return nil
+}
+
+// Count is the number of records written so far
+func (w *Writer) Count() int {
+	return int(w.count)
+}
+
+// Close rewrites both headers. It does not close the outputs.
+func (w *Writer) Close() error {
+	h := w.Header
+	h.FileLength = w.pos
+	_, err := w.shp.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = w.shp.Write(h.Bytes())
+	if err != nil {
+		return err
+	}
+	h.FileLength = HeaderLength + 8*int64(w.count)
+	_, err = w.shx.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = w.shx.Write(h.Bytes())
+	return err
+}
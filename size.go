@@ -0,0 +1,81 @@
+package dbf
+
+import (
+	"io"
+	"os"
+)
+
+// inputSize finds the total size of the input from os.File Stat, a Size()
+// method (bytes.Reader, io.SectionReader), or seeking to the end. Pipes and
+// other streams are not ok.
+func inputSize(r io.Reader) (size int64, ok bool, err error) {
+	switch v := r.(type) {
+	case interface{ Stat() (os.FileInfo, error) }:
+		fi, err := v.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return 0, false, nil
+		}
+		return fi.Size(), true, nil
+	case interface{ Size() int64 }:
+		return v.Size(), true, nil
+	}
+	if seeker, isSeeker := r.(io.Seeker); isSeeker {
+		cur, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			// not really seekable
+			return 0, false, nil
+		}
+		size, err = seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, false, nil
+		}
+		_, err = seeker.Seek(cur, io.SeekStart)
+		if err != nil {
+			// now we have lost our place
+			return 0, false, err
+		}
+		return size, true, nil
+	}
+	return 0, false, nil
+}
+
+// countRecordsFromSize sets SizeRecords from (size - header) / record length when the input size can be known.
+func (d *Dbf) countRecordsFromSize() error {
+	size, ok, err := inputSize(d.reader)
+	if err != nil || !ok {
+		return err
+	}
+	data := size - d.pos
+	if data < 0 {
+		data = 0
+	}
+	d.SizeRecords = data / int64(d.rowWidth+1)
+	if d.SizeRecords != int64(d.NumRecords) && d.repairCount {
+		d.warnAnomaly(AnomalyRecordCount, int64(d.NumRecords), d.SizeRecords, "NumRecords=%d but file size implies %d records, using that", d.NumRecords, d.SizeRecords)
+	} else if d.SizeRecords != int64(d.NumRecords) {
+		return d.anomaly(AnomalyRecordCount, int64(d.NumRecords), d.SizeRecords, "NumRecords=%d but file size implies %d records", d.NumRecords, d.SizeRecords)
+	}
+	return nil
+}
+
+// EffectiveRecords is the number of records actually in the input when its
+// size is known, otherwise the NumRecords declared in the header. Next
+// always iterates on the actual content, whatever the header says.
+func (d *Dbf) EffectiveRecords() int64 {
+	if d.SizeRecords >= 0 {
+		return d.SizeRecords
+	}
+	return int64(d.NumRecords)
+}
+
+// WithRecordCountRepair accepts a NumRecords that disagrees with the
+// input size, such as the 0 some tools leave, and goes by the count the
+// size implies, EffectiveRecords. NumRecords stays what the header
+// declares. The AnomalyRecordCount is still noted and logged, but
+// WithStrict does not fail on it. Inputs of unknown size, such as pipes,
+// have only NumRecords.
+func WithRecordCountRepair() Option {
+	return func(d *Dbf) {
+		d.repairCount = true
+	}
+}
@@ -0,0 +1,32 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestRecordCountRepair(t *testing.T) {
+	fields := []fuzzField{{"NAME", 'C', 5, 0}}
+	table := fuzzTable(fields, []string{" alpha", " bravo", " delta"}, 0, 0, true)
+	binary.LittleEndian.PutUint32(table[4:8], 0)
+
+	_, err := NewDbf(bytes.NewReader(table), WithStrict(), WithLogger(nil))
+	if err == nil {
+		t.Error("WithStrict accepted a count of 0 for 3 records")
+	}
+	d, err := NewDbf(bytes.NewReader(table), WithStrict(), WithRecordCountRepair(), WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.NumRecords != 0 || d.EffectiveRecords() != 3 {
+		t.Errorf("NumRecords %d EffectiveRecords %d, want 0 and 3", d.NumRecords, d.EffectiveRecords())
+	}
+	if len(d.Anomalies()) != 1 || d.Anomalies()[0].Kind != AnomalyRecordCount {
+		t.Errorf("anomalies %v, want one AnomalyRecordCount", d.Anomalies())
+	}
+	rows, err := d.ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Errorf("ReadAll %d rows, %v, want 3", len(rows), err)
+	}
+}
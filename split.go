@@ -0,0 +1,117 @@
+package dbf
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Split copies the remaining records of d into parts of partRecords
+// records, each a standalone table with its own header of the version of
+// d, written to namer(0), namer(1), ... Visual FoxPro and dBASE 7 tables
+// keep their version, other tables are split into dBASE III parts, and
+// memo files are not split. A part writer that is an io.Closer is closed
+// when its part is done. The record count in each header is what was
+// written: patched in at the end of the part on an io.WriteSeeker such as
+// an *os.File, otherwise known up front from EffectiveRecords. When it
+// can't be, because WithSkipDeleted or a filter passes over records, a
+// part for an output that cannot seek is held in memory until it is
+// complete. Returns the number of parts written.
+func Split(d *Dbf, partRecords int, namer func(i int) io.Writer) (int, error) {
+	if partRecords <= 0 {
+		return 0, errors.New("dbf Split partRecords must be positive")
+	}
+	remaining := d.EffectiveRecords() - (d.recno + 1)
+	parts := 0
+	var p *splitPart
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return parts, err
+		}
+		if p == nil {
+			count := int64(partRecords)
+			if remaining < count {
+				count = remaining
+			}
+			remaining -= count
+			p, err = startPart(d, namer(parts), count)
+			if err != nil {
+				return parts, err
+			}
+			parts++
+		}
+		err = p.w.WriteRaw(d.RawRecord(), d.IsDeleted())
+		if err != nil {
+			return parts, err
+		}
+		if p.w.count == uint32(partRecords) {
+			err = p.finish()
+			p = nil
+			if err != nil {
+				return parts, err
+			}
+		}
+	}
+	if p != nil {
+		return parts, p.finish()
+	}
+	return parts, nil
+}
+
+// splitPart is one table being written by Split
+type splitPart struct {
+	out io.Writer
+	bw  *bufio.Writer
+	w   *Writer
+	// header is where the part starts on a seekable out, -1 if it can't
+	// seek
+	header int64
+}
+
+// startPart begins a part on out of count records if that can't be
+// corrected later
+func startPart(d *Dbf, out io.Writer, count int64) (*splitPart, error) {
+	p := &splitPart{out: out, bw: bufio.NewWriter(out), header: -1}
+	if seeker, ok := out.(io.Seeker); ok {
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			p.header = pos
+		}
+	}
+	var err error
+	p.w, err = NewWriter(p.bw, d.Fields, WithTableVersion(WriterVersion(d.Version)))
+	if err != nil {
+		return nil, err
+	}
+	p.w.Language = d.Language
+	if p.header < 0 {
+		p.w.NumRecords = uint32(count)
+		p.w.countAtClose = !d.readsAll()
+	}
+	return p, nil
+}
+
+// finish ends the part, patching its header on a seekable output
+func (p *splitPart) finish() error {
+	if p.header >= 0 {
+		// the Writer sees the buffer, not the seeker
+		p.w.NumRecords = p.w.count
+	}
+	err := p.w.Close()
+	if err == nil {
+		err = p.bw.Flush()
+	}
+	if err == nil && p.header >= 0 {
+		err = patchHeader(p.out.(io.WriteSeeker), p.header, p.w.count)
+	}
+	if closer, ok := p.out.(io.Closer); ok {
+		cerr := closer.Close()
+		if err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
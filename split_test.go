@@ -0,0 +1,118 @@
+package dbf
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// deletedTable is a table of rows records, every third one deleted
+func deletedTable(t *testing.T, rows int) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []DbfField{{Name: "N", Type: DbfFieldNumeric, Width: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.NumRecords = uint32(rows)
+	for i := 0; i < rows; i++ {
+		err = w.writeValues([]string{strconv.Itoa(i)}, i%3 == 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// checkCount fails unless table declares and holds want records
+func checkCount(t *testing.T, table []byte, want int) {
+	t.Helper()
+	d, err := NewDbf(bytes.NewReader(table), WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for d.Next() == nil {
+		n++
+	}
+	if d.NumRecords != uint32(want) || n != want {
+		t.Errorf("NumRecords %d with %d records, want %d", d.NumRecords, n, want)
+	}
+}
+
+func TestSplitSkipDeleted(t *testing.T) {
+	table := deletedTable(t, 10)
+	// 6 live records in parts of 4
+	want := []int{4, 2}
+
+	var bufs []*bytes.Buffer
+	d, err := NewDbf(bytes.NewReader(table), WithSkipDeleted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err := Split(d, 4, func(i int) io.Writer {
+		bufs = append(bufs, new(bytes.Buffer))
+		return bufs[i]
+	})
+	if err != nil || parts != len(want) {
+		t.Fatalf("Split to buffers: %d parts, %v", parts, err)
+	}
+	for i, b := range bufs {
+		checkCount(t, b.Bytes(), want[i])
+	}
+
+	dir, err := ioutil.TempDir("", "split")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	d, err = NewDbf(bytes.NewReader(table), WithSkipDeleted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err = Split(d, 4, func(i int) io.Writer {
+		f, err := os.Create(filepath.Join(dir, strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	})
+	if err != nil || parts != len(want) {
+		t.Fatalf("Split to files: %d parts, %v", parts, err)
+	}
+	for i := range want {
+		b, err := ioutil.ReadFile(filepath.Join(dir, strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCount(t, b, want[i])
+	}
+}
+
+func TestSplitVisualFoxPro(t *testing.T) {
+	var rows []string
+	for i := 0; i < 5; i++ {
+		rows = append(rows, " name "+string([]byte{byte(i), 0, 0, 0}))
+	}
+	d, err := NewDbf(bytes.NewReader(vfpTable([]fuzzField{{"NAME", 'C', 5, 0}, {"N", 'I', 4, 0}}, rows)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bufs []*bytes.Buffer
+	_, err = Split(d, 3, func(i int) io.Writer {
+		bufs = append(bufs, new(bytes.Buffer))
+		return bufs[i]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIntegers(t, bufs[0].Bytes(), 0, 1, 2)
+	checkIntegers(t, bufs[1].Bytes(), 3, 4)
+}
@@ -0,0 +1,144 @@
+package dbf
+
+import "io"
+
+// StorageReport is where the bytes of a table go, from AnalyzeStorage
+type StorageReport struct {
+	HeaderBytes int64 `json:"header_bytes"`
+	// DataBytes is the size of all records read, flags included
+	DataBytes int64 `json:"data_bytes"`
+	Records   int64 `json:"records"`
+	Deleted   int64 `json:"deleted"`
+	// DeletedBytes is the space held by records marked deleted
+	DeletedBytes int64 `json:"deleted_bytes"`
+
+	Columns []ColumnStorage `json:"columns"`
+
+	// MemoFields are memo, general and binary fields; the bytes they point
+	// to are in a separate memo file and not counted in the table sizes.
+	MemoFields []string `json:"memo_fields,omitempty"`
+	// MemoBytes is the size of the attached memo file, MemoLiveBytes the
+	// whole blocks of it the memos of live records take. The rest is the
+	// memo file header, memos of deleted records and blocks left behind
+	// by edits. Both are 0 without AttachMemo, MemoBytes also when the
+	// memo file size is not known.
+	MemoBytes     int64 `json:"memo_bytes,omitempty"`
+	MemoLiveBytes int64 `json:"memo_live_bytes,omitempty"`
+
+	// PackedBytes is the projected file size with deleted records removed
+	PackedBytes int64 `json:"packed_bytes"`
+	// TightBytes is the projected file size packed and rewritten with the
+	// schema narrowed to the live data, see SuggestSchema
+	TightBytes int64 `json:"tight_bytes"`
+	// TightFields is that narrowed schema
+	TightFields []DbfField `json:"-"`
+}
+
+// ColumnStorage is the padding in one field over the live records
+type ColumnStorage struct {
+	Name  string `json:"name"`
+	Width int    `json:"width"`
+	// Used is the longest value, TightWidth the width SuggestSchema would pick
+	Used       int `json:"used"`
+	TightWidth int `json:"tight_width"`
+	// PaddingBytes is the total of width less value length
+	PaddingBytes int64   `json:"padding_bytes"`
+	PaddingRatio float64 `json:"padding_ratio"`
+}
+
+// FileBytes is the total size the table should have: header, records and
+// the end of file marker.
+func (r *StorageReport) FileBytes() int64 {
+	return r.HeaderBytes + r.DataBytes + 1
+}
+
+// Savings is the projected bytes saved by packing and tightening
+func (r *StorageReport) Savings() int64 {
+	return r.FileBytes() - r.TightBytes
+}
+
+// MemoBloat is the bytes of the memo file no live record points to, 0 if
+// the memo file size is not known
+func (r *StorageReport) MemoBloat() int64 {
+	if r.MemoBytes == 0 {
+		return 0
+	}
+	return r.MemoBytes - r.MemoLiveBytes
+}
+
+// AnalyzeStorage reads the remaining records of d and reports wasted
+// space: padding per column, bytes in deleted records, memo file space no
+// live record uses when a memo file is attached, and the projected size
+// after packing out deleted records and narrowing the schema. It helps
+// pick which archived files are worth rewriting with Rewrite. d should be
+// positioned at its first record.
+func AnalyzeStorage(d *Dbf) (*StorageReport, error) {
+	report := &StorageReport{
+		HeaderBytes: d.dataStart,
+		Columns:     make([]ColumnStorage, len(d.Fields)),
+	}
+	extents := newFieldExtents(len(d.Fields))
+	var memos []*DbfField
+	for i := range d.Fields {
+		f := &d.Fields[i]
+		report.Columns[i] = ColumnStorage{Name: f.Name, Width: f.Width}
+		if f.isMemo() {
+			report.MemoFields = append(report.MemoFields, f.Name)
+			memos = append(memos, f)
+		}
+	}
+	if d.memo == nil {
+		memos = nil
+	} else if d.memo.size > 0 {
+		report.MemoBytes = d.memo.size
+	}
+	rowBytes := int64(d.rowWidth + 1)
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		report.Records++
+		report.DataBytes += rowBytes
+		if d.IsDeleted() {
+			report.Deleted++
+			report.DeletedBytes += rowBytes
+			continue
+		}
+		for i := range d.Fields {
+			f := &d.Fields[i]
+			extents[i].observe(f)
+			report.Columns[i].PaddingBytes += int64(f.Width - len(fieldText(f)))
+		}
+		for _, f := range memos {
+			block, err := f.memoBlock()
+			if err == nil && block != 0 {
+				var n int64
+				n, err = d.memo.span(block)
+				report.MemoLiveBytes += n
+			}
+			if err != nil {
+				return nil, f.parseError(err)
+			}
+		}
+	}
+	report.TightFields = tightFields(d.Fields, extents)
+	live := report.Records - report.Deleted
+	tightRow := int64(1)
+	for i := range report.Columns {
+		col := &report.Columns[i]
+		col.Used = extents[i].length
+		col.TightWidth = report.TightFields[i].Width
+		tightRow += int64(col.TightWidth)
+		if live > 0 {
+			col.PaddingRatio = float64(col.PaddingBytes) / float64(live*int64(col.Width))
+		}
+	}
+	// as Rewrite writes it, a dBase III header with no extra padding
+	tightHeader := int64(32 + 32*len(d.Fields) + 1)
+	report.PackedBytes = report.HeaderBytes + live*rowBytes + 1
+	report.TightBytes = tightHeader + live*tightRow + 1
+	return report, nil
+}
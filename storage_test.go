@@ -0,0 +1,38 @@
+package dbf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAnalyzeStorageMemo(t *testing.T) {
+	table := fuzzTable([]fuzzField{{"ID", 'N', 2, 0}, {"NOTE", 'M', 10, 0}},
+		[]string{"  0          ", "  1         1", "* 2         2", "  3         3"}, 0, 0, true)
+	table[0] = 0x83
+	// dBASE III memo: the header block, then one block per memo
+	memo := make([]byte, 4*512)
+	copy(memo[512:], "live\x1a")
+	copy(memo[1024:], "deleted\x1a")
+	copy(memo[1536:], "also live\x1a")
+	d, err := NewDbf(bytes.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.AttachMemo(bytes.NewReader(memo)); err != nil {
+		t.Fatal(err)
+	}
+	// the first record is left out, HeaderBytes still the header
+	if err = d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	report, err := AnalyzeStorage(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(32 + 2*32 + 1); report.HeaderBytes != want {
+		t.Errorf("HeaderBytes %d, want %d", report.HeaderBytes, want)
+	}
+	if report.MemoBytes != 2048 || report.MemoLiveBytes != 1024 || report.MemoBloat() != 1024 {
+		t.Errorf("MemoBytes %d MemoLiveBytes %d MemoBloat %d, want 2048 1024 1024", report.MemoBytes, report.MemoLiveBytes, report.MemoBloat())
+	}
+}
@@ -0,0 +1,85 @@
+package dbf
+
+import (
+	"fmt"
+	"time"
+)
+
+// StrictError is malformed input that WithStrict fails on, where NewDbf
+// would otherwise log it and carry on
+type StrictError struct {
+	Msg string
+}
+
+func (e *StrictError) Error() string {
+	return "dbf strict: " + e.Msg
+}
+
+// WithStrict fails on malformed input instead of logging and working
+// around it, for pipelines that should stop at bad data. NewDbf returns a
+// *StrictError for a NumRecordBytes that disagrees with the field widths,
+// a field reaching past the record length, a NumHeaderBytes that
+// disagrees with where the descriptors end or the records start, a
+// NumRecords the file size disagrees with, and field
+// types no dBASE or FoxPro version defines. Next, RecordAt and NextN fail
+// with a *ParseError wrapping a *DateFormatError for a D field holding an
+// impossible date, such as 20230230 or 00000000; the record is still
+// consumed, so reading can go on past it.
+func WithStrict() Option {
+	return func(d *Dbf) {
+		d.strict = true
+	}
+}
+
+// knownFieldTypes are the field types of dBASE III to 7 and (Visual)
+// FoxPro, '0' being the _NullFlags system column
+var knownFieldTypes = map[DbfFieldType]bool{
+	'C': true, 'N': true, 'D': true, 'L': true, 'F': true, 'M': true,
+	'B': true, 'G': true, 'P': true, 'I': true, 'Y': true, 'T': true,
+	'V': true, 'Q': true, 'W': true, '0': true,
+	'@': true, '+': true, 'O': true,
+}
+
+// checkFields fails WithStrict on unknown field types and fields past the
+// record length the header declares
+func (d *Dbf) checkFields() error {
+	if !d.strict {
+		return nil
+	}
+	for _, f := range d.Fields {
+		if !knownFieldTypes[f.Type] {
+			return &StrictError{fmt.Sprintf("field %s has unknown type %#x", f.Name, byte(f.Type))}
+		}
+		if d.NumRecordBytes > 0 && f.StartPos+f.Width > int(d.NumRecordBytes)-1 {
+			return &StrictError{fmt.Sprintf("field %s ends at byte %d, past NumRecordBytes=%d", f.Name, 1+f.StartPos+f.Width, d.NumRecordBytes)}
+		}
+	}
+	return nil
+}
+
+// checkRecord fails WithStrict on impossible dates in D fields of row, the
+// record after the flag byte at recordPos
+func (d *Dbf) checkRecord(row []byte, recno, recordPos int64) error {
+	if !d.strict {
+		return nil
+	}
+	for i := range d.Fields {
+		f := &d.Fields[i]
+		if f.Type != DbfFieldDate || f.StartPos+f.Width > len(row) {
+			continue
+		}
+		v := trimPadding(row[f.StartPos:f.StartPos+f.Width], false)
+		if len(v) == 0 {
+			continue
+		}
+		if _, err := time.Parse(DateLayout, string(v)); err != nil {
+			return &ParseError{
+				Offset: recordPos + 1 + int64(f.StartPos),
+				Record: recno,
+				Field:  f.Name,
+				Err:    &DateFormatError{f.Name, string(v)},
+			}
+		}
+	}
+	return nil
+}
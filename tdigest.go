@@ -0,0 +1,227 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// TDigest is a streaming approximation of the distribution of a set of
+// numbers in bounded memory, a merging t-digest. Quantiles near 0 and 1
+// are the most accurate.
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min, max    float64
+}
+
+type centroid struct {
+	mean, weight float64
+}
+
+// DefaultCompression keeps roughly 100 centroids
+const DefaultCompression = 100
+
+// NewTDigest makes an empty digest. Larger compression is more accurate
+// and uses more memory.
+func NewTDigest(compression float64) *TDigest {
+	if compression < 10 {
+		compression = 10
+	}
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Add counts one value
+func (t *TDigest) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	t.buffer = append(t.buffer, centroid{x, 1})
+	t.count++
+	if x < t.min {
+		t.min = x
+	}
+	if x > t.max {
+		t.max = x
+	}
+	if len(t.buffer) >= int(5*t.compression) {
+		t.compress()
+	}
+}
+
+// Count is the number of values added
+func (t *TDigest) Count() uint64 {
+	return uint64(t.count)
+}
+
+// Min is the smallest value added, +Inf if none
+func (t *TDigest) Min() float64 {
+	return t.min
+}
+
+// Max is the largest value added, -Inf if none
+func (t *TDigest) Max() float64 {
+	return t.max
+}
+
+// scale is the k1 scale function, centroids may span at most 1 unit of it
+func (t *TDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.buffer, t.centroids...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	before := 0.0
+	kLeft := t.scale(0)
+	for _, c := range all[1:] {
+		q := (before + cur.weight + c.weight) / t.count
+		if t.scale(q)-kLeft <= 1 {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.weight
+		kLeft = t.scale(before / t.count)
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+	t.buffer = t.buffer[:0]
+}
+
+// knots are the points of the piecewise linear CDF estimate: (min, 0),
+// each centroid mean at the middle of its weight, (max, count).
+func (t *TDigest) knots() (xs, ws []float64) {
+	t.compress()
+	xs = append(xs, t.min)
+	ws = append(ws, 0)
+	cum := 0.0
+	for _, c := range t.centroids {
+		xs = append(xs, c.mean)
+		ws = append(ws, cum+c.weight/2)
+		cum += c.weight
+	}
+	return append(xs, t.max), append(ws, cum)
+}
+
+// Quantile estimates the value below which fraction q of values fall, NaN
+// if nothing was added.
+func (t *TDigest) Quantile(q float64) float64 {
+	if t.count == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	} else if q >= 1 {
+		return t.max
+	}
+	xs, ws := t.knots()
+	target := q * t.count
+	i := sort.SearchFloat64s(ws, target)
+	if ws[i] == ws[i-1] {
+		return xs[i]
+	}
+	return xs[i-1] + (xs[i]-xs[i-1])*(target-ws[i-1])/(ws[i]-ws[i-1])
+}
+
+// CDF estimates the fraction of values at or below x
+func (t *TDigest) CDF(x float64) float64 {
+	if t.count == 0 || x < t.min {
+		return 0
+	} else if x >= t.max {
+		return 1
+	}
+	xs, ws := t.knots()
+	i := sort.Search(len(xs), func(i int) bool { return xs[i] > x })
+	if xs[i] == xs[i-1] {
+		return ws[i] / t.count
+	}
+	return (ws[i-1] + (ws[i]-ws[i-1])*(x-xs[i-1])/(xs[i]-xs[i-1])) / t.count
+}
+
+// HistogramBin is one bar of a histogram, an estimated Count of values in [Low, High)
+type HistogramBin struct {
+	Low, High float64
+	Count     float64
+}
+
+// Histogram estimates counts in equal width bins from Min to Max
+func (t *TDigest) Histogram(bins int) []HistogramBin {
+	if t.count == 0 || bins < 1 {
+		return nil
+	}
+	width := (t.max - t.min) / float64(bins)
+	out := make([]HistogramBin, bins)
+	prev := 0.0
+	for i := range out {
+		low := t.min + width*float64(i)
+		high := t.min + width*float64(i+1)
+		c := t.CDF(high)
+		if i == bins-1 {
+			high = t.max
+			c = 1
+		}
+		out[i] = HistogramBin{low, high, (c - prev) * t.count}
+		prev = c
+	}
+	return out
+}
+
+// Distributions scans the remaining live (not deleted) records of d and
+// builds a TDigest for each named field, or every numeric (N, F) field if
+// none are named. Blank and unparseable values are not counted.
+func Distributions(d *Dbf, fields ...string) (map[string]*TDigest, error) {
+	var selected []*DbfField
+	if len(fields) == 0 {
+		for i, f := range d.Fields {
+			if isNumericType(f.Type) {
+				selected = append(selected, &d.Fields[i])
+			}
+		}
+		if len(selected) == 0 {
+			return nil, errors.New("dbf has no numeric fields")
+		}
+	} else {
+		var err error
+		selected, err = fieldsByName(d, fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	digests := make([]*TDigest, len(selected))
+	for i := range digests {
+		digests[i] = NewTDigest(DefaultCompression)
+	}
+	for {
+		err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if d.IsDeleted() {
+			continue
+		}
+		for i, f := range selected {
+			v, err := strconv.ParseFloat(f.StringValue(), 64)
+			if err == nil {
+				digests[i].Add(v)
+			}
+		}
+	}
+	out := make(map[string]*TDigest, len(selected))
+	for i, f := range selected {
+		out[f.Name] = digests[i]
+	}
+	return out, nil
+}
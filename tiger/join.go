@@ -0,0 +1,155 @@
+package tiger
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	dbf "github.com/brianolson/go-dbf"
+)
+
+// CSVTable is a CSV file held in memory by GEOID, for joining to a TIGER dbf.
+type CSVTable struct {
+	Header    []string
+	KeyColumn int
+
+	rows    map[string][]string
+	matched map[string]bool
+}
+
+// JoinReport describes how a join went. Key lists are sorted.
+type JoinReport struct {
+	Matched uint64
+	// UnmatchedDbf are GEOIDs of dbf rows with no CSV row
+	UnmatchedDbf []string
+	// UnmatchedCSV are GEOIDs of CSV rows with no dbf row
+	UnmatchedCSV []string
+}
+
+// NormalizeGEOID strips a summary level prefix as in "7500000US060014001001001"
+// from Census API and PL 94-171 extracts.
+func NormalizeGEOID(key string) string {
+	key = strings.TrimSpace(key)
+	if i := strings.Index(key, "US"); i >= 0 {
+		return key[i+2:]
+	}
+	return key
+}
+
+// ReadCSVTable reads a CSV with a header row, keyed by keyColumn (e.g. GEOID or GEO_ID).
+func ReadCSVTable(r io.Reader, keyColumn string) (*CSVTable, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	t := &CSVTable{Header: header, KeyColumn: -1, rows: make(map[string][]string), matched: make(map[string]bool)}
+	for i, name := range header {
+		if name == keyColumn {
+			t.KeyColumn = i
+			break
+		}
+	}
+	if t.KeyColumn < 0 {
+		return nil, errors.New("tiger: CSV has no column " + keyColumn)
+	}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if t.KeyColumn >= len(row) {
+			continue
+		}
+		t.rows[NormalizeGEOID(row[t.KeyColumn])] = row
+	}
+	return t, nil
+}
+
+// Join streams the remaining rows of d, calling emit with d on each row and
+// the CSV row with the same GEOID. The GEOID of a dbf row comes from its
+// GEOID field or is built from its state/county/tract/block fields.
+func (t *CSVTable) Join(d *dbf.Dbf, emit func(d *dbf.Dbf, row []string) error) (*JoinReport, error) {
+	keyer, err := newGeoKeyer(d)
+	if err != nil {
+		return nil, err
+	}
+	report := &JoinReport{}
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return report, err
+		}
+		key, err := keyer.key(keyer.widest)
+		if err != nil {
+			return report, err
+		}
+		row, ok := t.rows[key]
+		if !ok {
+			report.UnmatchedDbf = append(report.UnmatchedDbf, key)
+			continue
+		}
+		t.matched[key] = true
+		report.Matched++
+		err = emit(d, row)
+		if err != nil {
+			return report, err
+		}
+	}
+	for key := range t.rows {
+		if !t.matched[key] {
+			report.UnmatchedCSV = append(report.UnmatchedCSV, key)
+		}
+	}
+	sort.Strings(report.UnmatchedDbf)
+	sort.Strings(report.UnmatchedCSV)
+	return report, nil
+}
+
+// JoinCSV writes a combined CSV table of the dbf fields followed by the
+// CSV columns other than the key, for rows found in both.
+func (t *CSVTable) JoinCSV(d *dbf.Dbf, w io.Writer) (*JoinReport, error) {
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(d.Fields)+len(t.Header)-1)
+	for _, f := range d.Fields {
+		header = append(header, f.Name)
+	}
+	for i, name := range t.Header {
+		if i != t.KeyColumn {
+			header = append(header, name)
+		}
+	}
+	err := cw.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(header))
+	report, err := t.Join(d, func(d *dbf.Dbf, row []string) error {
+		out = out[:0]
+		for i := range d.Fields {
+			out = append(out, d.Fields[i].StringValue())
+		}
+		for i := range t.Header {
+			if i == t.KeyColumn {
+				continue
+			}
+			if i < len(row) {
+				out = append(out, row[i])
+			} else {
+				out = append(out, "")
+			}
+		}
+		return cw.Write(out)
+	})
+	if err != nil {
+		return report, err
+	}
+	cw.Flush()
+	return report, cw.Error()
+}
@@ -0,0 +1,157 @@
+package tiger
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+
+	dbf "github.com/brianolson/go-dbf"
+)
+
+var ErrUnsorted error = errors.New("tiger: RollUpSorted input is not sorted by GEOID")
+
+// DefaultSums are summed by RollUp when no columns are named
+var DefaultSums = []string{"ALAND", "AWATER"}
+
+// Group is the rollup of all rows sharing a GEOID prefix.
+type Group struct {
+	// Key is the GEOID at the rollup level
+	Key   string
+	Count uint64
+	// Sums of numeric columns by the base name they were asked for
+	Sums map[string]float64
+}
+
+type rollup struct {
+	keyer  *geoKeyer
+	names  []string
+	fields []*dbf.DbfField
+}
+
+func newRollup(d *dbf.Dbf, sums []string) (*rollup, error) {
+	keyer, err := newGeoKeyer(d)
+	if err != nil {
+		return nil, err
+	}
+	r := &rollup{keyer: keyer}
+	explicit := len(sums) != 0
+	if !explicit {
+		sums = DefaultSums
+	}
+	for _, name := range sums {
+		f := Field(d, name)
+		if f == nil {
+			if explicit {
+				return nil, errors.New("tiger: no field " + name)
+			}
+			continue
+		}
+		r.names = append(r.names, name)
+		r.fields = append(r.fields, f)
+	}
+	return r, nil
+}
+
+func (r *rollup) add(g *Group) error {
+	g.Count++
+	for i, f := range r.fields {
+		sv := f.StringValue()
+		if sv == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(sv, 64)
+		if err != nil {
+			return errors.New("tiger: field " + f.Name + ": " + err.Error())
+		}
+		g.Sums[r.names[i]] += v
+	}
+	return nil
+}
+
+func (r *rollup) newGroup(key string) *Group {
+	return &Group{Key: key, Sums: make(map[string]float64, len(r.names))}
+}
+
+// RollUp aggregates the remaining rows of d to level, counting rows and
+// summing the named numeric columns (found with any vintage suffix,
+// DefaultSums if none are named). Input may be in any order, memory grows
+// with the number of groups. Groups are returned sorted by Key.
+func RollUp(d *dbf.Dbf, level Level, sums ...string) ([]Group, error) {
+	r, err := newRollup(d, sums)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*Group)
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		key, err := r.keyer.key(level)
+		if err != nil {
+			return nil, err
+		}
+		g := groups[key]
+		if g == nil {
+			g = r.newGroup(key)
+			groups[key] = g
+		}
+		err = r.add(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// RollUpSorted is RollUp for input sorted by GEOID, as TIGER block files
+// are. Each group is passed to emit as soon as it is complete, so memory
+// is bounded. Out of order input fails with ErrUnsorted.
+func RollUpSorted(d *dbf.Dbf, level Level, emit func(Group) error, sums ...string) error {
+	r, err := newRollup(d, sums)
+	if err != nil {
+		return err
+	}
+	var g *Group
+	for {
+		err = d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		key, err := r.keyer.key(level)
+		if err != nil {
+			return err
+		}
+		if g != nil && key != g.Key {
+			if key < g.Key {
+				return ErrUnsorted
+			}
+			err = emit(*g)
+			if err != nil {
+				return err
+			}
+			g = nil
+		}
+		if g == nil {
+			g = r.newGroup(key)
+		}
+		err = r.add(g)
+		if err != nil {
+			return err
+		}
+	}
+	if g != nil {
+		return emit(*g)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package tiger has helpers for Census TIGER/Line attribute tables read with go-dbf.
+//
+// TIGER field names carry a vintage suffix, STATEFP10 or STATEFP00 or
+// STATEFP20, so fields are looked up by their base name.
+package tiger
+
+import (
+	"errors"
+
+	dbf "github.com/brianolson/go-dbf"
+)
+
+var ErrNoGeography error = errors.New("tiger: dbf has no GEOID or state/county/tract/block fields")
+
+// vintageSuffixes are tried in order after a base field name
+var vintageSuffixes = []string{"20", "10", "00", ""}
+
+// Field finds a field by base name with any vintage suffix, nil if there is none.
+func Field(d *dbf.Dbf, base string) *dbf.DbfField {
+	for _, suffix := range vintageSuffixes {
+		name := base + suffix
+		for i := range d.Fields {
+			if d.Fields[i].Name == name {
+				return &d.Fields[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Level is a census geography summary level, its value is the length of its GEOID.
+type Level int
+
+const (
+	State      Level = 2
+	County     Level = 5
+	Tract      Level = 11
+	BlockGroup Level = 12
+	Block      Level = 15
+)
+
+func (l Level) String() string {
+	switch l {
+	case State:
+		return "state"
+	case County:
+		return "county"
+	case Tract:
+		return "tract"
+	case BlockGroup:
+		return "block group"
+	case Block:
+		return "block"
+	}
+	return "Level(?)"
+}
+
+// geoKeyer builds GEOIDs for the current row from a GEOID field or from the component fields
+type geoKeyer struct {
+	geoid  *dbf.DbfField
+	parts  []*dbf.DbfField
+	widest Level
+}
+
+func newGeoKeyer(d *dbf.Dbf) (*geoKeyer, error) {
+	k := &geoKeyer{geoid: Field(d, "GEOID")}
+	if k.geoid == nil {
+		k.geoid = Field(d, "BLKIDFP")
+	}
+	if k.geoid != nil {
+		k.widest = Block
+		return k, nil
+	}
+	for _, part := range []struct {
+		base  string
+		level Level
+	}{{"STATEFP", State}, {"COUNTYFP", County}, {"TRACTCE", Tract}, {"BLOCKCE", Block}, {"BLKGRPCE", BlockGroup}} {
+		if k.widest == Block {
+			break
+		}
+		f := Field(d, part.base)
+		if f == nil {
+			if part.level <= Tract {
+				break
+			}
+			continue
+		}
+		k.parts = append(k.parts, f)
+		k.widest = part.level
+	}
+	if len(k.parts) == 0 {
+		return nil, ErrNoGeography
+	}
+	return k, nil
+}
+
+// key is the GEOID of the current row truncated to level
+func (k *geoKeyer) key(level Level) (string, error) {
+	if level > k.widest {
+		return "", errors.New("tiger: dbf does not have " + level.String() + " geography")
+	}
+	var geoid string
+	if k.geoid != nil {
+		geoid = k.geoid.StringValue()
+	} else {
+		for _, f := range k.parts {
+			geoid += f.StringValue()
+		}
+	}
+	if len(geoid) < int(level) {
+		return geoid, nil
+	}
+	return geoid[:level], nil
+}
@@ -0,0 +1,87 @@
+package tiger
+
+import (
+	dbf "github.com/brianolson/go-dbf"
+)
+
+// CanonicalFields are the TIGER base field names DetectVintage looks for
+var CanonicalFields = []string{
+	"STATEFP", "COUNTYFP", "COUSUBFP", "PLACEFP", "TRACTCE", "BLKGRPCE", "BLOCKCE",
+	"GEOID", "NAME", "NAMELSAD", "MTFCC", "UR", "UACE", "FUNCSTAT",
+	"ALAND", "AWATER", "INTPTLAT", "INTPTLON",
+}
+
+// basFields only appear in Boundary and Annexation Survey change files
+var basFields = []string{"CHNG_TYPE", "EFF_DATE", "RELATE", "AUTHTYPE"}
+
+// Vintage describes which census a TIGER attribute table is from.
+type Vintage struct {
+	// Year is 2000, 2010 or 2020 for decennial files, 0 for unsuffixed annual files
+	Year int
+	// Variant is "ACS" for annual (unsuffixed) TIGER files, "BAS" for
+	// Boundary and Annexation Survey files, "" for decennial files
+	Variant string
+	// Suffix is the field name suffix, "10" for STATEFP10
+	Suffix string
+	// Fields maps canonical base names to the field names present
+	Fields map[string]string
+}
+
+// Has is true if the canonical field is present
+func (v *Vintage) Has(base string) bool {
+	_, ok := v.Fields[base]
+	return ok
+}
+
+// DetectVintage identifies the census vintage of a TIGER table from the
+// suffixes of its field names, pass it d.Fields.
+func DetectVintage(fields []dbf.DbfField) Vintage {
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[f.Name] = true
+	}
+	best := ""
+	bestCount := 0
+	for _, suffix := range vintageSuffixes {
+		count := 0
+		for _, base := range CanonicalFields {
+			if names[base+suffix] {
+				count++
+			}
+		}
+		if suffix == "00" && names["BLKIDFP00"] {
+			count++
+		}
+		if count > bestCount {
+			best = suffix
+			bestCount = count
+		}
+	}
+	v := Vintage{Suffix: best, Fields: make(map[string]string)}
+	for _, base := range CanonicalFields {
+		if names[base+best] {
+			v.Fields[base] = base + best
+		}
+	}
+	switch best {
+	case "00":
+		v.Year = 2000
+		if _, ok := v.Fields["GEOID"]; !ok && names["BLKIDFP00"] {
+			// 2000 block files call the block GEOID BLKIDFP00
+			v.Fields["GEOID"] = "BLKIDFP00"
+		}
+	case "10":
+		v.Year = 2010
+	case "20":
+		v.Year = 2020
+	default:
+		v.Variant = "ACS"
+		for _, name := range basFields {
+			if names[name] {
+				v.Variant = "BAS"
+				break
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,54 @@
+package dbf
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrTruncated is what a *TruncatedError unwraps to, so errors.Is can tell
+// a cut off file from other read errors
+var ErrTruncated error = errors.New("dbf input truncated")
+
+// TruncatedError is the end of the data without the 0x1a end of file
+// marker, found by WithDetectTruncation. The records before it were read
+// normally.
+type TruncatedError struct {
+	// Records is how many whole records were read
+	Records int64
+	// Declared is NumRecords from the header
+	Declared uint32
+	// Partial is the bytes of the record that was cut off, deletion flag
+	// first, empty if the data ends between records
+	Partial []byte
+}
+
+func (e *TruncatedError) Error() string {
+	msg := "dbf input truncated after " + strconv.FormatInt(e.Records, 10) + " of " + strconv.FormatUint(uint64(e.Declared), 10) + " records"
+	if len(e.Partial) != 0 {
+		return msg + ", " + strconv.Itoa(len(e.Partial)) + " bytes into the next"
+	}
+	return msg + ", no end of file marker"
+}
+
+func (e *TruncatedError) Unwrap() error {
+	return ErrTruncated
+}
+
+// WithDetectTruncation makes the end of the data report how it ended,
+// for files that may be cut off, such as interrupted downloads. Without
+// it a short last record fails with io.ErrUnexpectedEOF and data ending
+// without the 0x1a marker is a normal io.EOF. With it both fail with a
+// *ParseError wrapping a *TruncatedError, which has the bytes of the
+// partial record; the records before it read as usual, and the next call
+// returns io.EOF. Tables from writers that leave out the marker are
+// reported too; compare Records with Declared to tell them apart.
+func WithDetectTruncation() Option {
+	return func(d *Dbf) {
+		d.detectTruncation = true
+	}
+}
+
+// truncated is the error for the end of the data without the marker
+func (d *Dbf) truncated(partial []byte) error {
+	return &TruncatedError{Records: d.recno + 1, Declared: d.NumRecords, Partial: partial}
+}
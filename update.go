@@ -0,0 +1,68 @@
+package dbf
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+var ErrNotWritable error = errors.New("dbf reader is not an io.ReadWriteSeeker")
+
+// UpdateRecord overwrites the fields of record i (0 based) in place with
+// values in field order, formatted as by Writer.WriteValues; the binary
+// I, Y, B and T fields of a Visual FoxPro table are encoded as they are
+// read, a time.Time keeping its time of day in a T field. The deletion
+// flag is left alone. The reader must be an io.ReadWriteSeeker, such as an
+// *os.File opened for reading and writing. Reading with Next carries on
+// from where it was; if record i is the current record its field values
+// change too.
+func (d *Dbf) UpdateRecord(i int, values ...interface{}) error {
+	rws, ok := d.reader.(io.ReadWriteSeeker)
+	if !ok {
+		return ErrNotWritable
+	}
+	if len(values) != len(d.Fields) {
+		return errors.New("dbf UpdateRecord wrong number of values, want " + strconv.Itoa(len(d.Fields)) + " got " + strconv.Itoa(len(values)))
+	}
+	if i < 0 || int64(i) >= d.EffectiveRecords() {
+		return errors.New("dbf UpdateRecord no record " + strconv.Itoa(i))
+	}
+	rec := make([]byte, d.recordLength)
+	for fi := range d.Fields {
+		f := &d.Fields[fi]
+		binary := isBinaryType(d.Version, f.Type)
+		v := formatValue(f, binary, values[fi])
+		if binary {
+			if f.Width < binaryWidth(f.Type) {
+				return &ValueTooLongError{f.Name, v}
+			}
+			err := putBinary(rec[f.StartPos:f.StartPos+f.Width], f.Type, v)
+			if err != nil {
+				return errors.New("dbf UpdateRecord field " + f.Name + " value " + strconv.Quote(v) + ": " + err.Error())
+			}
+			continue
+		}
+		if len(v) > f.Width {
+			return &ValueTooLongError{f.Name, v}
+		}
+		fillField(rec[f.StartPos:f.StartPos+f.Width], f.Type, v)
+	}
+	if d.rowWidth < len(rec) {
+		// fields past a short header width are not in the file
+		rec = rec[:d.rowWidth]
+	}
+	pos := d.pos
+	_, err := rws.Seek(d.dataStart+int64(i)*int64(d.rowWidth+1)+1, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = rws.Write(rec)
+	if err != nil {
+		return err
+	}
+	if int64(i) == d.recno {
+		copy(d.recordBuffer, rec)
+	}
+	// drops any pushed back bytes, which may be of the old record
+	return d.seekTo(pos)
+}
@@ -0,0 +1,89 @@
+package dbf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// vfpTable is a Visual FoxPro table of fields and rows, with the database
+// container backlink before the records
+func vfpTable(fields []fuzzField, rows []string) []byte {
+	descriptors := 32 + 32*len(fields) + 1
+	table := fuzzTable(fields, rows, uint16(descriptors+263), 0, true)
+	table = append(table[:descriptors:descriptors], append(make([]byte, 263), table[descriptors:]...)...)
+	table[0] = 0x30
+	return table
+}
+
+// tempTable writes table to a file in a new directory, for removing the
+// directory when done
+func tempTable(t *testing.T, table []byte) (dir, path string) {
+	dir, err := ioutil.TempDir("", "dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(dir, "t.dbf")
+	err = ioutil.WriteFile(path, table, 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return dir, path
+}
+
+func TestUpdateRecordVisualFoxPro(t *testing.T) {
+	fields := []fuzzField{{"NAME", 'C', 5, 0}, {"N", 'I', 4, 0}, {"PRICE", 'Y', 8, 4}, {"RATE", 'B', 8, 0}, {"AT", 'T', 8, 0}}
+	row := " abcde" + string(make([]byte, 28))
+	dir, path := tempTable(t, vfpTable(fields, []string{row, row}))
+	defer os.RemoveAll(dir)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDbf(f, WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	at := time.Date(2021, 3, 4, 5, 6, 7, 8e6, time.UTC)
+	err = d.UpdateRecord(1, "xy", 7, "-12.3456", 0.1, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.UpdateRecord(0, "zz", -123456, nil, "2.5", "2020-02-29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.UpdateRecord(0, "zz", "seven", nil, nil, nil)
+	if err == nil {
+		t.Error("UpdateRecord took text for an I field")
+	}
+
+	want := [][]string{
+		{"zz", "-123456", "0.0000", "2.5", "2020-02-29T00:00:00"},
+		{"xy", "7", "-12.3456", "0.1", "2021-03-04T05:06:07"},
+	}
+	for i, values := range want {
+		err = d.RecordAt(int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for fi, v := range values {
+			got := d.Fields[fi].StringValue()
+			if got != v {
+				t.Errorf("record %d %s = %q, want %q", i, d.Fields[fi].Name, got, v)
+			}
+		}
+	}
+	n, err := d.Field("N").Int64()
+	if err != nil || n != 7 {
+		t.Errorf("N Int64 %d, %v, want 7", n, err)
+	}
+	when, err := d.Field("AT").DateValue()
+	if err != nil || !when.Equal(at) {
+		t.Errorf("AT DateValue %v, %v, want %v", when, err, at)
+	}
+}
@@ -0,0 +1,56 @@
+package dbf
+
+import "strconv"
+
+// Value is the field for the current row as a Go value by field type:
+// string for C, int64 for N without decimals and I, float64 for other N,
+// F, B and Y, bool for L, time.Time for D and T. Blank numbers, dates and
+// logicals are nil, as are values null by _NullFlags, see IsNull. Memo
+// fields are the memo contents as []byte once AttachMemo is called,
+// otherwise the block number text. Other types are StringValue.
+func (h *DbfField) Value() (interface{}, error) {
+	if h.isMemo() && h.d != nil && h.d.memo != nil {
+		return h.MemoValue()
+	}
+	if h.raw() == nil {
+		return nil, h.parseError(ErrFieldBounds)
+	}
+	if h.nullBit >= 0 && h.IsNull() {
+		return nil, nil
+	}
+	v := h.StringValue()
+	switch h.Type {
+	case DbfFieldNumeric, DbfFieldFloat, DbfFieldInteger, DbfFieldCurrency, DbfFieldDouble:
+		if h.isMemo() {
+			// B in a dBASE table without a memo file
+			return v, nil
+		}
+		if v == "" {
+			return nil, nil
+		}
+		if h.Type == DbfFieldInteger || (h.Type == DbfFieldNumeric && h.DecimalCount() == 0) {
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err == nil {
+				return i, nil
+			}
+		}
+		f, err := h.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case DbfFieldLogical:
+		b, err := h.Bool()
+		if err == ErrUnsetLogical {
+			return nil, nil
+		}
+		return b, err
+	case DbfFieldDate, DbfFieldDateTime:
+		t, err := h.DateValue()
+		if err != nil || t.IsZero() {
+			return nil, err
+		}
+		return t, nil
+	}
+	return v, nil
+}
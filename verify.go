@@ -0,0 +1,150 @@
+package dbf
+
+import (
+	"io"
+	"strconv"
+)
+
+// VerifyReport is what Verify found
+type VerifyReport struct {
+	// Size is the size of the file
+	Size int64
+	// ExpectedSize is NumHeaderBytes + NumRecords * NumRecordBytes + 1 for
+	// the end of file marker
+	ExpectedSize int64
+	// Records is how many whole records the file holds
+	Records int64
+	// Deleted is how many of them are marked deleted
+	Deleted int64
+	// BadFlags is how many start with neither ' ' nor '*'
+	BadFlags int64
+	// EOFMarker is whether 0x1a follows the last record
+	EOFMarker bool
+	// Problems describe each inconsistency, none for a healthy file
+	Problems []string
+}
+
+// OK is true if no problems were found
+func (r *VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *VerifyReport) problem(msg string) {
+	r.Problems = append(r.Problems, msg)
+}
+
+// Verify checks the whole file against its header without disturbing
+// reading: the file size against the header, the end of file marker, and
+// the deletion flag of every record, which it counts. It is a quick health
+// check before a long run; it does not parse field values. The input must
+// be an io.ReaderAt or an io.Seeker.
+func (d *Dbf) Verify() (*VerifyReport, error) {
+	if d.reader == nil {
+		return nil, ErrClosed
+	}
+	ra, ok := d.reader.(io.ReaderAt)
+	if !ok {
+		seeker, ok := d.reader.(io.Seeker)
+		if !ok {
+			return nil, ErrNotSeekable
+		}
+		ra = &seekReaderAt{seeker, d.reader}
+		// seekTo puts the input back where reading was
+		defer d.seekTo(d.pos)
+	}
+	size, ok, err := inputSize(d.reader)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrNotSeekable
+	}
+	r := &VerifyReport{
+		Size:         size,
+		ExpectedSize: int64(d.NumHeaderBytes) + int64(d.NumRecords)*int64(d.NumRecordBytes) + 1,
+	}
+	if r.Size != r.ExpectedSize && r.Size != r.ExpectedSize-1 {
+		r.problem("file is " + strconv.FormatInt(r.Size, 10) + " bytes, the header makes it " + strconv.FormatInt(r.ExpectedSize, 10))
+	}
+
+	rowBytes := int64(d.rowWidth + 1)
+	data := size - d.dataStart
+	if data < 0 {
+		data = 0
+	}
+	r.Records = data / rowBytes
+	// read flags a chunk of whole records at a time
+	per := int64(64<<10) / rowBytes
+	if per < 1 {
+		per = 1
+	}
+	buf := make([]byte, per*rowBytes)
+scan:
+	for i := int64(0); i < r.Records; i += per {
+		n := per
+		if r.Records-i < n {
+			n = r.Records - i
+		}
+		chunk := buf[:n*rowBytes]
+		_, err = ra.ReadAt(chunk, d.dataStart+i*rowBytes)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		for rec := int64(0); rec < n; rec++ {
+			switch chunk[rec*rowBytes] {
+			case ' ':
+			case '*':
+				r.Deleted++
+			case 0x1a:
+				// an early marker, what follows is not records
+				r.problem("end of file marker at record " + strconv.FormatInt(i+rec, 10) + " of " + strconv.FormatInt(r.Records, 10))
+				r.Records = i + rec
+				break scan
+			default:
+				r.BadFlags++
+			}
+		}
+	}
+	if r.BadFlags > 0 {
+		r.problem(strconv.FormatInt(r.BadFlags, 10) + " records have a bad deletion flag")
+	}
+	if r.Records != int64(d.NumRecords) {
+		r.problem("header has " + strconv.FormatUint(uint64(d.NumRecords), 10) + " records, the file " + strconv.FormatInt(r.Records, 10))
+	}
+
+	end := d.dataStart + r.Records*rowBytes
+	if end < size {
+		var marker [1]byte
+		_, err = ra.ReadAt(marker[:], end)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.EOFMarker = marker[0] == 0x1a
+	}
+	switch rest := size - end; {
+	case !r.EOFMarker && rest > 0:
+		r.problem("no end of file marker, " + strconv.FormatInt(rest, 10) + " bytes of a partial record after record " + strconv.FormatInt(r.Records, 10))
+	case !r.EOFMarker:
+		r.problem("no end of file marker after record " + strconv.FormatInt(r.Records, 10))
+	case rest > 1:
+		r.problem(strconv.FormatInt(rest-1, 10) + " bytes after the end of file marker")
+	}
+	return r, nil
+}
+
+// seekReaderAt reads at an offset by seeking, for inputs without ReadAt
+type seekReaderAt struct {
+	seeker io.Seeker
+	reader io.Reader
+}
+
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	_, err := s.seeker.Seek(off, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(s.reader, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,620 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrRecordCount error = errors.New("dbf records written does not match declared NumRecords")
+
+// Writer emits a table, fixed width records after a header, dBASE III
+// unless WithTableVersion picks another version.
+type Writer struct {
+	Fields []DbfField
+
+	// NumRecords is written into the header. Set it before the first
+	// WriteRecord if the count is known. Close corrects it in the header
+	// of a seekable output and otherwise reports a mismatch.
+	NumRecords uint32
+
+	// Language is the language driver (codepage) byte for the header
+	Language byte
+
+	w             io.Writer
+	version       byte
+	encoder       Encoder
+	encoding      string
+	replacement   string
+	replace       bool
+	truncate      bool
+	recordLength  int
+	recordBuffer  []byte
+	headerWritten bool
+	count         uint32
+	// header is where the header starts on a seekable output, -1 if the
+	// output cannot seek
+	header int64
+	// countAtClose is set when the count is not known until Close; then
+	// held has the records of an output that cannot seek, for Close to
+	// write after the header
+	countAtClose bool
+	held         *bytes.Buffer
+	// unknownCount is set by WithUnknownCount
+	unknownCount bool
+	// memoOut and memoBlockSize are set by WithMemo, memo writes to memoOut
+	memoOut       io.WriteSeeker
+	memoBlockSize int
+	memo          *memoWriter
+
+	// appendTo is set by OpenAppend
+	appendTo *appendState
+}
+
+// ValueTooLongError is returned when a value does not fit its field width.
+type ValueTooLongError struct {
+	Field string
+	Value string
+}
+
+func (e *ValueTooLongError) Error() string {
+	return "dbf value too long for field " + e.Field + ": " + strconv.Quote(e.Value)
+}
+
+// WriterOption configures a Writer at NewWriter
+type WriterOption func(w *Writer)
+
+// WithTableVersion writes the header of another version than dBASE III
+// (0x03), for consumers that want a particular version byte: 0x04 for a
+// dBASE 7 header, whose field names may be up to 31 bytes, or 0x30 (0x31,
+// 0x32) for Visual FoxPro, with field offsets and flags in the descriptors
+// and the 263 byte database container backlink. Visual FoxPro I, Y, B and
+// T fields are written as the binary values they hold, from the text or
+// typed values WriteValues takes; other fields are text. Other versions
+// fail NewWriter.
+func WithTableVersion(version byte) WriterOption {
+	return func(w *Writer) {
+		w.version = version
+	}
+}
+
+// WithEncoder transcodes the UTF-8 values of character fields with enc,
+// e.g. one from NewEncoder or golang.org/x/text/encoding. Set Language to
+// declare the code page in the header. A character enc lacks fails
+// WriteRecord with its error, such as an *UnencodableError, unless enc
+// comes from ReplaceUnencodable or WithReplacement is used.
+func WithEncoder(enc Encoder) WriterOption {
+	return func(w *Writer) {
+		w.encoder = enc
+	}
+}
+
+// WithEncoding transcodes character fields to one of the single byte
+// Encoding names, as WithEncoder with NewEncoder, and declares it with
+// the Language byte, e.g. 0x03 for windows-1252 or 0x26 for IBM866. An
+// encoding NewEncoder does not know fails NewWriter.
+func WithEncoding(encoding string) WriterOption {
+	return func(w *Writer) {
+		w.encoding = encoding
+	}
+}
+
+// WithReplacement writes replacement, e.g. "?", for characters the
+// encoder lacks instead of failing, see ReplaceUnencodable
+func WithReplacement(replacement string) WriterOption {
+	return func(w *Writer) {
+		w.replacement = replacement
+		w.replace = true
+	}
+}
+
+// WithTruncate cuts character values longer than their field, after
+// encoding, instead of failing with a *ValueTooLongError. Without an
+// encoder UTF-8 text is cut between characters. Other fields still fail.
+func WithTruncate() WriterOption {
+	return func(w *Writer) {
+		w.truncate = true
+	}
+}
+
+// WriterVersion is the version for WithTableVersion to copy the records
+// of a table of version: Visual FoxPro and dBASE 7 keep their layouts, so
+// binary values and long names carry over, and the rest are dBASE III.
+// The memo flag is not kept, a memo file is not copied with the records.
+func WriterVersion(version byte) byte {
+	switch {
+	case isVisualFoxPro(version):
+		return version
+	case version&0x07 == 4:
+		return 0x04
+	}
+	return 0x03
+}
+
+// WithUnknownCount streams a table whose record count is not known up
+// front to an output that cannot seek, such as a pipe: the header keeps
+// NumRecords, 0 unless set, and Close does not fail with ErrRecordCount
+// when fewer or more records were written. Readers take the count from
+// the input size, see Dbf.EffectiveRecords. A seekable output still has
+// the count patched in at Close.
+func WithUnknownCount() WriterOption {
+	return func(w *Writer) {
+		w.unknownCount = true
+	}
+}
+
+// MaxNameLength is the longest field name, in bytes, the descriptors of
+// a table of version hold: 31 for dBASE 7, otherwise 10
+func MaxNameLength(version byte) int {
+	if version == 0x04 {
+		return 31
+	}
+	return 10
+}
+
+// NewWriter prepares a table with the given fields. Name, Type, Width (or
+// Length if Width is 0) and Count (decimal count) are used from each field;
+// StartPos is recalculated. Date fields default to width 8, logical
+// fields to 1 and memo fields to 10, or 4 in Visual FoxPro. Character fields wider than 255 are written with the FoxPro
+// convention of the high byte of the width in Count.
+func NewWriter(w io.Writer, fields []DbfField, opts ...WriterOption) (*Writer, error) {
+	out := &Writer{w: w, version: 0x03, header: -1}
+	for _, opt := range opts {
+		opt(out)
+	}
+	switch out.version {
+	case 0x03, 0x04, 0x30, 0x31, 0x32:
+	default:
+		return nil, fmt.Errorf("dbf Writer cannot write version %#02x", out.version)
+	}
+	if out.encoding != "" {
+		out.encoder = NewEncoder(out.encoding)
+		if out.encoder == nil {
+			return nil, errors.New("dbf Writer unknown encoding " + strconv.Quote(out.encoding))
+		}
+		out.Language = languageFor(out.encoding)
+	}
+	if out.encoder != nil && out.replace {
+		out.encoder = ReplaceUnencodable(out.encoder, out.replacement)
+	}
+	out.Fields = make([]DbfField, len(fields))
+	startPos := 0
+	for i, f := range fields {
+		if len(f.Name) == 0 || len(f.Name) > MaxNameLength(out.version) {
+			return nil, errors.New("dbf field name must be 1.." + strconv.Itoa(MaxNameLength(out.version)) + " bytes: " + strconv.Quote(f.Name))
+		}
+		if f.Width == 0 {
+			f.Width = int(f.Length)
+		}
+		if isBinaryType(out.version, f.Type) {
+			if f.Width == 0 {
+				f.Width = binaryWidth(f.Type)
+			}
+			if f.Width != binaryWidth(f.Type) {
+				return nil, errors.New("dbf Visual FoxPro binary field must be " + strconv.Itoa(binaryWidth(f.Type)) + " bytes: " + f.Name)
+			}
+		}
+		if f.Width == 0 && f.Type == DbfFieldDate {
+			f.Width = 8
+		} else if f.Width == 0 && f.Type == DbfFieldLogical {
+			f.Width = 1
+		} else if f.Width == 0 && f.Type == 'M' {
+			f.Width = 10
+			if isVisualFoxPro(out.version) {
+				f.Width = 4
+			}
+		}
+		if f.Width == 0 {
+			return nil, errors.New("dbf field has zero length: " + f.Name)
+		}
+		if f.Width > 255 && (f.Type != DbfFieldChar || f.Width > 0xffff) {
+			return nil, errors.New("dbf field too wide: " + f.Name)
+		}
+		if f.Type == DbfFieldChar {
+			f.Count = uint8(f.Width >> 8)
+		}
+		f.Length = uint8(f.Width)
+		f.StartPos = startPos
+		f.d = nil
+		startPos += f.Width
+		out.Fields[i] = f
+	}
+	if startPos+1 > 0xffff {
+		return nil, errors.New("dbf record too wide")
+	}
+	out.recordLength = startPos
+	out.recordBuffer = make([]byte, 1+startPos)
+	if out.memoOut != nil {
+		var err error
+		out.memo, err = newMemoWriter(out.version, out.memoOut, out.memoBlockSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (w *Writer) writeHeader() error {
+	if seeker, ok := w.w.(io.Seeker); ok {
+		// a pipe may have the method but fail
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			w.header = pos
+		}
+	}
+	w.headerWritten = true
+	if w.header < 0 && w.countAtClose {
+		// the count can't be patched in later, the header waits for it
+		w.held = new(bytes.Buffer)
+		return nil
+	}
+	_, err := w.w.Write(w.headerBytes())
+	return err
+}
+
+// headerBytes is the header and field descriptors with NumRecords
+func (w *Writer) headerBytes() []byte {
+	prefixLength, descriptorLength, backlink := 32, 32, 0
+	switch w.version {
+	case 0x04:
+		// language driver name and reserved bytes
+		prefixLength, descriptorLength = 68, 48
+	case 0x30, 0x31, 0x32:
+		backlink = 263
+	}
+	headerLength := prefixLength + (descriptorLength * len(w.Fields)) + 1 + backlink
+	header := make([]byte, headerLength)
+	header[0] = w.version
+	now := time.Now()
+	header[1] = byte(now.Year() - 1900)
+	header[2] = byte(now.Month())
+	header[3] = byte(now.Day())
+	binary.LittleEndian.PutUint32(header[4:8], w.NumRecords)
+	binary.LittleEndian.PutUint16(header[8:10], uint16(headerLength))
+	binary.LittleEndian.PutUint16(header[10:12], uint16(1+w.recordLength))
+	header[29] = w.Language
+	if w.memo != nil {
+		switch w.version {
+		case 0x03:
+			header[0] = 0x83
+		case 0x04:
+			header[0] = 0x8c
+		default:
+			// the Visual FoxPro table flag for a memo file
+			header[28] |= 0x02
+		}
+	}
+	for i, f := range w.Fields {
+		fd := header[prefixLength+(descriptorLength*i) : prefixLength+(descriptorLength*(i+1))]
+		if w.version == 0x04 {
+			copy(fd[0:32], f.Name)
+			fd[32] = byte(f.Type)
+			fd[33] = f.Length
+			fd[34] = f.Count
+			continue
+		}
+		copy(fd[0:11], f.Name)
+		fd[11] = byte(f.Type)
+		if isVisualFoxPro(w.version) {
+			// offset of the field in the record
+			binary.LittleEndian.PutUint32(fd[12:16], uint32(1+f.StartPos))
+			fd[18] = f.Flags
+		}
+		fd[16] = f.Length
+		fd[17] = f.Count
+	}
+	header[prefixLength+(descriptorLength*len(w.Fields))] = 0x0d
+	return header
+}
+
+// records is where records are written, held until Close or the output
+func (w *Writer) records() io.Writer {
+	if w.held != nil {
+		return w.held
+	}
+	return w.w
+}
+
+// WriteRecord writes one row. values are in field order, character fields
+// are left aligned and everything else right aligned, space padded. See
+// WriteValues for typed values.
+func (w *Writer) WriteRecord(values ...string) error {
+	return w.writeValues(values, false)
+}
+
+// WriteValues writes one row of typed values in field order. nil is
+// blank, bool is T or F, time.Time is a date, floats are rounded to the
+// field's decimal count, and anything else is formatted with fmt.Sprint.
+// In a Visual FoxPro T field a time.Time keeps its time of day, and a B
+// field takes floats unrounded.
+func (w *Writer) WriteValues(values ...interface{}) error {
+	if len(values) != len(w.Fields) {
+		return errors.New("dbf WriteValues wrong number of values, want " + strconv.Itoa(len(w.Fields)) + " got " + strconv.Itoa(len(values)))
+	}
+	text := make([]string, len(values))
+	for i, v := range values {
+		f := &w.Fields[i]
+		text[i] = formatValue(f, isBinaryType(w.version, f.Type), v)
+	}
+	return w.writeValues(text, false)
+}
+
+// formatValue is the text of a typed value for field f, for binary the
+// text putBinary reads
+func formatValue(f *DbfField, binary bool, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "T"
+		}
+		return "F"
+	case time.Time:
+		if binary && !v.IsZero() {
+			return v.UTC().Format(dateTimeMillis)
+		}
+		return FormatDate(v)
+	case float64:
+		if binary {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return formatFloat(f, v, 64)
+	case float32:
+		if binary {
+			return strconv.FormatFloat(float64(v), 'g', -1, 32)
+		}
+		return formatFloat(f, float64(v), 32)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatFloat rounds v to the decimal count of f. An F field value that
+// would not fit is written in exponent form instead.
+func formatFloat(f *DbfField, v float64, bitSize int) string {
+	s := strconv.FormatFloat(v, 'f', f.DecimalCount(), bitSize)
+	if f.Type != DbfFieldFloat || len(s) <= f.Width {
+		return s
+	}
+	for prec := f.Width; prec >= 0; prec-- {
+		e := strconv.FormatFloat(v, 'E', prec, bitSize)
+		if len(e) <= f.Width {
+			return e
+		}
+	}
+	return s
+}
+
+func (w *Writer) writeValues(values []string, deleted bool) error {
+	if len(values) != len(w.Fields) {
+		return errors.New("dbf WriteRecord wrong number of values, want " + strconv.Itoa(len(w.Fields)) + " got " + strconv.Itoa(len(values)))
+	}
+	if !w.headerWritten {
+		err := w.writeHeader()
+		if err != nil {
+			return err
+		}
+	}
+	rec := w.recordBuffer
+	if deleted {
+		rec[0] = '*'
+	} else {
+		rec[0] = ' '
+	}
+	for i, f := range w.Fields {
+		v := values[i]
+		if f.Type == 'M' && w.memo != nil {
+			block, err := w.writeMemo(v)
+			if err != nil {
+				return errors.New("dbf Writer field " + f.Name + " memo: " + err.Error())
+			}
+			if f.Width == 4 {
+				binary.LittleEndian.PutUint32(rec[1+f.StartPos:], uint32(block))
+				continue
+			}
+			v = ""
+			if block > 0 {
+				v = strconv.FormatInt(block, 10)
+			}
+		}
+		if isBinaryType(w.version, f.Type) {
+			err := putBinary(rec[1+f.StartPos:1+f.StartPos+f.Width], f.Type, v)
+			if err != nil {
+				return errors.New("dbf Writer field " + f.Name + " value " + strconv.Quote(v) + ": " + err.Error())
+			}
+			continue
+		}
+		if f.Type == DbfFieldChar {
+			var err error
+			v, err = w.encodeText(&f, v)
+			if err != nil {
+				return err
+			}
+		}
+		if len(v) > f.Width {
+			return &ValueTooLongError{f.Name, values[i]}
+		}
+		fillField(rec[1+f.StartPos:1+f.StartPos+f.Width], f.Type, v)
+	}
+	_, err := w.records().Write(rec)
+	if err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// encodeText is a character value as written, transcoded by the encoder
+// and cut to the field with WithTruncate
+func (w *Writer) encodeText(f *DbfField, v string) (string, error) {
+	if w.encoder != nil {
+		b, err := w.encoder.Bytes([]byte(v))
+		if err != nil {
+			return "", err
+		}
+		v = string(b)
+	}
+	if w.truncate && len(v) > f.Width {
+		n := f.Width
+		if w.encoder == nil {
+			// not in the middle of a UTF-8 sequence
+			for n > 0 && !utf8.RuneStart(v[n]) {
+				n--
+			}
+		}
+		v = v[:n]
+	}
+	return v, nil
+}
+
+// fillField lays out a value that fits in the field bytes fb, character
+// fields left aligned and everything else right aligned, space padded
+func fillField(fb []byte, typ DbfFieldType, v string) {
+	for j := range fb {
+		fb[j] = ' '
+	}
+	if typ == DbfFieldChar {
+		copy(fb, v)
+	} else {
+		copy(fb[len(fb)-len(v):], v)
+	}
+}
+
+// WriteRaw writes one row of already formatted field bytes, as from
+// Dbf.RawRecord of a table with the same fields.
+func (w *Writer) WriteRaw(record []byte, deleted bool) error {
+	if len(record) != w.recordLength {
+		return errors.New("dbf WriteRaw record length " + strconv.Itoa(len(record)) + " but fields need " + strconv.Itoa(w.recordLength))
+	}
+	if !w.headerWritten {
+		err := w.writeHeader()
+		if err != nil {
+			return err
+		}
+	}
+	rec := w.recordBuffer
+	if deleted {
+		rec[0] = '*'
+	} else {
+		rec[0] = ' '
+	}
+	copy(rec[1:], record)
+	_, err := w.records().Write(rec)
+	if err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Close writes the 0x1a end of file marker. On an io.WriteSeeker, such as
+// an *os.File, it then updates the record count and last update date in
+// the header to what was written and leaves the output at its end;
+// otherwise a count other than NumRecords fails with ErrRecordCount,
+// unless WithUnknownCount. It
+// does not close the underlying io.Writer, except for a Writer from
+// OpenAppend.
+func (w *Writer) Close() error {
+	if !w.headerWritten {
+		err := w.writeHeader()
+		if err != nil {
+			return err
+		}
+	}
+	if w.held != nil {
+		err := w.writeHeld()
+		if err != nil {
+			return err
+		}
+	}
+	if w.memo != nil {
+		err := w.memo.close()
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.w.Write([]byte{0x1a})
+	if w.appendTo != nil {
+		if err != nil {
+			w.appendTo.file.Close()
+			w.appendTo = nil
+			return err
+		}
+		return w.finishAppend()
+	}
+	if err != nil {
+		return err
+	}
+	if w.header >= 0 {
+		return w.finishHeader()
+	}
+	if w.count != w.NumRecords && !w.unknownCount {
+		return ErrRecordCount
+	}
+	return nil
+}
+
+// headerPatch is header bytes 1 to 7, the last update date and count
+func headerPatch(count uint32) []byte {
+	patch := make([]byte, 7)
+	now := time.Now()
+	patch[0] = byte(now.Year() - 1900)
+	patch[1] = byte(now.Month())
+	patch[2] = byte(now.Day())
+	binary.LittleEndian.PutUint32(patch[3:7], count)
+	return patch
+}
+
+// writeHeld writes the header with the count, then the held records
+func (w *Writer) writeHeld() error {
+	held := w.held
+	w.held = nil
+	w.NumRecords = w.count
+	_, err := w.w.Write(w.headerBytes())
+	if err == nil {
+		_, err = w.w.Write(held.Bytes())
+	}
+	return err
+}
+
+// finishHeader rewrites the header date and count of a seekable output
+func (w *Writer) finishHeader() error {
+	err := patchHeader(w.w.(io.WriteSeeker), w.header, w.count)
+	if err != nil {
+		return err
+	}
+	w.NumRecords = w.count
+	return nil
+}
+
+// patchHeader rewrites the date and count of the header at position
+// header of ws, leaving ws where it was
+func patchHeader(ws io.WriteSeeker, header int64, count uint32) error {
+	end, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = ws.Seek(header+1, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = ws.Write(headerPatch(count))
+	if err != nil {
+		return err
+	}
+	_, err = ws.Seek(end, io.SeekStart)
+	return err
+}
@@ -0,0 +1,111 @@
+package dbf
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// vfpFields are Visual FoxPro fields with an I field among the text
+var vfpFields = []DbfField{
+	{Name: "NAME", Type: DbfFieldChar, Width: 5},
+	{Name: "N", Type: DbfFieldInteger},
+}
+
+// checkIntegers fails unless table is a Visual FoxPro table whose N field
+// holds want
+func checkIntegers(t *testing.T, table []byte, want ...int64) {
+	t.Helper()
+	d, err := NewDbf(bytes.NewReader(table), WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Version != 0x30 {
+		t.Errorf("version %#x, want 0x30", d.Version)
+	}
+	n := d.Field("N")
+	for i, v := range want {
+		err = d.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := n.Int64()
+		if err != nil || got != v {
+			t.Errorf("record %d N = %d, %v, want %d", i, got, err, v)
+		}
+	}
+}
+
+func TestWriterVisualFoxPro(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, vfpFields, WithTableVersion(0x30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.NumRecords = 2
+	err = w.WriteRecord("ab", "42")
+	if err == nil {
+		err = w.WriteValues("zz", -7)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIntegers(t, buf.Bytes(), 42, -7)
+
+	_, err = NewWriter(&buf, []DbfField{{Name: "N", Type: DbfFieldInteger, Width: 10}}, WithTableVersion(0x30))
+	if err == nil {
+		t.Error("NewWriter took a 10 byte I field")
+	}
+}
+
+func TestAppendVisualFoxPro(t *testing.T) {
+	row := " abcde\x05\x00\x00\x00"
+	dir, path := tempTable(t, vfpTable([]fuzzField{{"NAME", 'C', 5, 0}, {"N", 'I', 4, 0}}, []string{row}))
+	defer os.RemoveAll(dir)
+	w, err := OpenAppend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.WriteValues("zz", 42)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIntegers(t, table, 5, 42)
+}
+
+func TestWriterUnknownCount(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(struct{ io.Writer }{&buf}, []DbfField{{Name: "N", Type: DbfFieldNumeric, Width: 3}}, WithUnknownCount())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		err = w.WriteValues(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDbf(bytes.NewReader(buf.Bytes()), WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.NumRecords != 0 || d.EffectiveRecords() != 3 {
+		t.Errorf("NumRecords %d EffectiveRecords %d, want 0 and 3", d.NumRecords, d.EffectiveRecords())
+	}
+}
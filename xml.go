@@ -0,0 +1,115 @@
+package dbf
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// xmlName makes a field name usable as an XML element name
+func xmlName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		case i == 0 && unicode.IsDigit(r):
+			sb.WriteByte('_')
+		default:
+			r = '_'
+		}
+		sb.WriteRune(r)
+	}
+	if sb.Len() == 0 {
+		return "_"
+	}
+	return sb.String()
+}
+
+func writeXMLText(w io.Writer, s string) {
+	xml.EscapeText(w, []byte(s))
+}
+
+// WriteXML writes every remaining record of d as a streaming XML document:
+//
+//	<records>
+//	  <schema><field name="NAME" type="C" width="30" decimals="0"/>...</schema>
+//	  <record><NAME type="C">value</NAME>...</record>
+//	  ...
+//	</records>
+//
+// Element names come from ExportOptions.XMLRoot and XMLRow; field names
+// that are not XML names have other characters replaced by '_'. With
+// IncludeDeleted, records get a deleted="true|false" attribute. Each part
+// of a split export is a complete document.
+func WriteXML(d *Dbf, w io.Writer, opts *ExportOptions) error {
+	cols, err := exportColumns(d, opts)
+	if err != nil {
+		return err
+	}
+	root, row := "records", "record"
+	if opts != nil && opts.XMLRoot != "" {
+		root = opts.XMLRoot
+	}
+	if opts != nil && opts.XMLRow != "" {
+		row = opts.XMLRow
+	}
+	root, row = xmlName(root), xmlName(row)
+	names := make([]string, len(cols))
+	types := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = xmlName(c.field.Name)
+		types[i] = string(rune(c.field.Type))
+	}
+	out, err := newExportOutput(w, opts)
+	if err != nil {
+		return err
+	}
+	startPart := func() {
+		out.WriteString(xml.Header)
+		out.WriteString("<" + root + ">\n<schema>")
+		for i, c := range cols {
+			out.WriteString("<field name=\"")
+			writeXMLText(out, c.field.Name)
+			out.WriteString("\" type=\"" + types[i] + "\" width=\"" + strconv.Itoa(c.field.Width))
+			out.WriteString("\" decimals=\"" + strconv.Itoa(c.field.DecimalCount()) + "\"/>")
+		}
+		out.WriteString("</schema>\n")
+	}
+	endPart := func() {
+		out.WriteString("</" + root + ">\n")
+	}
+	startPart()
+	for {
+		err = out.nextExported(d)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if out.full() {
+			endPart()
+			err = out.nextPart()
+			if err != nil {
+				return err
+			}
+			startPart()
+		}
+		out.WriteString("<" + row)
+		if opts.includeDeleted() {
+			out.WriteString(" deleted=\"" + strconv.FormatBool(d.IsDeleted()) + "\"")
+		}
+		out.WriteString(">")
+		for i := range cols {
+			out.WriteString("<" + names[i] + " type=\"" + types[i] + "\">")
+			writeXMLText(out, cols[i].value())
+			out.WriteString("</" + names[i] + ">")
+		}
+		out.WriteString("</" + row + ">\n")
+		out.rows++
+	}
+	endPart()
+	return out.finishPart()
+}
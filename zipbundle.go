@@ -0,0 +1,149 @@
+package dbf
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// ZipBundle is a shapefile bundle inside a zip, as the Census distributes
+// TIGER/Line files. Member names are "" for files the zip lacks.
+type ZipBundle struct {
+	Zip string
+	// Dbf reads the attribute table, member DbfMember
+	Dbf *Dbf
+
+	DbfMember string
+	ShpMember string
+	ShxMember string
+	PrjMember string
+	CpgMember string
+
+	zf      *zip.ReadCloser
+	members map[string]*zip.File
+}
+
+// OpenZipBundle opens the .dbf in the zip file at path along with the
+// names of its .shp, .shx, .prj and .cpg, found by base name in the same
+// directory of the zip, in any case. If the zip has several tables the
+// first with a .shp is used, else the first. opts are passed to NewDbf.
+func OpenZipBundle(path string, opts ...Option) (*ZipBundle, error) {
+	zf, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &ZipBundle{Zip: path, zf: zf, members: make(map[string]*zip.File, len(zf.File))}
+	var dbfs []string
+	for _, zff := range zf.File {
+		if strings.HasSuffix(zff.Name, "/") {
+			continue
+		}
+		base, ext := splitMember(zff.Name)
+		b.members[base+ext] = zff
+		if ext == ".dbf" {
+			dbfs = append(dbfs, base)
+		}
+	}
+	if len(dbfs) == 0 {
+		zf.Close()
+		return nil, errors.New("dbf: " + path + " has no .dbf")
+	}
+	base := dbfs[0]
+	for _, candidate := range dbfs {
+		if b.members[candidate+".shp"] != nil {
+			base = candidate
+			break
+		}
+	}
+	b.DbfMember = b.memberName(base + ".dbf")
+	b.ShpMember = b.memberName(base + ".shp")
+	b.ShxMember = b.memberName(base + ".shx")
+	b.PrjMember = b.memberName(base + ".prj")
+	b.CpgMember = b.memberName(base + ".cpg")
+	r, err := b.members[base+".dbf"].Open()
+	if err != nil {
+		zf.Close()
+		return nil, &ZipError{path, b.DbfMember, err}
+	}
+	b.Dbf, err = NewDbf(r, opts...)
+	if err != nil {
+		r.Close()
+		zf.Close()
+		return nil, &ZipError{path, b.DbfMember, err}
+	}
+	return b, nil
+}
+
+// splitMember is a zip member name without its extension and the
+// extension, in lower case to match names in any case
+func splitMember(name string) (base, ext string) {
+	name = strings.ToLower(name)
+	ext = path.Ext(name)
+	return name[:len(name)-len(ext)], ext
+}
+
+func (b *ZipBundle) memberName(key string) string {
+	if zff := b.members[key]; zff != nil {
+		return zff.Name
+	}
+	return ""
+}
+
+// Open reads a member of the zip by name, as ShpMember
+func (b *ZipBundle) Open(member string) (io.ReadCloser, error) {
+	if member != "" {
+		base, ext := splitMember(member)
+		if zff := b.members[base+ext]; zff != nil {
+			return zff.Open()
+		}
+	}
+	return nil, &ZipError{b.Zip, member, errors.New("no such member")}
+}
+
+// OpenShp reads the .shp geometry, for shp.NewReader
+func (b *ZipBundle) OpenShp() (io.ReadCloser, error) {
+	if b.ShpMember == "" {
+		return nil, errors.New("dbf: " + b.Zip + " has no .shp for " + b.DbfMember)
+	}
+	return b.Open(b.ShpMember)
+}
+
+// Prj is the well known text of the .prj, "" if there is none
+func (b *ZipBundle) Prj() (string, error) {
+	return b.readText(b.PrjMember)
+}
+
+// Cpg is the code page named by the .cpg, e.g. "UTF-8", "" if there is none
+func (b *ZipBundle) Cpg() (string, error) {
+	return b.readText(b.CpgMember)
+}
+
+func (b *ZipBundle) readText(member string) (string, error) {
+	if member == "" {
+		return "", nil
+	}
+	r, err := b.Open(member)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	text, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", &ZipError{b.Zip, member, err}
+	}
+	return strings.TrimSpace(string(text)), nil
+}
+
+// Close closes the table and the zip. Readers from Open must be closed
+// first.
+func (b *ZipBundle) Close() error {
+	err := b.Dbf.Close()
+	zerr := b.zf.Close()
+	if err == nil {
+		err = zerr
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package dbf
+
+import (
+	"archive/zip"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrStopWalk returned from a WalkZips callback stops the walk. It is not
+// reported as an error.
+var ErrStopWalk error = errors.New("dbf: stop WalkZips")
+
+// ZipError is one failure in WalkZips, Member is "" if the zip itself failed
+type ZipError struct {
+	Zip    string
+	Member string
+	Err    error
+}
+
+func (e *ZipError) Error() string {
+	if e.Member == "" {
+		return e.Zip + ": " + e.Err.Error()
+	}
+	return e.Zip + " " + e.Member + ": " + e.Err.Error()
+}
+
+func (e *ZipError) Unwrap() error {
+	return e.Err
+}
+
+// WalkError is every failure of a WalkZips, in the order of the paths
+type WalkError struct {
+	Errors []*ZipError
+}
+
+func (e *WalkError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	return strconv.Itoa(len(e.Errors)) + " errors walking zips, first: " + e.Errors[0].Error()
+}
+
+// WalkZips opens every .dbf member of the zip files at paths, as in Census
+// shapefile bundle downloads, and calls fn on each. workers zips are
+// processed at once, so fn must be safe to call concurrently; members of
+// one zip are visited in order by one worker. d is closed when fn returns.
+// Failures opening zips and members and errors from fn do not stop the
+// walk; they are all returned together in a *WalkError. fn may return
+// ErrStopWalk to stop early. opts are passed to NewDbf.
+func WalkZips(paths []string, workers int, fn func(zipName, member string, d *Dbf) error, opts ...Option) error {
+	if workers < 1 {
+		workers = 1
+	}
+	var stop int32
+	results := make([][]*ZipError, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = walkZip(paths[i], fn, &stop, opts)
+			}
+		}()
+	}
+	for i := range paths {
+		if atomic.LoadInt32(&stop) != 0 {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	var errs []*ZipError
+	for _, r := range results {
+		errs = append(errs, r...)
+	}
+	if len(errs) != 0 {
+		return &WalkError{errs}
+	}
+	return nil
+}
+
+func walkZip(path string, fn func(zipName, member string, d *Dbf) error, stop *int32, opts []Option) (errs []*ZipError) {
+	zf, err := zip.OpenReader(path)
+	if err != nil {
+		return []*ZipError{{path, "", err}}
+	}
+	defer zf.Close()
+	for _, zff := range zf.File {
+		if atomic.LoadInt32(stop) != 0 {
+			break
+		}
+		if !strings.HasSuffix(strings.ToLower(zff.Name), ".dbf") {
+			continue
+		}
+		r, err := zff.Open()
+		if err != nil {
+			errs = append(errs, &ZipError{path, zff.Name, err})
+			continue
+		}
+		d, err := NewDbf(r, opts...)
+		if err != nil {
+			r.Close()
+			errs = append(errs, &ZipError{path, zff.Name, err})
+			continue
+		}
+		err = fn(path, zff.Name, d)
+		d.Close()
+		if err == ErrStopWalk {
+			atomic.StoreInt32(stop, 1)
+		} else if err != nil {
+			errs = append(errs, &ZipError{path, zff.Name, err})
+		}
+	}
+	return errs
+}